@@ -2,87 +2,484 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/auth"
 	"github.com/PadmavathiSundaram/ArticleAPI/pkg/client"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/health"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/lifecycle"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/metrics"
 	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/ratelimit"
 	"github.com/PadmavathiSundaram/ArticleAPI/pkg/rest"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/rpc"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/stream"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/tlsconfig"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/tracing"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/webhook"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
-	mw "github.com/go-chi/chi/middleware"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 var (
-	port   = kingpin.Flag("port", "port").Short('p').Default("4852").Int()
-	config = kingpin.Flag("config", "config").Short('c').Default("cmd/server/config/config.standalone.json").File()
+	port     = kingpin.Flag("port", "port").Short('p').Default("4852").Int()
+	grpcPort = kingpin.Flag("grpc-port", "gRPC port").Short('g').Default("4853").Int()
+	config   = kingpin.Flag("config", "json, yaml or toml config, by extension").Short('c').Envar(model.EnvPrefix + "CONFIG").
+			Default("cmd/server/config/config.standalone.json").File()
+	watch = kingpin.Flag("watch", "poll the config file this often, eg: 10s, and reload it when modified. SIGHUP always reloads it").
+		Envar(model.EnvPrefix + "WATCH").Default("0s").Duration()
+	probe = kingpin.Flag("probe", "GET the url, eg: http://localhost:4852/readyz, and exit 0 when it answers 200. The image has no curl for healthchecks").String()
+	// settings hold the flag of every config setting, empty when not set
+	settings = settingFlags()
 )
 
+func settingFlags() map[string]*string {
+	flags := map[string]*string{}
+	for _, setting := range model.ConfigSettings() {
+		help := fmt.Sprintf("Overrides %s, also set by %s", setting.Path, setting.Env)
+		flags[setting.Path] = kingpin.Flag(setting.Flag, help).PlaceHolder(setting.Type).String()
+	}
+	return flags
+}
+
+// settingOverrides returns the config settings set by flag {path : value}
+func settingOverrides() map[string]string {
+	overrides := map[string]string{}
+	for path, value := range settings {
+		if *value != "" {
+			overrides[path] = *value
+		}
+	}
+	return overrides
+}
+
 func main() {
 
 	kingpin.Parse()
+	if *probe != "" {
+		os.Exit(runProbe(*probe))
+	}
 
-	done := make(chan bool, 1)
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
-	defer close(quit)
+	// docker stop sends SIGTERM
+	quit := make(chan os.Signal, 2)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 
-	dbClient := newDBClient()
-	server := newServer(dbClient)
-	go gracefullShutdown(server, dbClient, quit, done)
+	overrides := settingOverrides()
+	appConfig, err := model.LoadConfig(*config, overrides)
+	if err != nil {
+		logConfigError(err)
+		log.Fatalln("Could not Load Configurations.")
+	}
+	configureLogging(appConfig.Logging)
+	shutdown := lifecycle.New(appConfig.Shutdown)
+	stopTracing, err := tracing.Setup(appConfig.Tracing)
+	if err != nil {
+		log.Fatalln("Could not set up tracing.", err)
+	}
+	dbClient := newDBClient(appConfig.DBProperties)
+	// the webhooks are told about the articles created over rest and grpc
+	var webhooks webhook.Service
+	var publishers []client.Publisher
+	dispatcher := newDispatcher(dbClient, appConfig.DBProperties, appConfig.Webhooks)
+	if dispatcher != nil {
+		webhooks, publishers = dispatcher, []client.Publisher{dispatcher}
+	}
+	// the streams follow the change stream of the articles, or else the articles created by this instance
+	broker := stream.NewBroker(appConfig.Stream)
+	watcher := newWatcher(dbClient, broker, appConfig.Stream)
+	if watcher == nil {
+		publishers = append(publishers, broker)
+	}
+	articleStore := client.NewPublishingArticleStore(
+		client.NewCoalescingArticleStore(client.NewInstrumentedArticleStore(client.NewArticleStore(dbClient)),
+			time.Duration(appConfig.DBProperties.MaxTimeOut)*time.Second), publishers...)
+	keyClient := newKeyClient(dbClient, appConfig.DBProperties)
+	keyStore := client.NewAPIKeyStore(keyClient)
+	if appConfig.HTTPProperties.Auth.Enabled {
+		bootstrapAdminKey(keyStore)
+	}
+	tokenVerifier := newTokenVerifier(appConfig.HTTPProperties.Auth.JWT)
+	limiter := newLimiter(appConfig.HTTPProperties.RateLimit)
+	validator, err := model.NewArticleValidator(appConfig.Validation)
+	if err != nil {
+		log.Fatalln("Could not load the article validation rules.", err)
+	}
+	probes := newProbes(dbClient, keyClient, limiter, appConfig.Health)
+	articleDelegate := rest.NewArticleDelegate(articleStore, rest.DelegateOptions{
+		KeyStore:      keyStore,
+		TokenVerifier: tokenVerifier,
+		Limiter:       limiter,
+		Validator:     validator,
+		Probes:        probes,
+		Webhooks:      webhooks,
+		Streams:       broker,
+	})
+	routes := newLiveHandler(newRouter(articleDelegate, appConfig.HTTPProperties))
+	tlsConfig := newTLSConfig(appConfig.HTTPProperties.TLS)
+	server := newServer(routes, tlsConfig)
+	grpcServer := rpc.NewServer(articleStore, rpc.ServerOptions{
+		Validator:     validator,
+		KeyStore:      keyStore,
+		TokenVerifier: tokenVerifier,
+		Limiter:       limiter,
+		TLSConfig:     tlsConfig,
+		Auth:          appConfig.HTTPProperties.Auth,
+		RateLimit:     appConfig.HTTPProperties.RateLimit,
+		RoleMapping:   appConfig.HTTPProperties.TLS.RoleMapping,
+	})
+	adminServer := newAdminServer(appConfig.HTTPProperties.Metrics)
+	configReloader := &reloader{path: (*config).Name(), overrides: overrides, started: appConfig, applied: appConfig,
+		delegate: articleDelegate, routes: routes, done: make(chan struct{})}
 
-	log.Infoln("Server is ready to handle requests at", *port)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalln("Could not listen on", *port, err)
+	shutdown.OnShutdown(lifecycle.NotReady, "probes", func(ctx context.Context) error {
+		probes.Drain()
+		return nil
+	})
+	shutdown.OnShutdown(lifecycle.Listeners, "http", shutdownServer(server))
+	shutdown.OnShutdown(lifecycle.Listeners, "grpc", stopGRPC(grpcServer))
+	// open streams would hold the http server up until the grace period is over, their clients reconnect elsewhere
+	shutdown.OnShutdown(lifecycle.Listeners, "streams", func(ctx context.Context) error {
+		broker.Close()
+		return nil
+	})
+	shutdown.OnShutdown(lifecycle.Workers, "config reload", configReloader.stop)
+	if dispatcher != nil {
+		shutdown.OnShutdown(lifecycle.Workers, "webhooks", dispatcher.Stop)
+	}
+	if watcher != nil {
+		shutdown.OnShutdown(lifecycle.Workers, "change stream", watcher.Stop)
+	}
+	// metrics stay scrapeable while the requests drain
+	if adminServer != nil {
+		shutdown.OnShutdown(lifecycle.Telemetry, "admin", shutdownServer(adminServer))
+	}
+	shutdown.OnShutdown(lifecycle.Telemetry, "tracing", func(ctx context.Context) error {
+		stopTracing()
+		return nil
+	})
+	if closer, ok := limiter.(io.Closer); ok {
+		shutdown.OnShutdown(lifecycle.Storage, "ratelimit", func(ctx context.Context) error { return closer.Close() })
 	}
+	shutdown.OnShutdown(lifecycle.Storage, "database", func(ctx context.Context) error { return dbClient.DBDestroy() })
 
-	<-done
-	log.Infoln("Server stopped")
+	if dispatcher != nil {
+		if err := dispatcher.Start(context.Background()); err != nil {
+			log.Fatalln("Could not resume the webhook deliveries.", err)
+		}
+	}
+	go serveHTTP(server, shutdown)
+	go serveGRPC(grpcServer, tlsConfig != nil, shutdown)
+	if adminServer != nil {
+		go serveAdmin(adminServer, shutdown)
+	}
+	go configReloader.watch(hup, *watch)
 
+	code := shutdown.Wait(quit)
+	log.Infoln("Server stopped, exit code", code)
+	os.Exit(code)
 }
 
-// REF: https://marcofranssen.nl/go-webserver-with-graceful-shutdown/
-func gracefullShutdown(server *http.Server, dbClient client.DBClient, quit <-chan os.Signal, done chan<- bool) {
-	<-quit
-	log.Infoln("Server is shutting down...")
+// shutdownServer stops accepting connections and waits for the requests in flight,
+// those still running once ctx is done are cut off
+func shutdownServer(server *http.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		server.SetKeepAlivesEnabled(false)
+		if err := server.Shutdown(ctx); err != nil {
+			server.Close()
+			return err
+		}
+		return nil
+	}
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// stopGRPC waits for the calls in flight, those still running once ctx is done are cancelled
+func stopGRPC(grpcServer *grpc.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	}
+}
 
-	log.Fatalln(dbClient.DBDestroy())
-	server.SetKeepAlivesEnabled(false)
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalln("Could not gracefully shutdown the server: ", err)
+func serveHTTP(server *http.Server, shutdown *lifecycle.Manager) {
+	var err error
+	if server.TLSConfig != nil {
+		log.Infoln("Server is ready to handle TLS requests at", *port)
+		// the certificates are served by the TLS config
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		log.Infoln("Server is ready to handle requests at", *port)
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		shutdown.Fail(fmt.Errorf("could not listen on %d: %v", *port, err))
 	}
-	close(done)
 }
-func newDBClient() client.DBClient {
-	config, err := model.LoadConfig(*config)
+
+// runProbe returns the exit code of a healthcheck of url. The certificate of an https url is not verified,
+// the probe checks the app it runs next to.
+func runProbe(url string) int {
+	httpClient := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	resp, err := httpClient.Get(url)
 	if err != nil {
-		log.Fatalln("Could not Load Configurations.", err)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintln(os.Stderr, url, resp.Status)
+		return 1
+	}
+	return 0
+}
+
+// logConfigError lists every invalid setting of a configuration that could not be loaded
+func logConfigError(err error) {
+	if invalid, ok := err.(*model.Error); ok && len(invalid.Violations) > 0 {
+		for _, violation := range invalid.Violations {
+			log.WithFields(log.Fields{"setting": violation.Field, "rule": violation.Rule}).Errorln(violation.Message)
+		}
+		return
 	}
+	log.Errorln(err)
+}
+
+// configureLogging sets the level and format of the logs, json unless text is configured
+func configureLogging(LogProperties model.LogProperties) {
+	if LogProperties.Level != "" {
+		level, err := log.ParseLevel(LogProperties.Level)
+		if err != nil {
+			log.Fatalln("Could not set the log level.", err)
+		}
+		log.SetLevel(level)
+	}
+	switch LogProperties.Format {
+	case "", "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	case "text":
+		log.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+	default:
+		log.Fatalln("Could not set the log format, expected json or text, got", LogProperties.Format)
+	}
+}
+
+func newDBClient(DBProperties model.DBProperties) client.DBClient {
 	DBClient := client.NewDBClient()
-	if err := DBClient.DBInit(config.DBProperties); err != nil {
+	if err := DBClient.DBInit(DBProperties); err != nil {
 		log.Fatalln("Could not Connect to the Database.", err)
 	}
 	return DBClient
 }
-func newServer(DBClient client.DBClient) *http.Server {
-	router := chi.NewRouter()
-	router.Use(mw.Logger)
-	router.Use(middleware.Timeout(5 * time.Second))
 
-	articlestore := client.NewArticleStore(DBClient)
-	articleDelegate := rest.NewArticleDelegate(articlestore)
-	rest.SetupRoutes(router, articleDelegate)
-	server := &http.Server{
+// newKeyClient returns a client of the API key collection, sharing the connection of dbClient
+func newKeyClient(dbClient client.DBClient, DBProperties model.DBProperties) client.DBClient {
+	collectionName := DBProperties.KeyCollectionName
+	if collectionName == "" {
+		collectionName = "apikeys"
+	}
+	keyClient, err := dbClient.Collection(collectionName, client.APIKeyIndexes)
+	if err != nil {
+		log.Fatalln("Could not load the API key collection.", err)
+	}
+	return keyClient
+}
+
+// newDispatcher returns the dispatcher of the webhooks, its collections share the connection of dbClient.
+// It is nil when the webhooks are disabled.
+func newDispatcher(dbClient client.DBClient, DBProperties model.DBProperties, WebhookProperties model.WebhookProperties) *webhook.Dispatcher {
+	if !WebhookProperties.Enabled {
+		return nil
+	}
+	webhookClient, err := dbClient.Collection(DBProperties.WebhookCollectionName, client.WebhookIndexes)
+	if err != nil {
+		log.Fatalln("Could not load the webhook collection.", err)
+	}
+	deliveryClient, err := dbClient.Collection(DBProperties.DeliveryCollectionName, client.DeliveryIndexes)
+	if err != nil {
+		log.Fatalln("Could not load the webhook delivery collection.", err)
+	}
+	return webhook.NewDispatcher(client.NewWebhookStore(webhookClient, deliveryClient), WebhookProperties)
+}
+
+// newWatcher starts watching the change stream of the articles for the streams of broker. It is nil when the streams
+// follow the articles created by this instance: with the memory source, or when the database has no change streams with auto.
+func newWatcher(dbClient client.DBClient, broker *stream.Broker, StreamProperties model.StreamProperties) *stream.Watcher {
+	if StreamProperties.Source == "memory" {
+		return nil
+	}
+	watcher := stream.NewWatcher(dbClient, broker)
+	err := watcher.Start(context.Background())
+	if err == nil {
+		log.Infoln("Streaming the articles of the change stream")
+		return watcher
+	}
+	if StreamProperties.Source == "mongo" {
+		log.Fatalln("Could not open the change stream of the articles.", err)
+	}
+	log.WithError(err).Infoln("No change streams, streaming the articles created by this instance only")
+	return nil
+}
+
+// newTokenVerifier returns nil when no JWKS is configured, bearer tokens are then refused
+func newTokenVerifier(JWTProperties model.JWTProperties) auth.TokenVerifier {
+	if JWTProperties.JWKSFile == "" && JWTProperties.JWKSURL == "" {
+		return nil
+	}
+	verifier, err := auth.NewJWTVerifier(JWTProperties)
+	if err != nil {
+		log.Fatalln("Could not load the JWKS.", err)
+	}
+	return verifier
+}
+
+// newProbes registers the readiness checks: the database ping, the indexes of both collections,
+// the rate limit store when it is shared and the disks of the configured paths
+func newProbes(dbClient client.DBClient, keyClient client.DBClient, limiter ratelimit.Limiter, HealthProperties model.HealthProperties) *health.Registry {
+	probes := health.NewRegistry(HealthProperties)
+	probes.Register("database", health.CheckerFunc(dbClient.Ping))
+	probes.Register("articles:indexes", health.CheckerFunc(dbClient.CheckIndexes))
+	probes.Register("apikeys:indexes", health.CheckerFunc(keyClient.CheckIndexes))
+	if checker, ok := limiter.(health.Checker); ok {
+		probes.Register("ratelimit", checker)
+	}
+	return probes
+}
+
+func newLimiter(RateLimitProperties model.RateLimitProperties) ratelimit.Limiter {
+	limiter, err := ratelimit.New(RateLimitProperties)
+	if err != nil {
+		log.Fatalln("Could not connect to the rate limit store.", err)
+	}
+	return limiter
+}
+
+// bootstrapAdminKey mints an admin key when there is no active one, so the first keys can be minted.
+// The key is printed once to stderr, never logged, so that it is not kept or shipped along with the logs.
+func bootstrapAdminKey(keyStore client.APIKeyStore) {
+	keys, err := keyStore.ListAPIKeys(context.Background())
+	if err != nil {
+		log.Fatalln("Could not list API keys.", err)
+	}
+	for _, key := range keys {
+		if key.RevokedAt == nil && key.HasScope(model.ScopeAdmin) {
+			return
+		}
+	}
+	key, err := keyStore.CreateAPIKey(context.Background(), "bootstrap", []string{model.ScopeAdmin})
+	if err != nil {
+		log.Fatalln("Could not mint the bootstrap admin API key.", err)
+	}
+	log.WithField("key", key.ID).Warnln("No admin API key found, minted one and printed it to stderr - store it and revoke it once other keys are minted")
+	fmt.Fprintln(os.Stderr, "Bootstrap admin API key:", key.Key)
+}
+
+// serveGRPC serves the calls on the gRPC port, with the TLS config of the http server when secure
+func serveGRPC(grpcServer *grpc.Server, secure bool, shutdown *lifecycle.Manager) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", *grpcPort))
+	if err != nil {
+		shutdown.Fail(fmt.Errorf("could not listen on %d: %v", *grpcPort, err))
+		return
+	}
+	if secure {
+		log.Infoln("gRPC server is ready to handle TLS requests at", *grpcPort)
+	} else {
+		log.Infoln("gRPC server is ready to handle requests at", *grpcPort)
+	}
+	if err := grpcServer.Serve(listener); err != nil {
+		shutdown.Fail(fmt.Errorf("could not serve gRPC on %d: %v", *grpcPort, err))
+	}
+}
+
+// newAdminServer serves the metrics apart from the api, nil when they are disabled
+func newAdminServer(MetricsProperties model.MetricsProperties) *http.Server {
+	if !MetricsProperties.Enabled {
+		return nil
+	}
+	address := MetricsProperties.Address
+	if address == "" {
+		address = ":4854"
+	}
+	router := chi.NewRouter()
+	router.Handle("/metrics", metrics.Handler())
+	router.Handle("/debug/coalesced", metrics.CoalescedHandler())
+	return &http.Server{
 		Handler: router,
-		Addr:    fmt.Sprintf(":%d", *port),
+		Addr:    address,
+	}
+}
+func serveAdmin(adminServer *http.Server, shutdown *lifecycle.Manager) {
+	log.Infoln("Admin server is ready to serve metrics at", adminServer.Addr)
+	if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		shutdown.Fail(fmt.Errorf("could not listen on %s: %v", adminServer.Addr, err))
+	}
+}
+
+// newRouter sets up the routes of the api for the settings, again on every reload
+func newRouter(articleDelegate rest.Delegate, HTTPProperties model.HTTPProperties) http.Handler {
+	router := chi.NewRouter()
+	router.Use(requestTimeout(5 * time.Second))
+	rest.SetupRoutes(router, articleDelegate, HTTPProperties)
+	return router
+}
+
+// requestTimeout cancels the context of the requests after timeout, but the long lived ones
+func requestTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	limit := middleware.Timeout(timeout)
+	return func(next http.Handler) http.Handler {
+		limited := limit(next)
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if rest.LongLived(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limited.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// newTLSConfig loads the certificates the http and gRPC servers are served with, nil when none is configured
+func newTLSConfig(TLSProperties model.TLSProperties) *tls.Config {
+	if TLSProperties.CertFile == "" {
+		return nil
+	}
+	tlsConfig, err := tlsconfig.NewServerConfig(TLSProperties)
+	if err != nil {
+		log.Fatalln("Could not load the TLS certificates.", err)
+	}
+	return tlsConfig
+}
+
+// newServer serves TLS when tlsConfig is set
+func newServer(handler http.Handler, tlsConfig *tls.Config) *http.Server {
+	return &http.Server{
+		Handler:   handler,
+		Addr:      fmt.Sprintf(":%d", *port),
+		TLSConfig: tlsConfig,
 	}
-	return server
 }
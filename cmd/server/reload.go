@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/rest"
+	log "github.com/sirupsen/logrus"
+)
+
+// restartSettings are only read at startup, a change to them is logged and waits for a restart.
+// Every other setting, eg: the logging, cache control, rate limits, request and graphql limits
+// or the deprecations, is applied to the running server on reload.
+var restartSettings = []string{
+	"DBProperties.",
+	"Validation.", // shared with the gRPC server
+	"Tracing.",
+	"Health.",
+	"Shutdown.",
+	"Webhooks.",            // the dispatcher and its workers are set up once
+	"Stream.",              // so is the broker the open streams follow
+	"HTTPProperties.Auth.", // enabling auth needs the bootstrap admin key
+	"HTTPProperties.Metrics.",
+	"HTTPProperties.RateLimit.RedisURL",
+	// the certificates themselves are reloaded once rotated
+	"HTTPProperties.TLS.CertFile",
+	"HTTPProperties.TLS.KeyFile",
+	"HTTPProperties.TLS.MinVersion",
+	"HTTPProperties.TLS.ClientCAFile",
+	"HTTPProperties.TLS.ClientAuth",
+}
+
+func requiresRestart(path string) bool {
+	for _, prefix := range restartSettings {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// liveHandler serves the requests with the latest routes swapped in,
+// requests in flight finish on the routes they started with
+type liveHandler struct {
+	routes atomic.Value
+}
+
+func newLiveHandler(routes http.Handler) *liveHandler {
+	h := &liveHandler{}
+	h.swap(routes)
+	return h
+}
+
+func (h *liveHandler) swap(routes http.Handler) {
+	h.routes.Store(&routes)
+}
+
+func (h *liveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*h.routes.Load().(*http.Handler)).ServeHTTP(w, r)
+}
+
+// reloader loads the configuration again, from the same file, environment and flags,
+// and applies the settings that changed to the running server
+type reloader struct {
+	mu        sync.Mutex
+	path      string
+	overrides map[string]string
+	started   *model.Config // changes of the restartSettings are told apart from it
+	applied   *model.Config
+	delegate  rest.Delegate
+	routes    *liveHandler
+	done      chan struct{}
+}
+
+// stop ends the watch. SIGHUP is ignored from then on, its default action would kill the process mid shutdown
+func (r *reloader) stop(ctx context.Context) error {
+	signal.Ignore(syscall.SIGHUP)
+	// waits for a reload in progress
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	close(r.done)
+	return nil
+}
+
+// watch reloads on every SIGHUP and, when interval is set, whenever the modification time of the file changes
+func (r *reloader) watch(hup <-chan os.Signal, interval time.Duration) {
+	var ticks <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	modified := r.modified()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-hup:
+			log.Infoln("SIGHUP received, reloading the configuration", r.path)
+			r.reload()
+		case <-ticks:
+			// a missing file, eg: while it is replaced, is waited for
+			if m := r.modified(); !m.IsZero() && !m.Equal(modified) {
+				modified = m
+				log.Infoln("Configuration modified, reloading", r.path)
+				r.reload()
+			}
+		}
+	}
+}
+
+func (r *reloader) modified() time.Time {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// reload keeps the running configuration when the new one is invalid. The routes of the new
+// configuration are built before they are swapped in, requests see either the old or the new settings.
+// The restartSettings keep their startup value until then, eg: a reload cannot turn the auth off.
+func (r *reloader) reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file, err := os.Open(r.path)
+	if err != nil {
+		log.WithError(err).Errorln("Could not reload the configuration, the running one is kept")
+		return
+	}
+	defer file.Close()
+	next, err := model.LoadConfig(file, r.overrides)
+	if err != nil {
+		logConfigError(err)
+		log.Errorln("Could not reload the configuration, the running one is kept")
+		return
+	}
+
+	applied := next.Restore(r.started, requiresRestart)
+	reloaded := r.applied.Changed(applied)
+	r.routes.swap(newRouter(r.delegate, applied.HTTPProperties))
+	configureLogging(applied.Logging)
+	r.applied = applied
+
+	for _, path := range reloaded {
+		log.WithField("setting", path).Infoln("Setting reloaded")
+	}
+	// listed on every reload until the restart
+	for _, path := range r.started.Changed(next) {
+		if requiresRestart(path) {
+			log.WithField("setting", path).Warnln("Setting changed, it is applied on restart")
+		}
+	}
+	log.Infoln("Configuration reloaded,", len(reloaded), "settings changed")
+}
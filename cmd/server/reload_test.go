@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubArticleStore struct{}
+
+func (stubArticleStore) HealthCheck() bool { return true }
+
+func (stubArticleStore) CreatArticle(ctx context.Context, article *model.Article) error { return nil }
+
+func (stubArticleStore) ReadArticleByID(ctx context.Context, articleID string) (*model.Article, error) {
+	return &model.Article{ArticleID: articleID}, nil
+}
+
+func (stubArticleStore) ReadArticlesByIDs(ctx context.Context, articleIDs []string) ([]*model.Article, error) {
+	return nil, nil
+}
+
+func (stubArticleStore) SearchTagsByDate(ctx context.Context, date string, tag string) ([]*model.Tagsview, error) {
+	return nil, nil
+}
+
+func (stubArticleStore) ListTags(ctx context.Context, date string) ([]*model.Tagsview, error) {
+	return nil, nil
+}
+
+func TestReloadKeepsRestartSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reload")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	load := func(document string) *model.Config {
+		assert.NoError(t, ioutil.WriteFile(path, []byte(document), 0600))
+		file, err := os.Open(path)
+		assert.NoError(t, err)
+		defer file.Close()
+		config, err := model.LoadConfig(file, nil)
+		assert.NoError(t, err)
+		return config
+	}
+
+	started := load(`{"HTTPProperties": {"Auth": {"Enabled": true}}}`)
+	delegate := rest.NewArticleDelegate(stubArticleStore{}, rest.DelegateOptions{})
+	r := &reloader{path: path, started: started, applied: started, delegate: delegate,
+		routes: newLiveHandler(newRouter(delegate, started.HTTPProperties))}
+	status := func() int {
+		w := httptest.NewRecorder()
+		r.routes.ServeHTTP(w, httptest.NewRequest("GET", "/api/articles/1", nil))
+		return w.Code
+	}
+	assert.Equal(t, 401, status())
+
+	load(`{"HTTPProperties": {"Auth": {"Enabled": false}, "CacheControl": {"/api/articles/{id}": "max-age=60"}}}`)
+	r.reload()
+	assert.Equal(t, 401, status(), "the auth is only turned off on restart")
+	assert.True(t, r.applied.HTTPProperties.Auth.Enabled)
+	assert.Equal(t, map[string]string{"/api/articles/{id}": "max-age=60"}, r.applied.HTTPProperties.CacheControl)
+}
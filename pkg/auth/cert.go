@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"crypto/x509"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// CertNames lists the names of a client certificate: its common name, then its DNS, URI and email names
+func CertNames(cert *x509.Certificate) []string {
+	var names []string
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return append(names, cert.EmailAddresses...)
+}
+
+// CertPrincipal identifies the holder of a verified client certificate. Roles are granted to the names
+// of the certificate found in roleMapping {name : role}, a certificate without mapped names has no scopes.
+func CertPrincipal(cert *x509.Certificate, roleMapping map[string]string) *model.Principal {
+	names := CertNames(cert)
+	subject := cert.Subject.String()
+	if len(names) > 0 {
+		subject = names[0]
+	}
+	principal := &model.Principal{Subject: "cert:" + subject, Method: model.AuthMTLS}
+	granted := map[string]bool{}
+	for _, name := range names {
+		role, ok := roleMapping[name]
+		if _, known := model.RoleScopes[role]; !ok || !known || granted[role] {
+			continue
+		}
+		granted[role] = true
+		principal.Roles = append(principal.Roles, role)
+		principal.Scopes = append(principal.Scopes, model.RoleScopes[role]...)
+	}
+	return principal
+}
+
+// KeyPrincipal identifies the holder of an authenticated API key, granted the scopes of the key
+func KeyPrincipal(key *model.APIKey) *model.Principal {
+	return &model.Principal{Subject: "apikey:" + key.ID, Method: model.AuthAPIKey, Scopes: key.Scopes}
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertPrincipal(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/ns/reporting")
+	cert := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "reporting"},
+		DNSNames: []string{"reporting.internal"},
+		URIs:     []*url.URL{spiffe},
+	}
+	assert.Equal(t, []string{"reporting", "reporting.internal", "spiffe://example.org/ns/reporting"}, CertNames(cert))
+
+	principal := CertPrincipal(cert, map[string]string{
+		"spiffe://example.org/ns/reporting": model.RoleReader,
+		"reporting.internal":                model.RoleReader,
+		"reporting":                         "root",
+		"publisher":                         model.RoleEditor,
+	})
+	assert.Equal(t, &model.Principal{
+		Subject: "cert:reporting",
+		Method:  model.AuthMTLS,
+		Roles:   []string{model.RoleReader},
+		Scopes:  []string{model.ScopeArticlesRead},
+	}, principal)
+
+	// unmapped certificates are identified without scopes
+	principal = CertPrincipal(&x509.Certificate{Subject: pkix.Name{Organization: []string{"Example"}}}, nil)
+	assert.Equal(t, "cert:O=Example", principal.Subject)
+	assert.Empty(t, principal.Scopes)
+}
@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultJWKSRefreshInterval = time.Hour
+	// an unknown kid triggers a refetch of the JWKS at most this often
+	minJWKSRefetchInterval = time.Minute
+)
+
+// jwk is a JSON Web Key as per RFC 7517, only the members used for verification
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// verificationKey is a parsed jwk, key is an *rsa.PublicKey, *ecdsa.PublicKey or []byte
+type verificationKey struct {
+	kid string
+	alg string
+	key interface{}
+}
+
+// keySet holds the keys of a JWKS file or URL, remote sets are refetched lazily
+type keySet struct {
+	file            string
+	url             string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	group     singleflight.Group
+	mu        sync.Mutex
+	keys      []verificationKey
+	fetchedAt time.Time
+}
+
+func newKeySet(props model.JWTProperties) (*keySet, error) {
+	set := &keySet{
+		file:            props.JWKSFile,
+		url:             props.JWKSURL,
+		refreshInterval: time.Duration(props.JWKSRefreshInterval) * time.Second,
+		client:          &http.Client{Timeout: 10 * time.Second},
+	}
+	if set.refreshInterval <= 0 {
+		set.refreshInterval = defaultJWKSRefreshInterval
+	}
+	if err := set.load(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// lookup returns the keys that may have signed a token with the kid and alg
+func (set *keySet) lookup(kid string, alg string) []verificationKey {
+	set.mu.Lock()
+	keys, fetchedAt := set.keys, set.fetchedAt
+	set.mu.Unlock()
+
+	if set.url != "" && set.file == "" {
+		age := time.Since(fetchedAt)
+		if age > minJWKSRefetchInterval && !hasKid(keys, kid) {
+			// the issuer may have rotated its keys, the token waits for the refetch
+			set.group.Do(set.url, set.refresh)
+			set.mu.Lock()
+			keys = set.keys
+			set.mu.Unlock()
+		} else if age > set.refreshInterval {
+			// keep verifying with the keys already known until the refetched ones are swapped in
+			set.group.DoChan(set.url, set.refresh)
+		}
+	}
+
+	var matching []verificationKey
+	for _, key := range keys {
+		if kid != "" && key.kid != kid {
+			continue
+		}
+		if key.alg != "" && key.alg != alg {
+			continue
+		}
+		matching = append(matching, key)
+	}
+	return matching
+}
+
+func hasKid(keys []verificationKey, kid string) bool {
+	for _, key := range keys {
+		if key.kid == kid {
+			return true
+		}
+	}
+	return kid == ""
+}
+
+// refresh refetches the remote JWKS outside of set.mu, concurrent lookups share a refresh through set.group
+func (set *keySet) refresh() (interface{}, error) {
+	keys, err := set.read()
+	set.mu.Lock()
+	// a failed fetch is not retried before the next interval either
+	set.fetchedAt = time.Now()
+	if err == nil {
+		set.keys = keys
+	}
+	set.mu.Unlock()
+	if err != nil {
+		// keep verifying with the keys already known
+		log.Errorln("Could not refresh the JWKS from", set.url, err)
+	}
+	return nil, err
+}
+
+// load reads the JWKS before the set is shared
+func (set *keySet) load() error {
+	keys, err := set.read()
+	if err != nil {
+		return err
+	}
+	set.keys = keys
+	set.fetchedAt = time.Now()
+	return nil
+}
+
+// read reads and parses the JWKS of the file or URL
+func (set *keySet) read() ([]verificationKey, error) {
+	var data []byte
+	var err error
+	if set.file != "" {
+		data, err = ioutil.ReadFile(set.file)
+	} else {
+		data, err = set.fetch()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %v", err)
+	}
+	var keys []verificationKey
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(k)
+		if err != nil {
+			// keys of other types or curves are published next to ours, they are left out
+			log.WithField("kid", k.Kid).Warnln("Skipping the JWK:", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no usable JWK in the JWKS")
+	}
+	log.Infoln("Loaded", len(keys), "JWKS keys")
+	return keys, nil
+}
+
+func (set *keySet) fetch() ([]byte, error) {
+	resp, err := set.client.Get(set.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS request failed with status %d", resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func parseJWK(k jwk) (verificationKey, error) {
+	key := verificationKey{kid: k.Kid, alg: k.Alg}
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return key, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return key, err
+		}
+		key.key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		if k.Crv != "P-256" {
+			return key, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return key, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return key, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return key, fmt.Errorf("point is not on P-256")
+		}
+		key.key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return key, err
+		}
+		key.key = secret
+	default:
+		return key, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+	return key, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
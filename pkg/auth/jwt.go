@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// leeway tolerated on exp and nbf for clock skew between the issuer and the api
+const leeway = time.Minute
+
+// TokenVerifier authenticates the holder of a bearer token
+type TokenVerifier interface {
+	Verify(token string) (*model.Principal, error)
+}
+
+// NewJWTVerifier verifies RS256, ES256 and HS256 signed tokens against the configured JWKS
+func NewJWTVerifier(props model.JWTProperties) (TokenVerifier, error) {
+	keys, err := newKeySet(props)
+	if err != nil {
+		return nil, err
+	}
+	rolesClaim := props.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = "roles"
+	}
+	return &jwtVerifier{keys: keys, props: props, rolesClaim: rolesClaim, now: time.Now}, nil
+}
+
+type jwtVerifier struct {
+	keys       *keySet
+	props      model.JWTProperties
+	rolesClaim string
+	now        func() time.Time
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks the signature and the registered claims of the token,
+// then maps its roles claim to the scopes of the principal
+func (v *jwtVerifier) Verify(token string) (*model.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalidToken("malformed token")
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalidToken("malformed header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalidToken("malformed signature")
+	}
+	if !v.verifySignature(header, parts[0]+"."+parts[1], signature) {
+		return nil, invalidToken("invalid signature")
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, invalidToken("malformed claims")
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, invalidToken("missing sub claim")
+	}
+	principal := &model.Principal{Subject: subject, Method: model.AuthJWT}
+	for _, role := range v.roles(claims) {
+		principal.Roles = append(principal.Roles, role)
+		principal.Scopes = append(principal.Scopes, model.RoleScopes[role]...)
+	}
+	return principal, nil
+}
+
+func (v *jwtVerifier) verifySignature(header jwtHeader, signingInput string, signature []byte) bool {
+	hash := sha256.Sum256([]byte(signingInput))
+	for _, key := range v.keys.lookup(header.Kid, header.Alg) {
+		switch k := key.key.(type) {
+		case *rsa.PublicKey:
+			if header.Alg == "RS256" && rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], signature) == nil {
+				return true
+			}
+		case *ecdsa.PublicKey:
+			// ES256 signatures are the fixed size concatenation of r and s
+			if header.Alg == "ES256" && len(signature) == 64 {
+				r := new(big.Int).SetBytes(signature[:32])
+				s := new(big.Int).SetBytes(signature[32:])
+				if ecdsa.Verify(k, hash[:], r, s) {
+					return true
+				}
+			}
+		case []byte:
+			if header.Alg == "HS256" {
+				mac := hmac.New(sha256.New, k)
+				mac.Write([]byte(signingInput))
+				if hmac.Equal(mac.Sum(nil), signature) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (v *jwtVerifier) checkClaims(claims map[string]interface{}) error {
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return invalidToken("missing exp claim")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(leeway)) {
+		return invalidToken("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+		return invalidToken("token not valid yet")
+	}
+	if v.props.Issuer != "" && claims["iss"] != v.props.Issuer {
+		return invalidToken("unexpected issuer")
+	}
+	if v.props.Audience != "" && !hasAudience(claims["aud"], v.props.Audience) {
+		return invalidToken("unexpected audience")
+	}
+	return nil
+}
+
+// roles reads the roles claim, a string or a list of strings, unknown values are dropped
+func (v *jwtVerifier) roles(claims map[string]interface{}) []string {
+	var values []string
+	switch claim := claims[v.rolesClaim].(type) {
+	case string:
+		values = strings.Fields(claim)
+	case []interface{}:
+		for _, value := range claim {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	var roles []string
+	for _, value := range values {
+		role, ok := v.props.RoleMapping[value]
+		if !ok {
+			role = value
+		}
+		if _, known := model.RoleScopes[role]; known {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// hasAudience checks the aud claim, a string or a list of strings
+func hasAudience(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func invalidToken(reason string) error {
+	return model.Errorf(model.ErrUnauthorized, "Invalid bearer token - %s", reason)
+}
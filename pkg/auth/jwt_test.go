@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	rsaKey, _   = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _    = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	hmacSecret  = []byte("0123456789abcdef0123456789abcdef")
+	testNow     = time.Date(2019, 10, 2, 10, 30, 0, 0, time.UTC)
+	b64         = base64.RawURLEncoding.EncodeToString
+	testJWKSDoc = map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "use": "sig",
+			"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(pad32(ecKey.X)), "y": b64(pad32(ecKey.Y))},
+		{"kty": "oct", "kid": "hmac", "alg": "HS256", "k": b64(hmacSecret)},
+	}}
+)
+
+func pad32(i *big.Int) []byte {
+	b := make([]byte, 32)
+	return append(b[:32-len(i.Bytes())], i.Bytes()...)
+}
+
+func sign(t *testing.T, alg string, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	hash := sha256.Sum256([]byte(input))
+	var signature []byte
+	switch alg {
+	case "RS256":
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, hash[:])
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, hash[:])
+		assert.NoError(t, err)
+		signature = append(pad32(r), pad32(s)...)
+	case "HS256":
+		mac := hmac.New(sha256.New, hmacSecret)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	}
+	return input + "." + b64(signature)
+}
+
+func writeJWKS(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "jwks")
+	assert.NoError(t, err)
+	data, _ := json.Marshal(testJWKSDoc)
+	file := filepath.Join(dir, "jwks.json")
+	assert.NoError(t, ioutil.WriteFile(file, data, 0600))
+	return file
+}
+
+func claims(overrides map[string]interface{}) map[string]interface{} {
+	c := map[string]interface{}{
+		"sub":   "jane",
+		"iss":   "https://sso.example.com",
+		"aud":   []string{"articleapi", "other"},
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"roles": []string{"writers"},
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(c, k)
+			continue
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func TestJWTVerifier(t *testing.T) {
+	file := writeJWKS(t)
+	defer os.RemoveAll(filepath.Dir(file))
+	verifier, err := NewJWTVerifier(model.JWTProperties{
+		JWKSFile:    file,
+		Issuer:      "https://sso.example.com",
+		Audience:    "articleapi",
+		RoleMapping: map[string]string{"writers": model.RoleEditor},
+	})
+	assert.NoError(t, err)
+	verifier.(*jwtVerifier).now = func() time.Time { return testNow }
+
+	testScenarios := []struct {
+		Description string
+		Token       string
+		Error       string
+		Roles       []string
+	}{
+		{"RS256", sign(t, "RS256", "rsa", claims(nil)), "", []string{"editor"}},
+		{"ES256", sign(t, "ES256", "ec", claims(nil)), "", []string{"editor"}},
+		{"HS256 without kid", sign(t, "HS256", "", claims(nil)), "", []string{"editor"}},
+		{"Roles as a string", sign(t, "RS256", "rsa", claims(map[string]interface{}{"roles": "reader admin unknown"})), "", []string{"reader", "admin"}},
+		{"No roles", sign(t, "RS256", "rsa", claims(map[string]interface{}{"roles": nil})), "", nil},
+		{"Within leeway", sign(t, "RS256", "rsa", claims(map[string]interface{}{"exp": testNow.Add(-30 * time.Second).Unix()})), "", []string{"editor"}},
+		{"Expired", sign(t, "RS256", "rsa", claims(map[string]interface{}{"exp": testNow.Add(-time.Hour).Unix()})), "token expired", nil},
+		{"No exp", sign(t, "RS256", "rsa", claims(map[string]interface{}{"exp": nil})), "missing exp claim", nil},
+		{"Not yet valid", sign(t, "RS256", "rsa", claims(map[string]interface{}{"nbf": testNow.Add(time.Hour).Unix()})), "token not valid yet", nil},
+		{"Wrong issuer", sign(t, "RS256", "rsa", claims(map[string]interface{}{"iss": "evil"})), "unexpected issuer", nil},
+		{"Wrong audience", sign(t, "RS256", "rsa", claims(map[string]interface{}{"aud": "other"})), "unexpected audience", nil},
+		{"No subject", sign(t, "RS256", "rsa", claims(map[string]interface{}{"sub": nil})), "missing sub claim", nil},
+		{"Unknown kid", sign(t, "RS256", "unknown", claims(nil)), "invalid signature", nil},
+		{"Algorithm not allowed for the key", sign(t, "HS256", "rsa", claims(nil)), "invalid signature", nil},
+		{"alg none", b64([]byte(`{"alg":"none"}`)) + "." + b64([]byte(`{"sub":"jane"}`)) + ".", "invalid signature", nil},
+		{"Tampered", sign(t, "RS256", "rsa", claims(nil)) + "x", "", nil},
+		{"Malformed", "abc", "malformed token", nil},
+	}
+	for _, td := range testScenarios {
+		t.Run(td.Description, func(t *testing.T) {
+			principal, err := verifier.Verify(td.Token)
+			if td.Error == "" && td.Description != "Tampered" {
+				assert.NoError(t, err)
+				assert.Equal(t, "jane", principal.Subject)
+				assert.Equal(t, model.AuthJWT, principal.Method)
+				assert.Equal(t, td.Roles, principal.Roles)
+				return
+			}
+			assert.Error(t, err)
+			assert.Equal(t, model.ErrUnauthorized, err.(*model.Error).Code)
+			assert.Contains(t, err.Error(), td.Error)
+		})
+	}
+}
+
+func TestJWTVerifierScopes(t *testing.T) {
+	file := writeJWKS(t)
+	defer os.RemoveAll(filepath.Dir(file))
+	verifier, err := NewJWTVerifier(model.JWTProperties{JWKSFile: file, RolesClaim: "groups"})
+	assert.NoError(t, err)
+	verifier.(*jwtVerifier).now = func() time.Time { return testNow }
+
+	principal, err := verifier.Verify(sign(t, "ES256", "ec", claims(map[string]interface{}{"groups": []string{"editor"}})))
+	assert.NoError(t, err)
+	assert.True(t, principal.HasScope(model.ScopeArticlesRead))
+	assert.True(t, principal.HasScope(model.ScopeArticlesWrite))
+	assert.False(t, principal.HasScope(model.ScopeAdmin))
+}
+
+func TestJWKSURL(t *testing.T) {
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		json.NewEncoder(w).Encode(testJWKSDoc)
+	}))
+	defer server.Close()
+
+	verifier, err := NewJWTVerifier(model.JWTProperties{JWKSURL: server.URL, JWKSRefreshInterval: 60})
+	assert.NoError(t, err)
+	verifier.(*jwtVerifier).now = func() time.Time { return testNow }
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	_, err = verifier.Verify(sign(t, "RS256", "rsa", claims(nil)))
+	assert.NoError(t, err)
+	// unknown kids are not refetched right away
+	_, err = verifier.Verify(sign(t, "RS256", "rotated", claims(nil)))
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	// the set is refetched once it is older than the refresh interval
+	age(verifier, 2*time.Minute)
+	_, err = verifier.Verify(sign(t, "RS256", "rsa", claims(nil)))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&fetches) == 2 }, time.Second, 10*time.Millisecond)
+
+	_, err = NewJWTVerifier(model.JWTProperties{JWKSURL: server.URL + "/missing\x7f"})
+	assert.Error(t, err, fmt.Sprint("an unreachable JWKS fails the startup"))
+}
+
+func TestJWKSRefreshDoesNotBlock(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+	rotatedDoc := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "oct", "kid": "rotated", "alg": "HS256", "k": b64(hmacSecret)},
+	}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			json.NewEncoder(w).Encode(testJWKSDoc)
+			return
+		}
+		<-release
+		json.NewEncoder(w).Encode(rotatedDoc)
+	}))
+	defer server.Close()
+
+	verifier, err := NewJWTVerifier(model.JWTProperties{JWKSURL: server.URL, JWKSRefreshInterval: 60})
+	assert.NoError(t, err)
+	verifier.(*jwtVerifier).now = func() time.Time { return testNow }
+
+	// the known keys verify while the stale set is refetched, however many tokens come in
+	age(verifier, 2*time.Minute)
+	for i := 0; i < 5; i++ {
+		_, err = verifier.Verify(sign(t, "RS256", "rsa", claims(nil)))
+		assert.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&fetches) == 2 }, time.Second, 10*time.Millisecond)
+
+	// an unknown kid waits for the refetch, the rotated key is used once it is swapped in
+	done := make(chan error)
+	go func() {
+		_, err := verifier.Verify(sign(t, "HS256", "rotated", claims(nil)))
+		done <- err
+	}()
+	close(release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches), "the refetches are shared")
+	_, err = verifier.Verify(sign(t, "RS256", "rsa", claims(nil)))
+	assert.Error(t, err, "the keys are replaced by the refetched ones")
+}
+
+// age makes the keys of the verifier look fetched d ago
+func age(verifier TokenVerifier, d time.Duration) {
+	keys := verifier.(*jwtVerifier).keys
+	keys.mu.Lock()
+	keys.fetchedAt = keys.fetchedAt.Add(-d)
+	keys.mu.Unlock()
+}
+
+func TestJWKSSkipsUnusableKeys(t *testing.T) {
+	keys := []map[string]string{
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		{"kty": "EC", "kid": "p384", "crv": "P-384", "x": "AA", "y": "AA"},
+		{"kty": "RSA", "kid": "broken", "n": "!", "e": "AQAB"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	defer server.Close()
+
+	_, err := NewJWTVerifier(model.JWTProperties{JWKSURL: server.URL})
+	assert.Error(t, err, "a JWKS without a usable key fails the startup")
+
+	keys = append(keys, testJWKSDoc["keys"].([]map[string]string)[0])
+	verifier, err := NewJWTVerifier(model.JWTProperties{JWKSURL: server.URL})
+	assert.NoError(t, err)
+	verifier.(*jwtVerifier).now = func() time.Time { return testNow }
+	_, err = verifier.Verify(sign(t, "RS256", "rsa", claims(nil)))
+	assert.NoError(t, err)
+}
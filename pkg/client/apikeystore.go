@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// APIKeyStore is the interface that persists API keys, keys are stored hashed
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, name string, scopes []string) (*model.APIKey, error)
+	Authenticate(ctx context.Context, key string) (*model.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID string) error
+}
+
+// NewAPIKeyStore a service to mint, check and revoke API keys
+func NewAPIKeyStore(dbClient DBClient) APIKeyStore {
+	return &mongoAPIKeyStore{dbClient: dbClient}
+}
+
+// APIKeyIndexes are the indexes of the API key collection
+var APIKeyIndexes = map[string]bool{"KeyID": true, "Hash": true}
+
+type mongoAPIKeyStore struct {
+	dbClient DBClient
+}
+
+// CreateAPIKey mints a random key, the plain key is only returned here
+func (store *mongoAPIKeyStore) CreateAPIKey(ctx context.Context, name string, scopes []string) (*model.APIKey, error) {
+	id, err := randomString(8)
+	if err != nil {
+		return nil, model.ErrorEf(model.ErrUnknown, err, "Unable to mint API key")
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return nil, model.ErrorEf(model.ErrUnknown, err, "Unable to mint API key")
+	}
+	key := &model.APIKey{
+		ID:        id,
+		Name:      name,
+		Key:       id + "." + secret,
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	key.Hash = hashAPIKey(key.Key)
+	if _, err := store.dbClient.Write(ctx, key); err != nil {
+		return nil, model.ErrorEf(model.ErrUnknown, err, "Unable to create the API key")
+	}
+	return key, nil
+}
+
+// Authenticate finds the active key matching the plain key
+func (store *mongoAPIKeyStore) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	res := store.dbClient.Read(ctx, "Hash", hashAPIKey(key))
+	singleResult, ok := res.(*mongo.SingleResult)
+	if !ok {
+		return nil, mongo.CommandError{Message: "Unable to parse Read Result"}
+	}
+	var apiKey model.APIKey
+	if err := singleResult.Decode(&apiKey); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, model.Errorf(model.ErrUnauthorized, "Unknown API key")
+		}
+		return nil, err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, model.Errorf(model.ErrUnauthorized, "API key was revoked")
+	}
+	return &apiKey, nil
+}
+
+// ListAPIKeys lists every key, revoked ones included
+func (store *mongoAPIKeyStore) ListAPIKeys(ctx context.Context) (keys []*model.APIKey, e error) {
+	cur, err := store.dbClient.SimpleQuery(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	cursor, ok := cur.(*mongo.Cursor)
+	if !ok {
+		return nil, mongo.CommandError{Message: "Invalid read operation"}
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var key model.APIKey
+		if err := cursor.Decode(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (store *mongoAPIKeyStore) RevokeAPIKey(ctx context.Context, keyID string) error {
+	matched, err := store.dbClient.Update(ctx, map[string]string{"KeyID": keyID},
+		map[string]interface{}{"RevokedAt": time.Now().UTC().Truncate(time.Millisecond)})
+	if err != nil {
+		return model.ErrorEf(model.ErrUnknown, err, "Unable to revoke the API key")
+	}
+	if matched == 0 {
+		return model.Errorf(model.ErrNotFound, "API key %s not found", keyID)
+	}
+	return nil
+}
+
+// hashAPIKey keys are random and long, an unsalted digest is enough to keep them out of the database
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -3,8 +3,10 @@ package client
 import (
 	"context"
 	"strings"
+	"time"
 
 	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/tracing"
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -13,9 +15,11 @@ import (
 // ArticleStore is the interface that performs DB operations
 type ArticleStore interface {
 	HealthCheck() bool
-	CreatArticle(article *model.Article) error
-	ReadArticleByID(articleID string) (*model.Article, error)
-	SearchTagsByDate(date string, tag string) ([]*model.Tagsview, error)
+	CreatArticle(ctx context.Context, article *model.Article) error
+	ReadArticleByID(ctx context.Context, articleID string) (*model.Article, error)
+	ReadArticlesByIDs(ctx context.Context, articleIDs []string) ([]*model.Article, error)
+	SearchTagsByDate(ctx context.Context, date string, tag string) ([]*model.Tagsview, error)
+	ListTags(ctx context.Context, date string) ([]*model.Tagsview, error)
 }
 
 // NewArticleStore a service to perform Article curd operations
@@ -30,31 +34,117 @@ type mongoArticleStore struct {
 func (store *mongoArticleStore) HealthCheck() bool {
 	return store.dbClient.HealthCheck()
 }
-func (store *mongoArticleStore) CreatArticle(article *model.Article) error {
-	if _, err := store.dbClient.Write(article); err != nil {
+func (store *mongoArticleStore) CreatArticle(ctx context.Context, article *model.Article) error {
+	// mongo persists dates with millisecond precision
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	article.CreatedAt = &now
+	article.UpdatedAt = &now
+	if _, err := store.dbClient.Write(ctx, article); err != nil {
 		if strings.Contains(err.Error(), "duplicate key") {
-			return model.Errorf(model.ErrDuplicate, err.Error())
+			return model.ErrorEf(model.ErrDuplicate, err, "Article %s already exists", article.ArticleID)
 		}
-		return model.Errorf(model.ErrUnknown, err.Error())
+		return model.ErrorEf(model.ErrUnknown, err, "Unable to create the article")
 	}
 	return nil
 }
 
-func (store *mongoArticleStore) ReadArticleByID(articleID string) (result *model.Article, e error) {
+func (store *mongoArticleStore) ReadArticleByID(ctx context.Context, articleID string) (result *model.Article, e error) {
 
-	res := store.dbClient.Read("ArticleID", articleID)
+	res := store.dbClient.Read(ctx, "ArticleID", articleID)
 	singleResult, ok := res.(*mongo.SingleResult)
 	if !ok {
 		return nil, mongo.CommandError{Message: "Unable to parse Read Result"}
 	}
 	if err := singleResult.Decode(&result); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, model.ErrorEf(model.ErrNotFound, err, "Article Not Found")
+		}
 		return nil, err
 	}
-	log.Infoln("Found a single article: \n", result)
+	log.WithFields(articleFields(result)).Debugln("Found a single article")
 	return result, nil
 }
 
-func (store *mongoArticleStore) SearchTagsByDate(date string, tag string) (results []*model.Tagsview, e error) {
+// ReadArticlesByIDs loads all the requested articles in a single query,
+// ids without a matching article are left out of the result
+func (store *mongoArticleStore) ReadArticlesByIDs(ctx context.Context, articleIDs []string) (results []*model.Article, e error) {
+	pipeLine := []bson.M{
+		{"$match": bson.M{"ArticleID": bson.M{"$in": articleIDs}}},
+	}
+
+	cur, err := store.dbClient.AdvancedQuery(ctx, pipeLine)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, ok := cur.(*mongo.Cursor)
+	if !ok {
+		return nil, mongo.CommandError{Message: "Invalid read operation"}
+	}
+	err = decodeCursor(ctx, cursor, func(cursor *mongo.Cursor) error {
+		var elem model.Article
+		if err := cursor.Decode(&elem); err != nil {
+			return err
+		}
+		results = append(results, &elem)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{"requested": len(articleIDs), "found": len(results)}).Debugln("Read articles")
+	return results, nil
+}
+
+// ListTags lists every tag with its article count, most used first.
+// All dates are considered when date is empty.
+func (store *mongoArticleStore) ListTags(ctx context.Context, date string) (results []*model.Tagsview, e error) {
+	match := bson.M{}
+	if date != "" {
+		match["Date"] = date
+	}
+	pipeLine := []bson.M{
+		{"$match": match},
+		// one document per tag of each article
+		{"$unwind": "$Tags"},
+		{"$group": bson.M{
+			"_id":          "$Tags",
+			"count":        bson.M{"$sum": 1},
+			"lastModified": bson.M{"$max": "$UpdatedAt"},
+		}},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$project": bson.M{
+			"Tag":          "$_id",
+			"Count":        "$count",
+			"LastModified": "$lastModified",
+		}},
+	}
+
+	cur, err := store.dbClient.AdvancedQuery(ctx, pipeLine)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, ok := cur.(*mongo.Cursor)
+	if !ok {
+		return nil, mongo.CommandError{Message: "Invalid list operation"}
+	}
+	err = decodeCursor(ctx, cursor, func(cursor *mongo.Cursor) error {
+		var elem model.Tagsview
+		if err := cursor.Decode(&elem); err != nil {
+			return err
+		}
+		results = append(results, &elem)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{"date": date, "tags": len(results)}).Debugln("List tags query completed")
+	return results, nil
+}
+
+func (store *mongoArticleStore) SearchTagsByDate(ctx context.Context, date string, tag string) (results []*model.Tagsview, e error) {
 	pipeLine := []bson.M{
 		// filtes records based on date and tags
 		{"$match": bson.M{"Date": date, "Tags": tag}},
@@ -69,6 +159,8 @@ func (store *mongoArticleStore) SearchTagsByDate(date string, tag string) (resul
 			"relatedTags":  bson.M{"$push": "$Tags"},
 			// identifys the total records impacted by the query
 			"count": bson.M{"$sum": 1},
+			// most recent change among the matching articles
+			"lastModified": bson.M{"$max": "$UpdatedAt"},
 		}},
 		// generates the projections/views
 		{"$project": bson.M{
@@ -90,35 +182,64 @@ func (store *mongoArticleStore) SearchTagsByDate(date string, tag string) (resul
 			"Count": "$count",
 			// the tag queried for
 			"Tag": tag,
+			// drives the Last-Modified header of the view
+			"LastModified": "$lastModified",
 		}},
 	}
 
-	cur, err := store.dbClient.AdvancedQuery(pipeLine)
+	cur, err := store.dbClient.AdvancedQuery(ctx, pipeLine)
 	if err != nil {
 		return nil, err
 	}
 
 	cursor, ok := cur.(*mongo.Cursor)
-	defer cursor.Close(context.Background())
-
 	if !ok {
 		return nil, mongo.CommandError{Message: "Invalid search operation"}
 	}
 
 	// Finding multiple documents returns a cursor
 	// Iterating through the cursor allows us to decode documents one at a time
-	for cursor.Next(context.Background()) {
+	err = decodeCursor(ctx, cursor, func(cursor *mongo.Cursor) error {
 		// create a value into which the single document can be decoded
 		var elem model.Tagsview
 		if err := cursor.Decode(&elem); err != nil {
-			return nil, err
+			return err
 		}
 		results = append(results, &elem)
-	}
-
-	if err := cursor.Err(); err != nil {
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	log.Infoln("Search query Completed")
+	log.WithFields(log.Fields{"date": date, "tag": tag, "results": len(results)}).Debugln("Search query completed")
 	return results, nil
 }
+
+// decodeCursor decodes every document of the cursor with decode and closes it,
+// traced apart from the query so slow decoding stands out
+func decodeCursor(ctx context.Context, cursor *mongo.Cursor, decode func(cursor *mongo.Cursor) error) (err error) {
+	ctx, span := tracing.Start(ctx, "mongo.decode")
+	defer func() { tracing.End(span, err) }()
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		if err = decode(cursor); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// articleFields identify an article in logs, its title and body are redacted
+// as they are user content of arbitrary size
+func articleFields(article *model.Article) log.Fields {
+	if article == nil {
+		return log.Fields{}
+	}
+	return log.Fields{
+		"id":         article.ArticleID,
+		"date":       article.Date,
+		"tags":       len(article.Tags),
+		"body_bytes": len(article.Body),
+	}
+}
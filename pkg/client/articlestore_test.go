@@ -0,0 +1,16 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArticleFieldsRedactUserContent(t *testing.T) {
+	tag := "science"
+	article := &model.Article{ArticleID: "1", Title: "private title", Date: "2019-10-02", Body: "private body", Tags: []*string{&tag}}
+	assert.Equal(t, log.Fields{"id": "1", "date": "2019-10-02", "tags": 1, "body_bytes": 12}, articleFields(article))
+	assert.Equal(t, log.Fields{}, articleFields(nil))
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/metrics"
+	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"golang.org/x/sync/singleflight"
+)
+
+// NewCoalescingArticleStore wraps an ArticleStore so that concurrent identical
+// ReadArticleByID and SearchTagsByDate calls share one result.
+// The shared call is cut off after timeout, whichever caller started it, and each caller
+// stops waiting for it once its own context is done.
+// Shared results must be treated as read only by the callers.
+// The callers served by another caller's round-trip are counted in articleapi_store_coalesced_calls_total,
+// and per call in the view of metrics.CoalescedHandler.
+func NewCoalescingArticleStore(store ArticleStore, timeout time.Duration) ArticleStore {
+	return &coalescingArticleStore{
+		ArticleStore: store,
+		timeout:      timeout,
+	}
+}
+
+type coalescingArticleStore struct {
+	ArticleStore
+	group   singleflight.Group
+	timeout time.Duration
+	// waiting is called once a caller waits on the call of key, tests synchronise on it
+	waiting func(key string)
+}
+
+func (store *coalescingArticleStore) ReadArticleByID(ctx context.Context, articleID string) (*model.Article, error) {
+	res, err := store.do(ctx, "ReadArticleByID", strconv.Quote(articleID), func(shared context.Context) (interface{}, error) {
+		return store.ArticleStore.ReadArticleByID(shared, articleID)
+	})
+	article, _ := res.(*model.Article)
+	return article, err
+}
+
+func (store *coalescingArticleStore) SearchTagsByDate(ctx context.Context, date string, tag string) ([]*model.Tagsview, error) {
+	// quoted, a tag with a / or a quote cannot be mistaken for another tag and date
+	res, err := store.do(ctx, "SearchTagsByDate", strconv.Quote(tag)+strconv.Quote(date), func(shared context.Context) (interface{}, error) {
+		return store.ArticleStore.SearchTagsByDate(shared, date, tag)
+	})
+	tags, _ := res.([]*model.Tagsview)
+	return tags, err
+}
+
+// do runs fn once for the concurrent callers of the method with the same args. fn gets a context of its own,
+// bounded by the timeout, so that the callers waiting on it are not failed when the caller that started it goes away.
+func (store *coalescingArticleStore) do(ctx context.Context, method string, args string,
+	fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	key := method + ":" + args
+	var executed int32
+	results := store.group.DoChan(key, func() (interface{}, error) {
+		atomic.StoreInt32(&executed, 1)
+		shared, cancel := context.WithTimeout(detached{ctx}, store.timeout)
+		defer cancel()
+		return fn(shared)
+	})
+	if store.waiting != nil {
+		store.waiting(key)
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		// the caller that executed fn is not counted, only those who waited on it
+		if result.Shared && atomic.LoadInt32(&executed) == 0 {
+			metrics.ObserveCoalesced(method, args)
+		}
+		return result.Val, result.Err
+	}
+}
+
+// detached keeps the values of the first caller's context, its trace among others,
+// but not its deadline nor its cancellation, the shared call gets its own
+type detached struct{ parent context.Context }
+
+func (detached) Deadline() (time.Time, bool)           { return time.Time{}, false }
+func (detached) Done() <-chan struct{}                 { return nil }
+func (detached) Err() error                            { return nil }
+func (ctx detached) Value(key interface{}) interface{} { return ctx.parent.Value(key) }
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalescingArticleStoreCollapsesIdenticalReads(t *testing.T) {
+	slowStore := &slowArticleStore{release: make(chan struct{})}
+	store := NewCoalescingArticleStore(slowStore, time.Second).(*coalescingArticleStore)
+	const callers = 10
+	var joined sync.WaitGroup
+	joined.Add(callers)
+	store.waiting = func(key string) { joined.Done() }
+	before := coalesced(t, "ReadArticleByID")
+
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			article, err := store.ReadArticleByID(context.Background(), "1")
+			assert.NoError(t, err)
+			assert.Equal(t, "1", article.ArticleID)
+		}()
+	}
+	// the read is held until every caller waits on it
+	joined.Wait()
+	close(slowStore.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&slowStore.reads))
+	assert.Equal(t, float64(callers-1), coalesced(t, "ReadArticleByID")-before)
+}
+
+func TestCoalescingArticleStoreKeysByTagAndDate(t *testing.T) {
+	slowStore := &slowArticleStore{release: make(chan struct{})}
+	store := NewCoalescingArticleStore(slowStore, time.Second).(*coalescingArticleStore)
+	var joined sync.WaitGroup
+	joined.Add(3)
+	store.waiting = func(key string) { joined.Done() }
+
+	var wg sync.WaitGroup
+	// the same tag on another date, and a tag with a / that once read as the first tag and date
+	for _, search := range [][2]string{{"2019-10-02", "health"}, {"2019-10-03", "health"}, {"10-02", "health/2019"}} {
+		wg.Add(1)
+		go func(date string, tag string) {
+			defer wg.Done()
+			tags, err := store.SearchTagsByDate(context.Background(), date, tag)
+			assert.NoError(t, err)
+			assert.Equal(t, tag, tags[0].Tag)
+		}(search[0], search[1])
+	}
+	joined.Wait()
+	close(slowStore.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&slowStore.searches))
+}
+
+func TestCoalescingArticleStoreCallersStopWaitingOnTheirContext(t *testing.T) {
+	slowStore := &slowArticleStore{release: make(chan struct{})}
+	defer close(slowStore.release)
+	store := NewCoalescingArticleStore(slowStore, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := store.ReadArticleByID(ctx, "1")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestCoalescingArticleStoreBoundsTheSharedCall(t *testing.T) {
+	slowStore := &slowArticleStore{release: make(chan struct{})}
+	defer close(slowStore.release)
+	store := NewCoalescingArticleStore(slowStore, 10*time.Millisecond)
+
+	// the caller never gives up, the stalled read does
+	_, err := store.ReadArticleByID(context.Background(), "1")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+type slowArticleStore struct {
+	release  chan struct{}
+	reads    int32
+	searches int32
+}
+
+func (store *slowArticleStore) HealthCheck() bool {
+	return true
+}
+
+func (store *slowArticleStore) CreatArticle(ctx context.Context, article *model.Article) error {
+	return nil
+}
+
+func (store *slowArticleStore) ReadArticleByID(ctx context.Context, articleID string) (*model.Article, error) {
+	atomic.AddInt32(&store.reads, 1)
+	select {
+	case <-store.release:
+		return &model.Article{ArticleID: articleID}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (store *slowArticleStore) ReadArticlesByIDs(ctx context.Context, articleIDs []string) ([]*model.Article, error) {
+	return nil, nil
+}
+
+func (store *slowArticleStore) ListTags(ctx context.Context, date string) ([]*model.Tagsview, error) {
+	return nil, nil
+}
+
+func (store *slowArticleStore) SearchTagsByDate(ctx context.Context, date string, tag string) ([]*model.Tagsview, error) {
+	atomic.AddInt32(&store.searches, 1)
+	<-store.release
+	return []*model.Tagsview{{Tag: tag}}, nil
+}
+
+// coalesced reads articleapi_store_coalesced_calls_total of the method
+func coalesced(t *testing.T, method string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "articleapi_store_coalesced_calls_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "method" && label.GetValue() == method {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
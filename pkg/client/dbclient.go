@@ -2,9 +2,15 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/metrics"
 	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/tlsconfig"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/tracing"
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -39,17 +45,24 @@ func ConvertMapToBsonM(fields map[string]string) bson.M {
 type DBClient interface {
 	DBDestroy() error
 	HealthCheck() bool
+	Ping(ctx context.Context) error
+	CheckIndexes(ctx context.Context) error
 	DBInit(DBProperties model.DBProperties) (err error)
-	Read(key string, value string) interface{}
-	Write(document interface{}) (interface{}, error)
-	SimpleQuery(filterFields map[string]string, sortFields map[string]string) (interface{}, error)
-	AdvancedQuery(pipeLine interface{}) (interface{}, error)
+	Read(ctx context.Context, key string, value string) interface{}
+	Write(ctx context.Context, document interface{}) (interface{}, error)
+	SimpleQuery(ctx context.Context, filterFields map[string]string, sortFields map[string]string) (interface{}, error)
+	AdvancedQuery(ctx context.Context, pipeLine interface{}) (interface{}, error)
+	Watch(ctx context.Context, pipeLine interface{}, resumeAfter interface{}) (interface{}, error)
+	Update(ctx context.Context, filterFields map[string]string, fields map[string]interface{}) (int64, error)
+	Collection(name string, indexes map[string]bool) (DBClient, error)
 	Delete()
 }
 
 type mongoClient struct {
 	session    *mongo.Client
+	database   *mongo.Database
 	collection *mongo.Collection
+	indexes    map[string]bool // {field : unique} set up on the collection
 }
 
 func (mc *mongoClient) DBInit(DBProperties model.DBProperties) (err error) {
@@ -58,6 +71,25 @@ func (mc *mongoClient) DBInit(DBProperties model.DBProperties) (err error) {
 	defer cancel()
 	clientOptions := options.Client().ApplyURI(DBProperties.URL)
 	clientOptions.SetMaxPoolSize(uint64(DBProperties.MaxThreadPoolSize))
+	clientOptions.SetPoolMonitor(metrics.PoolMonitor)
+	clientOptions.SetMonitor(tracing.CommandMonitor)
+	if DBProperties.TLS.Enabled {
+		tlsConfig, err := tlsconfig.NewClientConfig(DBProperties.TLS)
+		if err != nil {
+			return err
+		}
+		clientOptions.SetTLSConfig(tlsConfig)
+	}
+	// credentials of the URL are kept unless a user or mechanism is configured
+	if auth := DBProperties.Auth; auth.Username != "" || auth.Mechanism != "" {
+		clientOptions.SetAuth(options.Credential{
+			AuthMechanism: auth.Mechanism,
+			AuthSource:    auth.Source,
+			Username:      auth.Username,
+			Password:      auth.Password,
+			PasswordSet:   auth.Password != "",
+		})
+	}
 
 	mc.session, err = mongo.Connect(ctx, clientOptions)
 	if err != nil {
@@ -69,22 +101,63 @@ func (mc *mongoClient) DBInit(DBProperties model.DBProperties) (err error) {
 	}
 
 	log.Infoln("Connected to MongoDB!")
-	mc.collection = mc.session.Database(DBProperties.DatabaseName).Collection(DBProperties.CollectionName)
+	mc.database = mc.session.Database(DBProperties.DatabaseName)
+	mc.collection = mc.database.Collection(DBProperties.CollectionName)
 
+	mc.indexes = DBProperties.Indexes
 	if len(DBProperties.Indexes) > 0 {
 		if err = mc.setUpIndexes(DBProperties.Indexes); err != nil {
 			return err
 		}
 	}
-	log.Infoln("Loaded Collection ", DBProperties.CollectionName)
+	log.WithField("collection", DBProperties.CollectionName).Infoln("Loaded collection")
 	return nil
 }
 
 func (mc *mongoClient) HealthCheck() bool {
-	if err := mc.session.Ping(context.Background(), nil); err != nil {
-		return false
+	return mc.Ping(context.Background()) == nil
+}
+
+// Ping checks that the primary can be reached
+func (mc *mongoClient) Ping(ctx context.Context) error {
+	return mc.session.Ping(ctx, nil)
+}
+
+// CheckIndexes checks that every index set up on the collection is still present
+func (mc *mongoClient) CheckIndexes(ctx context.Context) error {
+	cursor, err := mc.collection.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	present := map[string]bool{}
+	for cursor.Next(ctx) {
+		var index struct {
+			Key bson.D `bson:"key"`
+		}
+		if err := cursor.Decode(&index); err != nil {
+			return err
+		}
+		for _, key := range index.Key {
+			present[key.Key] = true
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
 	}
-	return true
+
+	missing := []string{}
+	for field := range mc.indexes {
+		if !present[field] {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing indexes on %s: %s", mc.collection.Name(), strings.Join(missing, ", "))
+	}
+	return nil
 }
 
 func (mc *mongoClient) setUpIndexes(indexes map[string]bool) error {
@@ -100,30 +173,30 @@ func (mc *mongoClient) setUpIndexes(indexes map[string]bool) error {
 	}
 
 	if _, err := mc.collection.Indexes().CreateMany(context.Background(), models); err != nil {
-		log.Errorln("Indexes().CreateMany() ERROR:", err)
+		log.WithError(err).WithField("collection", mc.collection.Name()).Errorln("Could not create the indexes")
 		return err
 	}
 
-	log.Infoln("Created Indexes:", models)
+	log.WithFields(log.Fields{"collection": mc.collection.Name(), "indexes": len(models)}).Infoln("Created indexes")
 	return nil
 }
 
-func (mc *mongoClient) Read(key string, value string) interface{} {
+func (mc *mongoClient) Read(ctx context.Context, key string, value string) interface{} {
 	filter := bson.D{{Key: key, Value: value}}
-	result := mc.collection.FindOne(context.Background(), filter)
+	result := mc.collection.FindOne(ctx, filter)
 
-	log.Infoln("Found a single document: \n", result)
+	log.WithFields(log.Fields{"collection": mc.collection.Name(), "key": key}).Debugln("Read a single document")
 	return result
 }
-func (mc *mongoClient) Write(document interface{}) (interface{}, error) {
-	insertResult, err := mc.collection.InsertOne(context.Background(), document)
+func (mc *mongoClient) Write(ctx context.Context, document interface{}) (interface{}, error) {
+	insertResult, err := mc.collection.InsertOne(ctx, document)
 	if err != nil {
 		return nil, err
 	}
-	log.Infoln("Inserted a single document: ", insertResult.InsertedID)
+	log.WithFields(log.Fields{"collection": mc.collection.Name(), "id": insertResult.InsertedID}).Debugln("Inserted a single document")
 	return insertResult.InsertedID, nil
 }
-func (mc *mongoClient) SimpleQuery(filterFields map[string]string, sortFields map[string]string) (interface{}, error) {
+func (mc *mongoClient) SimpleQuery(ctx context.Context, filterFields map[string]string, sortFields map[string]string) (interface{}, error) {
 	findOptions := options.Find()
 	if len(sortFields) > 0 {
 		sort := ConvertMapToBsonD(sortFields)
@@ -131,12 +204,44 @@ func (mc *mongoClient) SimpleQuery(filterFields map[string]string, sortFields ma
 	}
 	filter := ConvertMapToBsonD(filterFields)
 
-	return mc.collection.Find(context.Background(), filter, findOptions)
+	return mc.collection.Find(ctx, filter, findOptions)
 }
 
 // pipeLine example: []bson.M anything that the mongo db aggregate can process
-func (mc *mongoClient) AdvancedQuery(pipeLine interface{}) (interface{}, error) {
-	return mc.collection.Aggregate(context.Background(), pipeLine)
+func (mc *mongoClient) AdvancedQuery(ctx context.Context, pipeLine interface{}) (interface{}, error) {
+	return mc.collection.Aggregate(ctx, pipeLine)
+}
+
+// Watch opens a change stream of the collection, right after the resumeAfter token when it is not nil.
+// Change streams are only supported by replica sets and sharded clusters.
+func (mc *mongoClient) Watch(ctx context.Context, pipeLine interface{}, resumeAfter interface{}) (interface{}, error) {
+	changeStreamOptions := options.ChangeStream()
+	if resumeAfter != nil {
+		changeStreamOptions.SetResumeAfter(resumeAfter)
+	}
+	return mc.collection.Watch(ctx, pipeLine, changeStreamOptions)
+}
+
+// Update sets the fields of the documents matching filterFields, returns the number of matched documents
+func (mc *mongoClient) Update(ctx context.Context, filterFields map[string]string, fields map[string]interface{}) (int64, error) {
+	filter := ConvertMapToBsonD(filterFields)
+	result, err := mc.collection.UpdateMany(ctx, filter, bson.M{"$set": fields})
+	if err != nil {
+		return 0, err
+	}
+	return result.MatchedCount, nil
+}
+
+// Collection returns a client of another collection of the database sharing this connection,
+// only the original client should be destroyed
+func (mc *mongoClient) Collection(name string, indexes map[string]bool) (DBClient, error) {
+	client := &mongoClient{session: mc.session, database: mc.database, collection: mc.database.Collection(name), indexes: indexes}
+	if len(indexes) > 0 {
+		if err := client.setUpIndexes(indexes); err != nil {
+			return nil, err
+		}
+	}
+	return client, nil
 }
 
 // To Do implement for future use case
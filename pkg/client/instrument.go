@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/metrics"
+	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/tracing"
+	"go.opentelemetry.io/otel/api/trace"
+)
+
+// NewInstrumentedArticleStore wraps an ArticleStore to record the latency and errors of every method
+// and trace it as a child of the caller's span
+func NewInstrumentedArticleStore(store ArticleStore) ArticleStore {
+	return &instrumentedArticleStore{store: store}
+}
+
+type instrumentedArticleStore struct {
+	store ArticleStore
+}
+
+// operation is a running store method, finished once with its error
+type operation struct {
+	method string
+	start  time.Time
+	span   trace.Span
+}
+
+func startOperation(ctx context.Context, method string) (context.Context, *operation) {
+	ctx, span := tracing.Start(ctx, "ArticleStore."+method)
+	return ctx, &operation{method: method, start: time.Now(), span: span}
+}
+
+func (op *operation) finish(err error) {
+	metrics.ObserveStore(op.method, time.Since(op.start), err)
+	tracing.End(op.span, err)
+}
+
+func (s *instrumentedArticleStore) HealthCheck() bool {
+	_, op := startOperation(context.Background(), "HealthCheck")
+	healthy := s.store.HealthCheck()
+	var err error
+	if !healthy {
+		err = model.Errorf(model.ErrUnknown, "Unhealthy")
+	}
+	op.finish(err)
+	return healthy
+}
+
+func (s *instrumentedArticleStore) CreatArticle(ctx context.Context, article *model.Article) error {
+	ctx, op := startOperation(ctx, "CreatArticle")
+	err := s.store.CreatArticle(ctx, article)
+	op.finish(err)
+	return err
+}
+
+func (s *instrumentedArticleStore) ReadArticleByID(ctx context.Context, articleID string) (*model.Article, error) {
+	ctx, op := startOperation(ctx, "ReadArticleByID")
+	article, err := s.store.ReadArticleByID(ctx, articleID)
+	op.finish(err)
+	return article, err
+}
+
+func (s *instrumentedArticleStore) ReadArticlesByIDs(ctx context.Context, articleIDs []string) ([]*model.Article, error) {
+	ctx, op := startOperation(ctx, "ReadArticlesByIDs")
+	articles, err := s.store.ReadArticlesByIDs(ctx, articleIDs)
+	op.finish(err)
+	return articles, err
+}
+
+func (s *instrumentedArticleStore) SearchTagsByDate(ctx context.Context, date string, tag string) ([]*model.Tagsview, error) {
+	ctx, op := startOperation(ctx, "SearchTagsByDate")
+	tags, err := s.store.SearchTagsByDate(ctx, date, tag)
+	op.finish(err)
+	return tags, err
+}
+
+func (s *instrumentedArticleStore) ListTags(ctx context.Context, date string) ([]*model.Tagsview, error) {
+	ctx, op := startOperation(ctx, "ListTags")
+	tags, err := s.store.ListTags(ctx, date)
+	op.finish(err)
+	return tags, err
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+
+	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// Publisher is told about every article event, eg: to deliver it to the webhooks.
+// Publish must not block the write that caused the event.
+type Publisher interface {
+	Publish(ctx context.Context, event string, article *model.Article)
+}
+
+// NewPublishingArticleStore wraps an ArticleStore so that the publishers are told about
+// every article it created, once the write succeeded
+func NewPublishingArticleStore(store ArticleStore, publishers ...Publisher) ArticleStore {
+	return &publishingArticleStore{ArticleStore: store, publishers: publishers}
+}
+
+type publishingArticleStore struct {
+	ArticleStore
+	publishers []Publisher
+}
+
+func (store *publishingArticleStore) CreatArticle(ctx context.Context, article *model.Article) error {
+	if err := store.ArticleStore.CreatArticle(ctx, article); err != nil {
+		return err
+	}
+	for _, publisher := range store.publishers {
+		// the caller keeps the article it passed in
+		published := *article
+		publisher.Publish(ctx, model.EventArticleCreated, &published)
+	}
+	return nil
+}
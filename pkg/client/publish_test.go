@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishingArticleStorePublishesCreatedArticles(t *testing.T) {
+	publisher := &recordingPublisher{}
+	store := NewPublishingArticleStore(&failingCreateStore{}, publisher)
+
+	article := &model.Article{ArticleID: "1"}
+	assert.NoError(t, store.CreatArticle(context.Background(), article))
+	article.Title = "changed by the caller"
+	assert.Error(t, store.CreatArticle(context.Background(), &model.Article{ArticleID: "duplicate"}))
+
+	assert.Equal(t, []string{model.EventArticleCreated}, publisher.events)
+	assert.Equal(t, "1", publisher.articles[0].ArticleID)
+	assert.Empty(t, publisher.articles[0].Title)
+}
+
+type recordingPublisher struct {
+	events   []string
+	articles []*model.Article
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event string, article *model.Article) {
+	p.events = append(p.events, event)
+	p.articles = append(p.articles, article)
+}
+
+// failingCreateStore refuses to create the duplicate article
+type failingCreateStore struct {
+	slowArticleStore
+}
+
+func (store *failingCreateStore) CreatArticle(ctx context.Context, article *model.Article) error {
+	if article.ArticleID == "duplicate" {
+		return errors.New("duplicate key")
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	model "github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WebhookStore is the interface that persists the webhooks and the log of their deliveries
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+	ReadWebhook(ctx context.Context, webhookID string) (*model.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]*model.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *model.Webhook) error
+	DeleteWebhook(ctx context.Context, webhookID string) error
+	SaveDelivery(ctx context.Context, delivery *model.Delivery) error
+	UpdateDelivery(ctx context.Context, delivery *model.Delivery) error
+	UpdateDeliveryFrom(ctx context.Context, delivery *model.Delivery, status string, owner string) error
+	ReadDelivery(ctx context.Context, deliveryID string) (*model.Delivery, error)
+	ListDeliveries(ctx context.Context, filter map[string]string) ([]*model.Delivery, error)
+}
+
+// NewWebhookStore a service to manage the webhooks and log their deliveries, each in a collection of its own
+func NewWebhookStore(webhookClient DBClient, deliveryClient DBClient) WebhookStore {
+	return &mongoWebhookStore{webhookClient: webhookClient, deliveryClient: deliveryClient}
+}
+
+// WebhookIndexes are the indexes of the webhook collection
+var WebhookIndexes = map[string]bool{"WebhookID": true}
+
+// DeliveryIndexes are the indexes of the delivery collection, deliveries are listed by webhook and status
+var DeliveryIndexes = map[string]bool{"DeliveryID": true, "WebhookID": false, "Status": false}
+
+type mongoWebhookStore struct {
+	webhookClient  DBClient
+	deliveryClient DBClient
+}
+
+// CreateWebhook mints the id and the signing secret of the webhook
+func (store *mongoWebhookStore) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	id, err := randomString(8)
+	if err != nil {
+		return model.ErrorEf(model.ErrUnknown, err, "Unable to create the webhook")
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return model.ErrorEf(model.ErrUnknown, err, "Unable to create the webhook")
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	webhook.ID, webhook.Secret = id, secret
+	webhook.CreatedAt, webhook.UpdatedAt = now, now
+	if _, err := store.webhookClient.Write(ctx, webhook); err != nil {
+		return model.ErrorEf(model.ErrUnknown, err, "Unable to create the webhook")
+	}
+	return nil
+}
+
+// ReadWebhook finds a webhook that was not deleted, its secret included
+func (store *mongoWebhookStore) ReadWebhook(ctx context.Context, webhookID string) (*model.Webhook, error) {
+	res := store.webhookClient.Read(ctx, "WebhookID", webhookID)
+	singleResult, ok := res.(*mongo.SingleResult)
+	if !ok {
+		return nil, mongo.CommandError{Message: "Unable to parse Read Result"}
+	}
+	var webhook model.Webhook
+	if err := singleResult.Decode(&webhook); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, model.Errorf(model.ErrNotFound, "Webhook %s not found", webhookID)
+		}
+		return nil, err
+	}
+	if webhook.DeletedAt != nil {
+		return nil, model.Errorf(model.ErrNotFound, "Webhook %s not found", webhookID)
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks lists the webhooks that were not deleted, oldest first
+func (store *mongoWebhookStore) ListWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	cursor, err := query(ctx, store.webhookClient, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []*model.Webhook{}
+	for cursor.Next(ctx) {
+		var webhook model.Webhook
+		if err := cursor.Decode(&webhook); err != nil {
+			return nil, err
+		}
+		if webhook.DeletedAt == nil {
+			webhooks = append(webhooks, &webhook)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(webhooks, func(i, j int) bool { return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt) })
+	return webhooks, nil
+}
+
+// UpdateWebhook replaces the URL, events and tags of the webhook, its secret is kept
+func (store *mongoWebhookStore) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	current, err := store.ReadWebhook(ctx, webhook.ID)
+	if err != nil {
+		return err
+	}
+	webhook.Secret, webhook.CreatedAt = current.Secret, current.CreatedAt
+	webhook.UpdatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	_, err = store.webhookClient.Update(ctx, map[string]string{"WebhookID": webhook.ID},
+		map[string]interface{}{"URL": webhook.URL, "Events": webhook.Events, "Tags": webhook.Tags, "UpdatedAt": webhook.UpdatedAt})
+	if err != nil {
+		return model.ErrorEf(model.ErrUnknown, err, "Unable to update the webhook")
+	}
+	return nil
+}
+
+// DeleteWebhook stops the deliveries to the webhook, its delivery log is kept
+func (store *mongoWebhookStore) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if _, err := store.ReadWebhook(ctx, webhookID); err != nil {
+		return err
+	}
+	_, err := store.webhookClient.Update(ctx, map[string]string{"WebhookID": webhookID},
+		map[string]interface{}{"DeletedAt": time.Now().UTC().Truncate(time.Millisecond)})
+	if err != nil {
+		return model.ErrorEf(model.ErrUnknown, err, "Unable to delete the webhook")
+	}
+	return nil
+}
+
+// SaveDelivery logs a new delivery, minting its id
+func (store *mongoWebhookStore) SaveDelivery(ctx context.Context, delivery *model.Delivery) error {
+	id, err := randomString(12)
+	if err != nil {
+		return model.ErrorEf(model.ErrUnknown, err, "Unable to log the delivery")
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	delivery.ID = id
+	delivery.CreatedAt, delivery.UpdatedAt = now, now
+	if _, err := store.deliveryClient.Write(ctx, delivery); err != nil {
+		return model.ErrorEf(model.ErrUnknown, err, "Unable to log the delivery")
+	}
+	return nil
+}
+
+// UpdateDelivery records the status and the attempts of the delivery
+func (store *mongoWebhookStore) UpdateDelivery(ctx context.Context, delivery *model.Delivery) error {
+	matched, err := store.updateDelivery(ctx, map[string]string{"DeliveryID": delivery.ID}, delivery)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return model.Errorf(model.ErrNotFound, "Delivery %s not found", delivery.ID)
+	}
+	return nil
+}
+
+// UpdateDeliveryFrom records the delivery as UpdateDelivery does, provided its stored status still is status,
+// and its stored owner still is owner when one is given, for in flight deliveries.
+// Of concurrent updates from the same status and owner only one succeeds, the others get ErrDuplicate.
+func (store *mongoWebhookStore) UpdateDeliveryFrom(ctx context.Context, delivery *model.Delivery, status string, owner string) error {
+	filter := map[string]string{"DeliveryID": delivery.ID, "Status": status}
+	if owner != "" {
+		filter["Owner"] = owner
+	}
+	matched, err := store.updateDelivery(ctx, filter, delivery)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return model.Errorf(model.ErrDuplicate, "Delivery %s is not %s anymore", delivery.ID, status)
+	}
+	return nil
+}
+
+func (store *mongoWebhookStore) updateDelivery(ctx context.Context, filter map[string]string, delivery *model.Delivery) (int64, error) {
+	delivery.UpdatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	matched, err := store.deliveryClient.Update(ctx, filter, map[string]interface{}{
+		"Status":         delivery.Status,
+		"Attempts":       delivery.Attempts,
+		"NextAttemptAt":  delivery.NextAttemptAt,
+		"ReplayedAt":     delivery.ReplayedAt,
+		"Owner":          delivery.Owner,
+		"LeaseExpiresAt": delivery.LeaseExpiresAt,
+		"UpdatedAt":      delivery.UpdatedAt,
+	})
+	if err != nil {
+		return 0, model.ErrorEf(model.ErrUnknown, err, "Unable to update the delivery")
+	}
+	return matched, nil
+}
+
+func (store *mongoWebhookStore) ReadDelivery(ctx context.Context, deliveryID string) (*model.Delivery, error) {
+	res := store.deliveryClient.Read(ctx, "DeliveryID", deliveryID)
+	singleResult, ok := res.(*mongo.SingleResult)
+	if !ok {
+		return nil, mongo.CommandError{Message: "Unable to parse Read Result"}
+	}
+	var delivery model.Delivery
+	if err := singleResult.Decode(&delivery); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, model.Errorf(model.ErrNotFound, "Delivery %s not found", deliveryID)
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries lists the deliveries matching filter {field : value} eg: WebhookID or Status, newest first
+func (store *mongoWebhookStore) ListDeliveries(ctx context.Context, filter map[string]string) ([]*model.Delivery, error) {
+	cursor, err := query(ctx, store.deliveryClient, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []*model.Delivery{}
+	for cursor.Next(ctx) {
+		var delivery model.Delivery
+		if err := cursor.Decode(&delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt) })
+	return deliveries, nil
+}
+
+func query(ctx context.Context, dbClient DBClient, filter map[string]string) (*mongo.Cursor, error) {
+	cur, err := dbClient.SimpleQuery(ctx, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+	cursor, ok := cur.(*mongo.Cursor)
+	if !ok {
+		return nil, mongo.CommandError{Message: "Invalid read operation"}
+	}
+	return cursor, nil
+}
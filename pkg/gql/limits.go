@@ -0,0 +1,130 @@
+package gql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	// DefaultMaxDepth is applied when no depth limit is configured
+	DefaultMaxDepth = 10
+	// DefaultMaxComplexity is applied when no complexity limit is configured
+	DefaultMaxComplexity = 1000
+	// the selections of a field returning a list are paid once per expected item
+	listSizeEstimate = 10
+)
+
+// checkLimits rejects operations nested deeper or costing more than allowed.
+// Introspection fields are not counted so tooling keeps working.
+func checkLimits(doc *ast.Document, operationName string, props model.GraphQLProperties) []gqlerrors.FormattedError {
+	maxDepth, maxComplexity := props.MaxDepth, props.MaxComplexity
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	if maxComplexity <= 0 {
+		maxComplexity = DefaultMaxComplexity
+	}
+
+	w := &limitsWalker{fragments: map[string]*ast.FragmentDefinition{}}
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			w.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		}
+	}
+	if operation == nil {
+		return nil
+	}
+
+	// the schema only serves queries
+	cost, depth := w.selectionCost(operation.SelectionSet, schema.QueryType())
+
+	var errs []gqlerrors.FormattedError
+	if depth > maxDepth {
+		errs = append(errs, gqlerrors.NewFormattedError(
+			fmt.Sprintf("Query depth %d exceeds the maximum depth of %d", depth, maxDepth)))
+	}
+	if cost > maxComplexity {
+		errs = append(errs, gqlerrors.NewFormattedError(
+			fmt.Sprintf("Query complexity %d exceeds the maximum complexity of %d", cost, maxComplexity)))
+	}
+	return errs
+}
+
+type limitsWalker struct {
+	fragments map[string]*ast.FragmentDefinition
+}
+
+// selectionCost returns the cost and depth of a selection set on the parent type
+func (w *limitsWalker) selectionCost(set *ast.SelectionSet, parent graphql.Type) (cost int, depth int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, selection := range set.Selections {
+		var c, d int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			c, d = w.fieldCost(selection, parent)
+		case *ast.InlineFragment:
+			fragmentType := parent
+			if selection.TypeCondition != nil {
+				fragmentType = schema.Type(selection.TypeCondition.Name.Value)
+			}
+			c, d = w.selectionCost(selection.SelectionSet, fragmentType)
+		case *ast.FragmentSpread:
+			if fragment, ok := w.fragments[selection.Name.Value]; ok {
+				c, d = w.selectionCost(fragment.SelectionSet, schema.Type(fragment.TypeCondition.Name.Value))
+			}
+		}
+		cost += c
+		if d > depth {
+			depth = d
+		}
+	}
+	return cost, depth
+}
+
+func (w *limitsWalker) fieldCost(field *ast.Field, parent graphql.Type) (int, int) {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+	var fieldDef *graphql.FieldDefinition
+	switch parent := parent.(type) {
+	case *graphql.Object:
+		fieldDef = parent.Fields()[field.Name.Value]
+	case *graphql.Interface:
+		fieldDef = parent.Fields()[field.Name.Value]
+	}
+	if fieldDef == nil {
+		return 1, 1
+	}
+
+	fieldType, isList := fieldDef.Type, false
+	for {
+		if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+			fieldType = nonNull.OfType
+			continue
+		}
+		if list, ok := fieldType.(*graphql.List); ok {
+			fieldType, isList = list.OfType, true
+			continue
+		}
+		break
+	}
+
+	childCost, childDepth := w.selectionCost(field.SelectionSet, fieldType)
+	if isList {
+		childCost *= listSizeEstimate
+	}
+	return 1 + childCost, 1 + childDepth
+}
@@ -0,0 +1,128 @@
+package gql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/client"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// loaders batch and cache store reads for the lifetime of one query.
+// Resolvers enqueue keys and return thunks, the executor resolves thunks
+// level by level so every key of a level is read in one round-trip.
+type loaders struct {
+	articleStore client.ArticleStore
+	articles     *articleLoader
+	tags         *tagsLoader
+}
+
+type loadersCtxKey struct{}
+
+func withLoaders(ctx context.Context, store client.ArticleStore) context.Context {
+	return context.WithValue(ctx, loadersCtxKey{}, &loaders{
+		articleStore: store,
+		articles:     &articleLoader{ctx: ctx, store: store, results: map[string]*model.Article{}},
+		tags:         &tagsLoader{ctx: ctx, store: store, results: map[tagKey]*tagsResult{}},
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersCtxKey{}).(*loaders)
+}
+
+// articleLoader batches article reads by id
+type articleLoader struct {
+	ctx   context.Context // of the query, the batched reads are traced under it
+	store client.ArticleStore
+
+	mu      sync.Mutex
+	pending []string
+	results map[string]*model.Article
+	loaded  map[string]error
+}
+
+// load enqueues an article id and returns a thunk resolving to the article
+func (l *articleLoader) load(articleID string) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.loaded[articleID]; !ok {
+		l.pending = append(l.pending, articleID)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if err, ok := l.loaded[articleID]; ok {
+			return l.result(articleID), err
+		}
+		l.dispatch()
+		return l.result(articleID), l.loaded[articleID]
+	}
+}
+
+// dispatch reads every pending article in one batch, callers must hold l.mu
+func (l *articleLoader) dispatch() {
+	if l.loaded == nil {
+		l.loaded = map[string]error{}
+	}
+	ids := l.pending
+	l.pending = nil
+	articles, err := l.store.ReadArticlesByIDs(l.ctx, ids)
+	for _, article := range articles {
+		l.results[article.ArticleID] = article
+	}
+	for _, id := range ids {
+		l.loaded[id] = err
+	}
+}
+
+// result returns a nil interface for missing articles so they resolve to null
+func (l *articleLoader) result(articleID string) interface{} {
+	if article, ok := l.results[articleID]; ok {
+		return article
+	}
+	return nil
+}
+
+type tagKey struct {
+	tag  string
+	date string
+}
+
+type tagsResult struct {
+	view *model.Tagsview
+	err  error
+}
+
+// tagsLoader deduplicates tag searches, the store has no batched search
+// so each distinct tag and date pair costs exactly one read
+type tagsLoader struct {
+	ctx   context.Context
+	store client.ArticleStore
+
+	mu      sync.Mutex
+	results map[tagKey]*tagsResult
+}
+
+func (l *tagsLoader) load(tag string, date string) func() (interface{}, error) {
+	key := tagKey{tag: tag, date: date}
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		res, ok := l.results[key]
+		if !ok {
+			res = &tagsResult{}
+			views, err := l.store.SearchTagsByDate(l.ctx, date, tag)
+			res.err = err
+			if len(views) > 0 {
+				res.view = views[0]
+			}
+			l.results[key] = res
+		}
+		if res.view == nil {
+			return nil, res.err
+		}
+		return &tagsview{Tagsview: res.view, date: date}, res.err
+	}
+}
@@ -0,0 +1,318 @@
+package gql
+
+import (
+	"context"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/client"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request is a GraphQL request as received over http
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Do parses, validates and executes a request against the article store.
+// Requests beyond the configured depth and complexity are rejected before execution.
+func Do(ctx context.Context, store client.ArticleStore, req Request, props model.GraphQLProperties) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if validation := graphql.ValidateDocument(&schema, doc, nil); !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+	if errs := checkLimits(doc, req.OperationName, props); len(errs) > 0 {
+		return &graphql.Result{Errors: errs}
+	}
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, store),
+	})
+}
+
+// tagRef is a tag seen in the context of a date, the date scopes its stats
+type tagRef struct {
+	name  string
+	date  string
+	count *int
+}
+
+// tagsview is a search result along with the date it was searched for
+type tagsview struct {
+	*model.Tagsview
+	date string
+}
+
+var schema = mustSchema()
+
+func mustSchema() graphql.Schema {
+	tagsviewType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Tagsview",
+		Description: "Stats of a tag on a date",
+		Fields:      graphql.Fields{},
+	})
+	articleType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Article",
+		Fields: graphql.Fields{},
+	})
+	tagType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tag",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(tagRef).name, nil
+				},
+			},
+			"count": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "Number of articles with the tag, on the date the tag was reached from",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ref := p.Source.(tagRef)
+					if ref.count != nil {
+						return *ref.count, nil
+					}
+					if ref.date == "" {
+						return nil, nil
+					}
+					load := loadersFrom(p.Context).tags.load(ref.name, ref.date)
+					return func() (interface{}, error) {
+						view, err := load()
+						if view == nil || err != nil {
+							return nil, err
+						}
+						return view.(*tagsview).Count, nil
+					}, nil
+				},
+			},
+			"stats": &graphql.Field{
+				Type:        tagsviewType,
+				Description: "Stats of the tag, on the given date or the date the tag was reached from",
+				Args: graphql.FieldConfigArgument{
+					"date": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ref := p.Source.(tagRef)
+					date, _ := p.Args["date"].(string)
+					if date == "" {
+						date = ref.date
+					}
+					if date == "" {
+						return nil, nil
+					}
+					return loadersFrom(p.Context).tags.load(ref.name, date), nil
+				},
+			},
+		},
+	})
+
+	articleType.AddFieldConfig("id", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Article).ArticleID, nil
+		},
+	})
+	articleType.AddFieldConfig("title", &graphql.Field{
+		Type: graphql.String,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Article).Title, nil
+		},
+	})
+	articleType.AddFieldConfig("date", &graphql.Field{
+		Type: graphql.String,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Article).Date, nil
+		},
+	})
+	articleType.AddFieldConfig("body", &graphql.Field{
+		Type: graphql.String,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Article).Body, nil
+		},
+	})
+	articleType.AddFieldConfig("author", &graphql.Field{
+		Type:        graphql.String,
+		Description: "Subject of the caller who created the article",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Article).Author, nil
+		},
+	})
+	articleType.AddFieldConfig("createdAt", &graphql.Field{
+		Type: graphql.DateTime,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return optionalTime(p.Source.(*model.Article).CreatedAt), nil
+		},
+	})
+	articleType.AddFieldConfig("updatedAt", &graphql.Field{
+		Type: graphql.DateTime,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return optionalTime(p.Source.(*model.Article).UpdatedAt), nil
+		},
+	})
+	articleType.AddFieldConfig("tags", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			article := p.Source.(*model.Article)
+			return tagRefs(article.Tags, article.Date), nil
+		},
+	})
+
+	tagsviewType.AddFieldConfig("tag", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*tagsview).Tag, nil
+		},
+	})
+	tagsviewType.AddFieldConfig("date", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*tagsview).date, nil
+		},
+	})
+	tagsviewType.AddFieldConfig("count", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.Int),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*tagsview).Count, nil
+		},
+	})
+	tagsviewType.AddFieldConfig("articleIds", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+		Description: "Ids of the 10 latest articles",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return derefStrings(p.Source.(*tagsview).Articles), nil
+		},
+	})
+	tagsviewType.AddFieldConfig("articles", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(articleType)),
+		Description: "The 10 latest articles",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			loader := loadersFrom(p.Context).articles
+			var articles []interface{}
+			for _, id := range derefStrings(p.Source.(*tagsview).Articles) {
+				articles = append(articles, loader.load(id))
+			}
+			return articles, nil
+		},
+	})
+	tagsviewType.AddFieldConfig("relatedTags", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			view := p.Source.(*tagsview)
+			return tagRefs(view.RelatedTags, view.date), nil
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"article": &graphql.Field{
+				Type: articleType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p.Context).articles.load(p.Args["id"].(string)), nil
+				},
+			},
+			"articles": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(articleType)),
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					loader := loadersFrom(p.Context).articles
+					var articles []interface{}
+					for _, id := range p.Args["ids"].([]interface{}) {
+						articles = append(articles, loader.load(id.(string)))
+					}
+					return articles, nil
+				},
+			},
+			"tags": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))),
+				Description: "All tags with their article count, most used first",
+				Args: graphql.FieldConfigArgument{
+					"date": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: "Only count articles of this date",
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					date, _ := p.Args["date"].(string)
+					views, err := loadersFrom(p.Context).articleStore.ListTags(p.Context, date)
+					if err != nil {
+						return nil, err
+					}
+					tags := make([]tagRef, 0, len(views))
+					for _, view := range views {
+						count := view.Count
+						tags = append(tags, tagRef{name: view.Tag, date: date, count: &count})
+					}
+					return tags, nil
+				},
+			},
+			"searchTags": &graphql.Field{
+				Type:        tagsviewType,
+				Description: "Stats of a tag on a date, as served by /api/tags/{tagName}/{date}",
+				Args: graphql.FieldConfigArgument{
+					"tag":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"date": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p.Context).tags.load(p.Args["tag"].(string), p.Args["date"].(string)), nil
+				},
+			},
+		},
+	})
+
+	s, err := graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func tagRefs(tags []*string, date string) []tagRef {
+	refs := make([]tagRef, 0, len(tags))
+	seen := map[string]bool{}
+	// duplicate tags are persisted as is but filtered in views
+	for _, tag := range derefStrings(tags) {
+		if !seen[tag] {
+			seen[tag] = true
+			refs = append(refs, tagRef{name: tag, date: date})
+		}
+	}
+	return refs
+}
+
+func derefStrings(values []*string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			result = append(result, *v)
+		}
+	}
+	return result
+}
+
+func optionalTime(t *time.Time) interface{} {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return *t
+}
@@ -0,0 +1,143 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoBatchesArticleReads(t *testing.T) {
+	store := &mockArticleStore{}
+	result := Do(context.Background(), store, Request{Query: `{
+		searchTags(tag: "health", date: "2016-09-22") {
+			count
+			articles { id tags { name count } }
+			relatedTags { name stats { articles { id } } }
+		}
+	}`}, model.GraphQLProperties{})
+	assert.Empty(t, result.Errors)
+
+	body, _ := json.Marshal(result.Data)
+	assert.JSONEq(t, `{"searchTags": {
+		"count": 2,
+		"articles": [
+			{"id": "1", "tags": [{"name": "health", "count": 2}, {"name": "fitness", "count": 2}]},
+			{"id": "2", "tags": [{"name": "health", "count": 2}]}
+		],
+		"relatedTags": [{"name": "fitness", "stats": {"articles": [{"id": "1"}, {"id": "2"}]}}]
+	}}`, string(body))
+
+	// articles of the search are read in one batch, those of the related tag stats are already cached
+	assert.Equal(t, [][]string{{"1", "2"}}, store.batches)
+	// each distinct tag and date pair is searched once
+	assert.Equal(t, 2, store.searches)
+}
+
+func TestDoListsTags(t *testing.T) {
+	result := Do(context.Background(), &mockArticleStore{}, Request{
+		Query:     `query Tags($date: String) { tags(date: $date) { name count } }`,
+		Variables: map[string]interface{}{"date": "2016-09-22"},
+	}, model.GraphQLProperties{})
+	assert.Empty(t, result.Errors)
+
+	body, _ := json.Marshal(result.Data)
+	assert.JSONEq(t, `{"tags": [{"name": "health", "count": 2}, {"name": "fitness", "count": 1}]}`, string(body))
+}
+
+func TestDoMissingArticleIsNull(t *testing.T) {
+	result := Do(context.Background(), &mockArticleStore{}, Request{Query: `{ article(id: "404") { id } }`}, model.GraphQLProperties{})
+	assert.Empty(t, result.Errors)
+	body, _ := json.Marshal(result.Data)
+	assert.JSONEq(t, `{"article": null}`, string(body))
+}
+
+func TestDoEnforcesLimits(t *testing.T) {
+	deepQuery := `{ article(id: "1") { tags { stats { articles { tags { stats { articles { id } } } } } } } }`
+
+	testScenarios := []struct {
+		Description string
+		Query       string
+		Props       model.GraphQLProperties
+		Errors      int
+	}{
+		{"Within defaults", `{ article(id: "1") { id } }`, model.GraphQLProperties{}, 0},
+		{"Too deep", deepQuery, model.GraphQLProperties{MaxDepth: 5, MaxComplexity: 100000}, 1},
+		{"Too complex", `{ articles(ids: ["1", "2"]) { id title body } }`, model.GraphQLProperties{MaxComplexity: 30}, 1},
+		{"Fragments are counted", `{ ...A } fragment A on Query { articles(ids: ["1"]) { id title body } }`,
+			model.GraphQLProperties{MaxComplexity: 30}, 1},
+		{"Too deep and complex", deepQuery, model.GraphQLProperties{MaxDepth: 3, MaxComplexity: 3}, 2},
+		{"Introspection not counted", `{ __schema { types { fields { type { ofType { name } } } } } }`,
+			model.GraphQLProperties{MaxDepth: 1, MaxComplexity: 1}, 0},
+		{"Invalid query", `{ unknown }`, model.GraphQLProperties{}, 1},
+	}
+	for _, td := range testScenarios {
+		t.Run(td.Description, func(t *testing.T) {
+			store := &mockArticleStore{}
+			result := Do(context.Background(), store, Request{Query: td.Query}, td.Props)
+			assert.Len(t, result.Errors, td.Errors)
+			if td.Errors > 0 {
+				assert.Nil(t, result.Data)
+				assert.Empty(t, store.batches)
+			}
+		})
+	}
+}
+
+type mockArticleStore struct {
+	batches  [][]string
+	searches int
+}
+
+func strPtrs(values ...string) []*string {
+	ptrs := make([]*string, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	return ptrs
+}
+
+var mockArticles = map[string]*model.Article{
+	"1": {ArticleID: "1", Date: "2016-09-22", Tags: strPtrs("health", "fitness", "health")},
+	"2": {ArticleID: "2", Date: "2016-09-22", Tags: strPtrs("health")},
+}
+
+func (store *mockArticleStore) HealthCheck() bool {
+	return true
+}
+
+func (store *mockArticleStore) CreatArticle(ctx context.Context, article *model.Article) error {
+	return nil
+}
+
+func (store *mockArticleStore) ReadArticleByID(ctx context.Context, articleID string) (*model.Article, error) {
+	return mockArticles[articleID], nil
+}
+
+func (store *mockArticleStore) ReadArticlesByIDs(ctx context.Context, articleIDs []string) ([]*model.Article, error) {
+	store.batches = append(store.batches, articleIDs)
+	var articles []*model.Article
+	for _, id := range articleIDs {
+		if article, ok := mockArticles[id]; ok {
+			articles = append(articles, article)
+		}
+	}
+	return articles, nil
+}
+
+func (store *mockArticleStore) ListTags(ctx context.Context, date string) ([]*model.Tagsview, error) {
+	return []*model.Tagsview{{Tag: "health", Count: 2}, {Tag: "fitness", Count: 1}}, nil
+}
+
+func (store *mockArticleStore) SearchTagsByDate(ctx context.Context, date string, tag string) ([]*model.Tagsview, error) {
+	store.searches++
+	switch tag {
+	case "health":
+		return []*model.Tagsview{{Tag: tag, Count: 2, Articles: strPtrs("1", "2"), RelatedTags: strPtrs("fitness")}}, nil
+	case "fitness":
+		return []*model.Tagsview{{Tag: tag, Count: 2, Articles: strPtrs("1", "2"), RelatedTags: strPtrs("health")}}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+)
+
+// DiskChecker fails when the filesystem of path has less than minFree bytes available
+func DiskChecker(path string, minFree uint64) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			return err
+		}
+		free := stat.Bavail * uint64(stat.Bsize)
+		if free < minFree {
+			return fmt.Errorf("%d MB free on %s, %d MB required", free>>20, path, minFree>>20)
+		}
+		return nil
+	})
+}
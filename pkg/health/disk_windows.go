@@ -0,0 +1,13 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+// DiskChecker is not supported on windows, it always fails
+func DiskChecker(path string, minFree uint64) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return fmt.Errorf("disk checks are not supported on windows")
+	})
+}
@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// Statuses of the probes and their components
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// Checker checks a component the app depends on, a nil error is healthy
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a func to a Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx)
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Registry holds the checkers of the readiness probe
+type Registry struct {
+	timeout time.Duration
+
+	mu       sync.RWMutex
+	names    []string // in registration order
+	checkers map[string]Checker
+
+	draining int32
+}
+
+// NewRegistry returns a registry checking the disk of every configured path,
+// the other components register their own checkers
+func NewRegistry(props model.HealthProperties) *Registry {
+	timeout := time.Duration(props.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	minFreeMB := props.MinFreeMB
+	if minFreeMB <= 0 {
+		minFreeMB = 100
+	}
+	registry := &Registry{timeout: timeout, checkers: map[string]Checker{}}
+	for _, path := range props.DiskPaths {
+		registry.Register("disk:"+path, DiskChecker(path, uint64(minFreeMB)<<20))
+	}
+	return registry
+}
+
+// Register adds a checker to the readiness probe, replacing the one of the same name
+func (r *Registry) Register(name string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.checkers[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.checkers[name] = checker
+}
+
+// Drain flips readiness to false for good, called once shutdown starts
+// so that no new traffic is routed to the app while it drains
+func (r *Registry) Drain() {
+	atomic.StoreInt32(&r.draining, 1)
+}
+
+// Live reports that the process is up and serving, it never checks dependencies
+// so that an unreachable database does not get the app restarted
+func (r *Registry) Live() *model.Health {
+	return &model.Health{Status: StatusSuccess}
+}
+
+// Ready runs every checker concurrently, each bounded by the timeout,
+// the app is ready when all of them pass and it is not draining
+func (r *Registry) Ready(ctx context.Context) (*model.Health, bool) {
+	if atomic.LoadInt32(&r.draining) == 1 {
+		return &model.Health{
+			Status:     StatusFailure,
+			Components: []*model.ComponentHealth{{Name: "shutdown", Status: StatusFailure, Error: "Shutting down"}},
+		}, false
+	}
+
+	r.mu.RLock()
+	names := append([]string(nil), r.names...)
+	checkers := make([]Checker, len(names))
+	for i, name := range names {
+		checkers[i] = r.checkers[name]
+	}
+	r.mu.RUnlock()
+
+	components := make([]*model.ComponentHealth, len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			components[i] = r.check(ctx, names[i], checkers[i])
+		}(i)
+	}
+	wg.Wait()
+
+	health := &model.Health{Status: StatusSuccess, Components: components}
+	for _, component := range components {
+		if component.Status != StatusSuccess {
+			health.Status = StatusFailure
+		}
+	}
+	return health, health.Status == StatusSuccess
+}
+
+func (r *Registry) check(ctx context.Context, name string, checker Checker) *model.ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				errc <- fmt.Errorf("panic: %v", err)
+			}
+		}()
+		errc <- checker.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		// checkers ignoring the context are not waited for
+		err = ctx.Err()
+	}
+
+	component := &model.ComponentHealth{
+		Name:      name,
+		Status:    StatusSuccess,
+		LatencyMS: time.Since(start).Seconds() * 1000,
+	}
+	if err != nil {
+		component.Status = StatusFailure
+		component.Error = err.Error()
+	}
+	return component
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadyReportsEveryComponent(t *testing.T) {
+	registry := NewRegistry(model.HealthProperties{Timeout: 1})
+	registry.Register("database", CheckerFunc(func(ctx context.Context) error { return nil }))
+	registry.Register("cache", CheckerFunc(func(ctx context.Context) error { return errors.New("connection refused") }))
+	registry.Register("slow", CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	health, ready := registry.Ready(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, StatusFailure, health.Status)
+	assert.Len(t, health.Components, 3)
+	assert.Equal(t, "database", health.Components[0].Name)
+	assert.Equal(t, StatusSuccess, health.Components[0].Status)
+	assert.Equal(t, "cache", health.Components[1].Name)
+	assert.Equal(t, StatusFailure, health.Components[1].Status)
+	assert.Equal(t, "connection refused", health.Components[1].Error)
+	assert.Equal(t, "slow", health.Components[2].Name)
+	assert.Equal(t, context.DeadlineExceeded.Error(), health.Components[2].Error)
+	assert.True(t, health.Components[2].LatencyMS >= 1000)
+}
+
+func TestReadyRecoversPanics(t *testing.T) {
+	registry := NewRegistry(model.HealthProperties{})
+	registry.Register("broken", CheckerFunc(func(ctx context.Context) error { panic("nil pool") }))
+
+	health, ready := registry.Ready(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, "panic: nil pool", health.Components[0].Error)
+}
+
+func TestDrain(t *testing.T) {
+	registry := NewRegistry(model.HealthProperties{})
+	registry.Register("database", CheckerFunc(func(ctx context.Context) error { return nil }))
+
+	health, ready := registry.Ready(context.Background())
+	assert.True(t, ready)
+	assert.Equal(t, StatusSuccess, health.Status)
+
+	registry.Drain()
+	health, ready = registry.Ready(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, StatusFailure, health.Status)
+	assert.Equal(t, "shutdown", health.Components[0].Name)
+	// the process is still live while it drains
+	assert.Equal(t, StatusSuccess, registry.Live().Status)
+}
+
+func TestDiskChecker(t *testing.T) {
+	registry := NewRegistry(model.HealthProperties{DiskPaths: []string{os.TempDir()}, MinFreeMB: 1})
+	health, ready := registry.Ready(context.Background())
+	assert.True(t, ready, "%+v", health.Components[0])
+	assert.Equal(t, "disk:"+os.TempDir(), health.Components[0].Name)
+
+	assert.Error(t, DiskChecker(os.TempDir(), math.MaxUint64).Check(context.Background()))
+	assert.Error(t, DiskChecker("/does/not/exist", 0).Check(context.Background()))
+}
+
+func TestRegisterReplaces(t *testing.T) {
+	registry := NewRegistry(model.HealthProperties{Timeout: 1})
+	registry.Register("database", CheckerFunc(func(ctx context.Context) error { return errors.New("down") }))
+	registry.Register("database", CheckerFunc(func(ctx context.Context) error { return nil }))
+
+	start := time.Now()
+	health, ready := registry.Ready(context.Background())
+	assert.True(t, ready)
+	assert.Len(t, health.Components, 1)
+	assert.True(t, time.Since(start) < time.Second)
+}
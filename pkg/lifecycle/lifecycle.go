@@ -0,0 +1,179 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// Phase of the shutdown. Phases run in order, the hooks of a phase concurrently.
+type Phase int
+
+// Shutdown phases, the database is closed last
+const (
+	NotReady  Phase = iota // readiness fails so that no new traffic is routed
+	Listeners              // stop accepting connections and drain the requests in flight
+	Workers                // stop the background workers once no request can hand them work
+	Telemetry              // flush the traces and stop serving the metrics of the drained work
+	Storage                // close the database and the other stores
+	phases
+)
+
+var phaseNames = [phases]string{"not ready", "listeners", "workers", "telemetry", "storage"}
+
+func (p Phase) String() string {
+	if p < 0 || p >= phases {
+		return fmt.Sprintf("phase %d", int(p))
+	}
+	return phaseNames[p]
+}
+
+// closeTimeout bounds the telemetry and storage phases, they run even once the grace period is over
+const closeTimeout = 5 * time.Second
+
+type hook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Manager shuts the app down in phases once it is signalled or fails
+type Manager struct {
+	grace      time.Duration
+	drainDelay time.Duration
+
+	mu     sync.Mutex
+	hooks  [phases][]hook
+	failed chan error
+}
+
+// New returns a manager that gives the listeners and workers the grace period of props to stop
+func New(props model.ShutdownProperties) *Manager {
+	grace := time.Duration(props.GracePeriod) * time.Second
+	if grace <= 0 {
+		grace = 15 * time.Second
+	}
+	return &Manager{
+		grace:      grace,
+		drainDelay: time.Duration(props.DrainDelay) * time.Second,
+		failed:     make(chan error, 1),
+	}
+}
+
+// OnShutdown registers stop to run in phase. stop should return once ctx is done,
+// forcing whatever is left to stop.
+func (m *Manager) OnShutdown(phase Phase, name string, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks[phase] = append(m.hooks[phase], hook{name: name, stop: stop})
+}
+
+// Fail starts the shutdown on a fatal error, eg: a listener that could not bind.
+// Only the first failure is kept.
+func (m *Manager) Fail(err error) {
+	select {
+	case m.failed <- err:
+	default:
+	}
+}
+
+// Wait blocks until a signal is received or Fail is called, then shuts down.
+// It returns the exit code: 0 after a clean shutdown on a signal, 1 otherwise.
+// A second signal exits at once, without waiting for the shutdown.
+func (m *Manager) Wait(signals <-chan os.Signal) int {
+	code := 0
+	select {
+	case sig := <-signals:
+		log.Infoln("Received", sig, "- shutting down")
+	case err := <-m.failed:
+		log.WithError(err).Errorln("Shutting down on a fatal error")
+		code = 1
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Shutdown()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			log.WithError(err).Errorln("Could not shut down gracefully")
+			code = 1
+		}
+	case sig := <-signals:
+		log.Warnln("Received", sig, "again - exiting without waiting for the shutdown")
+		code = 1
+	}
+	return code
+}
+
+// Shutdown runs the phases in order. The listeners and the workers share the grace period,
+// the telemetry and storage phases run after it in any case so that the database is always closed.
+func (m *Manager) Shutdown() error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), m.grace)
+	defer cancel()
+
+	var failures []string
+	for phase := Phase(0); phase < phases; phase++ {
+		if phase == Listeners && m.drainDelay > 0 {
+			log.Infoln("Waiting", m.drainDelay, "for the load balancers to stop routing traffic")
+			select {
+			case <-time.After(m.drainDelay):
+			case <-ctx.Done():
+			}
+		}
+		phaseCtx := ctx
+		if phase >= Telemetry {
+			var cancelPhase context.CancelFunc
+			phaseCtx, cancelPhase = context.WithTimeout(context.Background(), closeTimeout)
+			defer cancelPhase()
+		}
+		failures = append(failures, m.run(phaseCtx, phase)...)
+	}
+	log.WithField("latency_ms", time.Since(start).Seconds()*1000).Infoln("Shutdown complete")
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// run stops every hook of the phase concurrently and returns their failures
+func (m *Manager) run(ctx context.Context, phase Phase) []string {
+	m.mu.Lock()
+	hooks := m.hooks[phase]
+	m.mu.Unlock()
+
+	errs := make([]error, len(hooks))
+	var wg sync.WaitGroup
+	for i, h := range hooks {
+		wg.Add(1)
+		go func(i int, h hook) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			errs[i] = h.stop(ctx)
+		}(i, h)
+	}
+	wg.Wait()
+
+	var failures []string
+	for i, h := range hooks {
+		logger := log.WithFields(log.Fields{"phase": phase.String(), "component": h.name})
+		if errs[i] != nil {
+			logger.WithError(errs[i]).Errorln("Could not stop gracefully")
+			failures = append(failures, fmt.Sprintf("%s: %v", h.name, errs[i]))
+			continue
+		}
+		logger.Infoln("Stopped")
+	}
+	return failures
+}
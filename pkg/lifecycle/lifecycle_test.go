@@ -0,0 +1,93 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder keeps the order the hooks stopped in
+type recorder struct {
+	mu      sync.Mutex
+	stopped []string
+}
+
+func (r *recorder) hook(name string, err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.stopped = append(r.stopped, name)
+		return err
+	}
+}
+
+func TestShutdownRunsThePhasesInOrder(t *testing.T) {
+	manager := New(model.ShutdownProperties{GracePeriod: 1})
+	r := &recorder{}
+	// registered out of order
+	manager.OnShutdown(Storage, "database", r.hook("database", nil))
+	manager.OnShutdown(Telemetry, "tracing", r.hook("tracing", nil))
+	manager.OnShutdown(Listeners, "http", r.hook("http", nil))
+	manager.OnShutdown(Workers, "webhooks", r.hook("webhooks", nil))
+	manager.OnShutdown(NotReady, "probes", r.hook("probes", nil))
+
+	assert.NoError(t, manager.Shutdown())
+	assert.Equal(t, []string{"probes", "http", "webhooks", "tracing", "database"}, r.stopped)
+}
+
+func TestShutdownClosesStorageAfterTheGracePeriod(t *testing.T) {
+	manager := New(model.ShutdownProperties{GracePeriod: 1})
+	r := &recorder{}
+	manager.OnShutdown(Listeners, "http", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	manager.OnShutdown(Workers, "broken", func(ctx context.Context) error { panic("nil worker") })
+	manager.OnShutdown(Storage, "database", func(ctx context.Context) error {
+		// the storage phase is given its own deadline
+		assert.NoError(t, ctx.Err())
+		return r.hook("database", nil)(ctx)
+	})
+
+	start := time.Now()
+	err := manager.Shutdown()
+	assert.True(t, time.Since(start) >= time.Second)
+	assert.EqualError(t, err, "http: context deadline exceeded; broken: panic: nil worker")
+	assert.Equal(t, []string{"database"}, r.stopped)
+}
+
+func TestWaitExitCodes(t *testing.T) {
+	signals := make(chan os.Signal, 2)
+	signals <- syscall.SIGTERM
+	assert.Equal(t, 0, New(model.ShutdownProperties{}).Wait(signals))
+
+	manager := New(model.ShutdownProperties{})
+	manager.OnShutdown(Storage, "database", func(ctx context.Context) error { return errors.New("already closed") })
+	signals <- syscall.SIGTERM
+	assert.Equal(t, 1, manager.Wait(signals))
+
+	manager = New(model.ShutdownProperties{})
+	manager.Fail(errors.New("address already in use"))
+	manager.Fail(errors.New("ignored"))
+	assert.Equal(t, 1, manager.Wait(signals))
+}
+
+func TestWaitExitsOnASecondSignal(t *testing.T) {
+	manager := New(model.ShutdownProperties{GracePeriod: 60})
+	manager.OnShutdown(Listeners, "http", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	signals := make(chan os.Signal, 2)
+	signals <- syscall.SIGINT
+	signals <- syscall.SIGINT
+
+	assert.Equal(t, 1, manager.Wait(signals))
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"container/list"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// coalescedKeysSize bounds the keys kept for the coalesced view, the least recently coalesced are dropped first
+const coalescedKeysSize = 100
+
+// CoalescedKey is how many callers waited on the identical call of another caller, for a store call key
+type CoalescedKey struct {
+	Method string    `json:"method"`
+	Key    string    `json:"key"`
+	Calls  int       `json:"calls"`
+	LastAt time.Time `json:"last_at"`
+}
+
+// keyCounter counts the coalesced callers per key, keeping only the size most recently coalesced keys
+// so that a scan of distinct ids cannot grow it unbounded
+type keyCounter struct {
+	size int
+
+	mu    sync.Mutex
+	order *list.List // of *CoalescedKey, the most recent first
+	keys  map[string]*list.Element
+}
+
+var coalescedKeys = newKeyCounter(coalescedKeysSize)
+
+func newKeyCounter(size int) *keyCounter {
+	return &keyCounter{size: size, order: list.New(), keys: map[string]*list.Element{}}
+}
+
+func (c *keyCounter) observe(method string, key string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := method + ":" + key
+	if element, ok := c.keys[id]; ok {
+		counted := element.Value.(*CoalescedKey)
+		counted.Calls++
+		counted.LastAt = now
+		c.order.MoveToFront(element)
+		return
+	}
+	c.keys[id] = c.order.PushFront(&CoalescedKey{Method: method, Key: key, Calls: 1, LastAt: now})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		counted := c.order.Remove(oldest).(*CoalescedKey)
+		delete(c.keys, counted.Method+":"+counted.Key)
+	}
+}
+
+// top returns copies of the keys kept, the most coalesced first
+func (c *keyCounter) top() []CoalescedKey {
+	c.mu.Lock()
+	keys := make([]CoalescedKey, 0, c.order.Len())
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		keys = append(keys, *element.Value.(*CoalescedKey))
+	}
+	c.mu.Unlock()
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].Calls > keys[j].Calls })
+	return keys
+}
+
+// CoalescedHandler serves the store call keys most recently coalesced as JSON, the most coalesced first.
+// The keys hold article ids and tags, it belongs on the admin listener next to Handler.
+func CoalescedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(coalescedKeys.top())
+	})
+}
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace of every metric of the app
+const namespace = "articleapi"
+
+// UnmatchedRoute labels the requests no route matched, keeping raw paths out of the labels
+const UnmatchedRoute = "unmatched"
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests served, by method, chi route pattern and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the HTTP requests, by method, chi route pattern and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	storeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of the article store operations, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	storeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "errors_total",
+		Help:      "Failed article store operations, by method and error code.",
+	}, []string{"method", "code"})
+
+	storeCoalesced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "coalesced_calls_total",
+		Help:      "Article store calls served by the identical call of another caller, by method.",
+	}, []string{"method"})
+
+	poolConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongo_pool",
+		Name:      "connections",
+		Help:      "Open connections of the Mongo connection pool, by server address.",
+	}, []string{"address"})
+
+	poolInUse = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongo_pool",
+		Name:      "connections_in_use",
+		Help:      "Connections checked out of the Mongo connection pool, by server address.",
+	}, []string{"address"})
+
+	poolCheckoutFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongo_pool",
+		Name:      "checkout_failures_total",
+		Help:      "Failed checkouts of the Mongo connection pool, by server address and reason.",
+	}, []string{"address", "reason"})
+
+	webhookAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "attempts_total",
+		Help:      "Attempts to deliver the article events to the webhooks, by event and resulting delivery status.",
+	}, []string{"event", "status"})
+
+	webhookDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "attempt_duration_seconds",
+		Help:      "Latency of the webhook receivers, by event.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"event"})
+
+	webhookDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "dropped_events_total",
+		Help:      "Article events dropped as the webhook queue was full.",
+	})
+
+	streamClients = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "stream",
+		Name:      "clients",
+		Help:      "Clients following the articles created, by transport.",
+	}, []string{"transport"})
+
+	streamLagging = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "stream",
+		Name:      "lagging_clients_total",
+		Help:      "Clients disconnected as they fell too far behind the articles created, by transport.",
+	}, []string{"transport"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequests, httpDuration, storeDuration, storeErrors, storeCoalesced, poolConnections, poolInUse, poolCheckoutFailures,
+		webhookAttempts, webhookDuration, webhookDropped, streamClients, streamLagging)
+}
+
+// Handler serves every registered metric, along with the go runtime and process ones
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveRequest records a served HTTP request, route is the chi route pattern or UnmatchedRoute
+func ObserveRequest(method string, route string, status int, latency time.Duration) {
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	httpRequests.With(labels).Inc()
+	httpDuration.With(labels).Observe(latency.Seconds())
+}
+
+// ObserveStore records an article store operation, errors are counted by their model error code
+func ObserveStore(method string, latency time.Duration, err error) {
+	storeDuration.WithLabelValues(method).Observe(latency.Seconds())
+	if err == nil {
+		return
+	}
+	code := model.ErrUnknown
+	if specificError, ok := err.(*model.Error); ok {
+		code = specificError.Code
+	}
+	storeErrors.WithLabelValues(method, model.ErrorCode(code)).Inc()
+}
+
+// ObserveCoalesced records a store call that waited on the identical call of another caller,
+// key identifies the call among those of the method, it is kept out of the labels
+func ObserveCoalesced(method string, key string) {
+	storeCoalesced.WithLabelValues(method).Inc()
+	coalescedKeys.observe(method, key, time.Now())
+}
+
+// ObserveWebhookAttempt records an attempt to deliver an event, status is the one of the delivery after it
+func ObserveWebhookAttempt(event string, status string, latency time.Duration) {
+	webhookAttempts.WithLabelValues(event, status).Inc()
+	webhookDuration.WithLabelValues(event).Observe(latency.Seconds())
+}
+
+// ObserveWebhookDropped records an event no webhook was told about
+func ObserveWebhookDropped() {
+	webhookDropped.Inc()
+}
+
+// ObserveStreamClient records a client of the transport connecting, delta 1, or disconnecting, delta -1
+func ObserveStreamClient(transport string, delta float64) {
+	streamClients.WithLabelValues(transport).Add(delta)
+}
+
+// ObserveStreamLagging records a client of the transport disconnected as it fell behind
+func ObserveStreamLagging(transport string) {
+	streamLagging.WithLabelValues(transport).Inc()
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func scrape(t *testing.T) string {
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := ioutil.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func TestObserveStore(t *testing.T) {
+	ObserveStore("TestRead", time.Millisecond, nil)
+	ObserveStore("TestRead", time.Millisecond, model.Errorf(model.ErrNotFound, "Not found"))
+	ObserveStore("TestRead", time.Millisecond, errors.New("connection reset"))
+
+	body := scrape(t)
+	assert.Contains(t, body, `articleapi_store_operation_duration_seconds_count{method="TestRead"} 3`)
+	assert.Contains(t, body, `articleapi_store_errors_total{code="not_found",method="TestRead"} 1`)
+	assert.Contains(t, body, `articleapi_store_errors_total{code="internal_error",method="TestRead"} 1`)
+}
+
+func TestObserveCoalesced(t *testing.T) {
+	ObserveCoalesced("TestSearch", `"tag""2019-10-02"`)
+	ObserveCoalesced("TestSearch", `"other""2019-10-02"`)
+
+	assert.Contains(t, scrape(t), `articleapi_store_coalesced_calls_total{method="TestSearch"} 2`)
+	recorder := httptest.NewRecorder()
+	CoalescedHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/coalesced", nil))
+	assert.Contains(t, recorder.Body.String(), `"method":"TestSearch","key":"\"tag\"\"2019-10-02\"","calls":1`)
+}
+
+func TestCoalescedKeys(t *testing.T) {
+	counter := newKeyCounter(2)
+	now := time.Now()
+	counter.observe("ReadArticleByID", `"1"`, now)
+	counter.observe("ReadArticleByID", `"2"`, now)
+	counter.observe("ReadArticleByID", `"2"`, now)
+	counter.observe("ReadArticleByID", `"1"`, now)
+	// the least recently coalesced key is dropped past the size
+	counter.observe("ReadArticleByID", `"3"`, now)
+
+	assert.Equal(t, []CoalescedKey{
+		{Method: "ReadArticleByID", Key: `"1"`, Calls: 2, LastAt: now},
+		{Method: "ReadArticleByID", Key: `"3"`, Calls: 1, LastAt: now},
+	}, counter.top())
+}
+
+func TestPoolMonitor(t *testing.T) {
+	address := "pool-test:27017"
+	for _, eventType := range []string{event.ConnectionCreated, event.ConnectionCreated, event.GetSucceeded, event.GetSucceeded, event.ConnectionReturned, event.ConnectionClosed} {
+		PoolMonitor.Event(&event.PoolEvent{Type: eventType, Address: address})
+	}
+	PoolMonitor.Event(&event.PoolEvent{Type: event.GetFailed, Address: address, Reason: event.ReasonTimedOut})
+
+	body := scrape(t)
+	assert.Contains(t, body, `articleapi_mongo_pool_connections{address="pool-test:27017"} 1`)
+	assert.Contains(t, body, `articleapi_mongo_pool_connections_in_use{address="pool-test:27017"} 1`)
+	assert.Contains(t, body, `articleapi_mongo_pool_checkout_failures_total{address="pool-test:27017",reason="timeout"} 1`)
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// PoolMonitor keeps the Mongo connection pool gauges up to date, set it on the client options
+var PoolMonitor = &event.PoolMonitor{Event: observePoolEvent}
+
+func observePoolEvent(e *event.PoolEvent) {
+	switch e.Type {
+	case event.ConnectionCreated:
+		poolConnections.WithLabelValues(e.Address).Inc()
+	case event.ConnectionClosed:
+		poolConnections.WithLabelValues(e.Address).Dec()
+	case event.GetSucceeded:
+		poolInUse.WithLabelValues(e.Address).Inc()
+	case event.ConnectionReturned:
+		poolInUse.WithLabelValues(e.Address).Dec()
+	case event.GetFailed:
+		poolCheckoutFailures.WithLabelValues(e.Address, e.Reason).Inc()
+	}
+}
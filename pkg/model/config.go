@@ -0,0 +1,470 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// EnvPrefix of the environment variables overriding the configuration, eg: ARTICLEAPI_DB_URL
+const EnvPrefix = "ARTICLEAPI_"
+
+// Setting is a field of the Config the environment and the flags may override.
+// Lists and maps are given as json unless they are lists of strings, given comma separated.
+type Setting struct {
+	Path string // eg: DBProperties.URL
+	Env  string // eg: ARTICLEAPI_DB_URL
+	Flag string // eg: db-url
+	Type string // of the field eg: int
+
+	index []int
+}
+
+// ConfigSettings lists every setting of the Config, in declaration order
+func ConfigSettings() []Setting {
+	return settingsOf(reflect.TypeOf(Config{}), nil, nil, nil)
+}
+
+func settingsOf(t reflect.Type, index []int, path []string, name []string) []Setting {
+	var settings []Setting
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		fieldPath := append(append([]string(nil), path...), field.Name)
+		fieldName := append(append([]string(nil), name...), splitWords(strings.TrimSuffix(field.Name, "Properties"))...)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			settings = append(settings, settingsOf(field.Type, fieldIndex, fieldPath, fieldName)...)
+			continue
+		}
+		settings = append(settings, Setting{
+			Path:  strings.Join(fieldPath, "."),
+			Env:   EnvPrefix + strings.ToUpper(strings.Join(fieldName, "_")),
+			Flag:  strings.ToLower(strings.Join(fieldName, "-")),
+			Type:  field.Type.String(),
+			index: fieldIndex,
+		})
+	}
+	return settings
+}
+
+// nameWords split the names the case alone cannot
+var nameWords = map[string][]string{
+	"GraphQL": {"GraphQL"},
+	"JWKSURL": {"JWKS", "URL"},
+}
+
+// splitWords splits a field name into its words, acronyms are kept whole eg: MaxThreadPoolSize, RedisURL, OTLPAddress
+func splitWords(name string) []string {
+	if words, ok := nameWords[name]; ok {
+		return words
+	}
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		if !unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
+
+// DefaultConfig returns the settings used when neither the file, the environment nor the flags set them
+func DefaultConfig() Config {
+	return Config{
+		DBProperties: DBProperties{
+			URL:                    "mongodb://localhost:27017",
+			DatabaseName:           "articlestore",
+			CollectionName:         "articles",
+			MaxThreadPoolSize:      10,
+			MaxTimeOut:             20,
+			KeyCollectionName:      "apikeys",
+			WebhookCollectionName:  "webhooks",
+			DeliveryCollectionName: "webhook_deliveries",
+		},
+		HTTPProperties: HTTPProperties{
+			Requests: RequestProperties{MaxBodySize: 1 << 20},
+			Metrics:  MetricsProperties{Address: ":4854"},
+			CORS:     CORSProperties{MaxAge: 600},
+			Compression: CompressionProperties{
+				MinSize:   1024,
+				Encodings: []string{"br", "gzip"},
+			},
+		},
+		Logging:  LogProperties{Level: "info", Format: "json"},
+		Tracing:  TracingProperties{OTLPAddress: "localhost:55680", SampleRatio: 1, ServiceName: "articleapi"},
+		Health:   HealthProperties{Timeout: 2, MinFreeMB: 100},
+		Shutdown: ShutdownProperties{GracePeriod: 15},
+		Webhooks: WebhookProperties{
+			Workers:        4,
+			QueueSize:      1000,
+			MaxAttempts:    8,
+			InitialBackoff: 1,
+			MaxBackoff:     3600,
+			Timeout:        10,
+		},
+		Stream: StreamProperties{Source: "auto", Heartbeat: 15, History: 1000, ClientBuffer: 64, MaxSubscriptions: 20, WriteTimeout: 10},
+	}
+}
+
+// LoadConfig layers the configuration: the defaults, then the file - json, yaml or toml by extension -,
+// then the ARTICLEAPI_ environment variables and last the overrides {Setting.Path : value} eg: of the flags.
+// Every invalid setting is reported at once as a violation.
+func LoadConfig(file *os.File, overrides map[string]string) (*Config, error) {
+	config := DefaultConfig()
+	if err := decodeConfig(file, &config); err != nil {
+		return nil, ErrorEf(ErrInvalidInput, err, "Could not decode the configuration %s", file.Name())
+	}
+
+	var violations []Violation
+	for _, setting := range ConfigSettings() {
+		value, ok := os.LookupEnv(setting.Env)
+		if override, overridden := overrides[setting.Path]; overridden {
+			value, ok = override, true
+		}
+		if !ok {
+			continue
+		}
+		if err := setting.set(&config, value); err != nil {
+			violations = append(violations, Violation{Field: setting.Path, Rule: "type", Message: err.Error()})
+		}
+	}
+	violations = append(violations, config.violations()...)
+	if len(violations) > 0 {
+		return nil, Violationsf(violations, "Invalid configuration")
+	}
+	return &config, nil
+}
+
+// decodeConfig decodes the file over the defaults. yaml and toml documents are converted to json first
+// so that every format matches the field names case insensitively.
+func decodeConfig(file *os.File, config *Config) error {
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	var document interface{}
+	switch strings.ToLower(filepath.Ext(file.Name())) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return err
+		}
+		document = stringKeys(document)
+	case ".toml":
+		if _, err := toml.Decode(string(data), &document); err != nil {
+			return err
+		}
+	default:
+		return json.Unmarshal(data, config)
+	}
+	if document == nil {
+		return nil
+	}
+	if data, err = json.Marshal(document); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, config)
+}
+
+// stringKeys converts the maps decoded by yaml, keyed by interface{}, into json objects
+func stringKeys(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			object[fmt.Sprint(k)] = stringKeys(v)
+		}
+		return object
+	case []interface{}:
+		for i, v := range value {
+			value[i] = stringKeys(v)
+		}
+	}
+	return value
+}
+
+// set parses value into the field of the setting
+func (s Setting) set(config *Config, value string) error {
+	field := reflect.ValueOf(config).Elem().FieldByIndex(s.index)
+	switch {
+	case field.Type() == reflect.TypeOf(time.Time{}):
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("must be an RFC 3339 time: %v", err)
+		}
+		field.Set(reflect.ValueOf(t))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("must be a boolean, got %q", value)
+		}
+		field.SetBool(b)
+	case field.Kind() >= reflect.Int && field.Kind() <= reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be an integer, got %q", value)
+		}
+		field.SetInt(i)
+	case field.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number, got %q", value)
+		}
+		field.SetFloat(f)
+	case field.Type() == reflect.TypeOf([]string{}) && !strings.HasPrefix(strings.TrimSpace(value), "["):
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		// lists and maps replace the ones of the file as a whole
+		decoded := reflect.New(field.Type())
+		decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(decoded.Interface()); err != nil {
+			return fmt.Errorf("must be json of a %s: %v", field.Type(), err)
+		}
+		field.Set(decoded.Elem())
+	}
+	return nil
+}
+
+// Changed lists the paths of the settings whose value differs in other, in declaration order
+func (c *Config) Changed(other *Config) []string {
+	current, next := reflect.ValueOf(c).Elem(), reflect.ValueOf(other).Elem()
+	var paths []string
+	for _, setting := range ConfigSettings() {
+		if !reflect.DeepEqual(current.FieldByIndex(setting.index).Interface(), next.FieldByIndex(setting.index).Interface()) {
+			paths = append(paths, setting.Path)
+		}
+	}
+	return paths
+}
+
+// Restore returns a copy of the configuration with the settings selected by restore set back to their value in from
+func (c *Config) Restore(from *Config, restore func(path string) bool) *Config {
+	restored := *c
+	current, previous := reflect.ValueOf(&restored).Elem(), reflect.ValueOf(from).Elem()
+	for _, setting := range ConfigSettings() {
+		if restore(setting.Path) {
+			current.FieldByIndex(setting.index).Set(previous.FieldByIndex(setting.index))
+		}
+	}
+	return &restored
+}
+
+// Validate reports every invalid setting of the configuration
+func (c *Config) Validate() error {
+	if violations := c.violations(); len(violations) > 0 {
+		return Violationsf(violations, "Invalid configuration")
+	}
+	return nil
+}
+
+func (c *Config) violations() []Violation {
+	var violations []Violation
+	invalid := func(field string, rule string, format string, args ...interface{}) {
+		violations = append(violations, Violation{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+	minimum := func(field string, value int64, min int64) {
+		if value < min {
+			invalid(field, "min", "must be at least %d, got %d", min, value)
+		}
+	}
+	// empty values stand for the default
+	oneOf := func(field string, value string, allowed ...string) {
+		if value == "" {
+			return
+		}
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		invalid(field, "enum", "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+	}
+	requiredWith := func(field string, value string, with string, withValue string) {
+		if value == "" && withValue != "" {
+			invalid(field, "required", "is required with %s", with)
+		}
+	}
+	urlWithScheme := func(field string, value string, schemes ...string) {
+		u, err := url.Parse(value)
+		if err != nil {
+			invalid(field, "format", "must be a url: %v", err)
+			return
+		}
+		for _, scheme := range schemes {
+			if u.Scheme == scheme {
+				return
+			}
+		}
+		invalid(field, "format", "must be a %s url, got %q", strings.Join(schemes, " or "), value)
+	}
+
+	db := c.DBProperties
+	if db.URL == "" {
+		invalid("DBProperties.URL", "required", "is required")
+	} else {
+		urlWithScheme("DBProperties.URL", db.URL, "mongodb", "mongodb+srv")
+	}
+	if db.DatabaseName == "" {
+		invalid("DBProperties.DatabaseName", "required", "is required")
+	}
+	if db.CollectionName == "" {
+		invalid("DBProperties.CollectionName", "required", "is required")
+	}
+	minimum("DBProperties.MaxThreadPoolSize", int64(db.MaxThreadPoolSize), 1)
+	minimum("DBProperties.MaxTimeOut", int64(db.MaxTimeOut), 1)
+	fields := articleFields()
+	for _, field := range sortedKeys(db.Indexes) {
+		if !fields[field] {
+			invalid("DBProperties.Indexes."+field, "unknown", "is not a field of the articles, expected one of %s", strings.Join(sortedKeys(fields), ", "))
+		}
+	}
+	requiredWith("DBProperties.TLS.CertFile", db.TLS.CertFile, "a KeyFile", db.TLS.KeyFile)
+	requiredWith("DBProperties.TLS.KeyFile", db.TLS.KeyFile, "a CertFile", db.TLS.CertFile)
+	requiredWith("DBProperties.Auth.Username", db.Auth.Username, "a Password", db.Auth.Password)
+	oneOf("DBProperties.Auth.Mechanism", db.Auth.Mechanism, "SCRAM-SHA-1", "SCRAM-SHA-256", "MONGODB-X509")
+	if db.Auth.Mechanism == "MONGODB-X509" && (!db.TLS.Enabled || db.TLS.CertFile == "") {
+		invalid("DBProperties.TLS.CertFile", "required", "is required by MONGODB-X509, with TLS enabled")
+	}
+
+	http := c.HTTPProperties
+	minimum("HTTPProperties.GraphQL.MaxDepth", int64(http.GraphQL.MaxDepth), 0)
+	minimum("HTTPProperties.GraphQL.MaxComplexity", int64(http.GraphQL.MaxComplexity), 0)
+	minimum("HTTPProperties.Requests.MaxBodySize", http.Requests.MaxBodySize, 0)
+	minimum("HTTPProperties.Auth.JWT.JWKSRefreshInterval", int64(http.Auth.JWT.JWKSRefreshInterval), 0)
+	if http.Auth.JWT.JWKSURL != "" {
+		urlWithScheme("HTTPProperties.Auth.JWT.JWKSURL", http.Auth.JWT.JWKSURL, "http", "https")
+	}
+	limits := map[string]RateLimit{"HTTPProperties.RateLimit.Default": http.RateLimit.Default, "HTTPProperties.RateLimit.PerIP": http.RateLimit.PerIP}
+	for route, limit := range http.RateLimit.Routes {
+		limits["HTTPProperties.RateLimit.Routes."+route] = limit
+	}
+	for _, field := range sortedKeys(limits) {
+		minimum(field+".Requests", int64(limits[field].Requests), 0)
+		minimum(field+".Period", int64(limits[field].Period), 0)
+		minimum(field+".Burst", int64(limits[field].Burst), 0)
+	}
+	if http.RateLimit.RedisURL != "" {
+		urlWithScheme("HTTPProperties.RateLimit.RedisURL", http.RateLimit.RedisURL, "redis", "rediss")
+	}
+	requiredWith("HTTPProperties.TLS.CertFile", http.TLS.CertFile, "a KeyFile", http.TLS.KeyFile)
+	requiredWith("HTTPProperties.TLS.KeyFile", http.TLS.KeyFile, "a CertFile", http.TLS.CertFile)
+	if http.TLS.KeyFile == "" {
+		requiredWith("HTTPProperties.TLS.CertFile", http.TLS.CertFile, "a ClientCAFile", http.TLS.ClientCAFile)
+	}
+	requiredWith("HTTPProperties.TLS.ClientCAFile", http.TLS.ClientCAFile, "a ClientAuth", http.TLS.ClientAuth)
+	oneOf("HTTPProperties.TLS.MinVersion", http.TLS.MinVersion, "1.2", "1.3")
+	oneOf("HTTPProperties.TLS.ClientAuth", http.TLS.ClientAuth, "request", "require")
+	for _, name := range sortedKeys(http.TLS.RoleMapping) {
+		oneOf("HTTPProperties.TLS.RoleMapping."+name, http.TLS.RoleMapping[name], RoleReader, RoleEditor, RoleAdmin)
+	}
+	for i, origin := range http.CORS.AllowedOrigins {
+		field := fmt.Sprintf("HTTPProperties.CORS.AllowedOrigins.%d", i)
+		if origin == "*" {
+			if http.CORS.AllowCredentials {
+				invalid(field, "invalid", "cannot be * when credentials are allowed, list the origins")
+			}
+			continue
+		}
+		urlWithScheme(field, strings.Replace(origin, "*.", "", 1), "http", "https")
+	}
+	minimum("HTTPProperties.CORS.MaxAge", int64(http.CORS.MaxAge), 0)
+	minimum("HTTPProperties.Compression.MinSize", int64(http.Compression.MinSize), 0)
+	for i, encoding := range http.Compression.Encodings {
+		oneOf(fmt.Sprintf("HTTPProperties.Compression.Encodings.%d", i), encoding, "br", "gzip")
+	}
+
+	if _, err := NewArticleValidator(c.Validation); err != nil {
+		invalid("Validation", "invalid", "%v", err)
+	}
+
+	oneOf("Logging.Level", c.Logging.Level, "trace", "debug", "info", "warn", "warning", "error", "fatal", "panic")
+	oneOf("Logging.Format", c.Logging.Format, "json", "text")
+
+	oneOf("Tracing.Exporter", c.Tracing.Exporter, "otlp", "file")
+	if c.Tracing.Exporter == "file" && c.Tracing.File == "" {
+		invalid("Tracing.File", "required", "is required by the file exporter")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("Tracing.SampleRatio", "range", "must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+
+	minimum("Health.Timeout", int64(c.Health.Timeout), 0)
+	minimum("Health.MinFreeMB", int64(c.Health.MinFreeMB), 0)
+	minimum("Shutdown.GracePeriod", int64(c.Shutdown.GracePeriod), 1)
+	minimum("Shutdown.DrainDelay", int64(c.Shutdown.DrainDelay), 0)
+
+	webhooks := c.Webhooks
+	if webhooks.Enabled {
+		if db.WebhookCollectionName == "" {
+			invalid("DBProperties.WebhookCollectionName", "required", "is required by the webhooks")
+		}
+		if db.DeliveryCollectionName == "" {
+			invalid("DBProperties.DeliveryCollectionName", "required", "is required by the webhooks")
+		}
+	}
+	minimum("Webhooks.Workers", int64(webhooks.Workers), 1)
+	minimum("Webhooks.QueueSize", int64(webhooks.QueueSize), 1)
+	minimum("Webhooks.MaxAttempts", int64(webhooks.MaxAttempts), 1)
+	minimum("Webhooks.InitialBackoff", int64(webhooks.InitialBackoff), 1)
+	minimum("Webhooks.MaxBackoff", int64(webhooks.MaxBackoff), int64(webhooks.InitialBackoff))
+	minimum("Webhooks.Timeout", int64(webhooks.Timeout), 1)
+
+	oneOf("Stream.Source", c.Stream.Source, "auto", "mongo", "memory")
+	minimum("Stream.Heartbeat", int64(c.Stream.Heartbeat), 1)
+	minimum("Stream.History", int64(c.Stream.History), 0)
+	minimum("Stream.ClientBuffer", int64(c.Stream.ClientBuffer), 1)
+	minimum("Stream.MaxSubscriptions", int64(c.Stream.MaxSubscriptions), 1)
+	minimum("Stream.WriteTimeout", int64(c.Stream.WriteTimeout), 1)
+	return violations
+}
+
+// articleFields are the names the article fields are persisted under, the only ones that can be indexed
+func articleFields() map[string]bool {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(Article{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("bson"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// configFile writes the document into a temp file named name, the extension picks its format
+func configFile(t *testing.T, name string, document string) *os.File {
+	dir, err := ioutil.TempDir("", "config")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, name)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(document), 0600))
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+func TestConfigSettings(t *testing.T) {
+	settings := map[string]Setting{}
+	for _, setting := range ConfigSettings() {
+		settings[setting.Path] = setting
+	}
+
+	assert.Equal(t, "ARTICLEAPI_DB_URL", settings["DBProperties.URL"].Env)
+	assert.Equal(t, "db-url", settings["DBProperties.URL"].Flag)
+	assert.Equal(t, "ARTICLEAPI_HTTP_RATE_LIMIT_REDIS_URL", settings["HTTPProperties.RateLimit.RedisURL"].Env)
+	assert.Equal(t, "http-graphql-max-depth", settings["HTTPProperties.GraphQL.MaxDepth"].Flag)
+	assert.Equal(t, "ARTICLEAPI_HTTP_AUTH_JWT_JWKS_URL", settings["HTTPProperties.Auth.JWT.JWKSURL"].Env)
+	assert.Equal(t, "map[string]bool", settings["DBProperties.Indexes"].Type)
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	documents := map[string]string{
+		"config.json": `{"DBProperties": {"URL": "mongodb://db:27017", "Indexes": {"ArticleID": true}},
+			"HTTPProperties": {"RateLimit": {"Default": {"Requests": 5}}}, "Health": {"DiskPaths": ["/data"]}}`,
+		"config.yaml": `
+DBProperties:
+  URL: mongodb://db:27017
+  Indexes:
+    ArticleID: true
+HTTPProperties:
+  RateLimit:
+    Default:
+      Requests: 5
+Health:
+  DiskPaths: [/data]
+`,
+		"config.toml": `
+[DBProperties]
+URL = "mongodb://db:27017"
+[DBProperties.Indexes]
+ArticleID = true
+[HTTPProperties.RateLimit.Default]
+Requests = 5
+[Health]
+DiskPaths = ["/data"]
+`,
+	}
+	for name, document := range documents {
+		t.Run(name, func(t *testing.T) {
+			config, err := LoadConfig(configFile(t, name, document), nil)
+			assert.NoError(t, err)
+			assert.Equal(t, "mongodb://db:27017", config.DBProperties.URL)
+			assert.Equal(t, map[string]bool{"ArticleID": true}, config.DBProperties.Indexes)
+			assert.Equal(t, 5, config.HTTPProperties.RateLimit.Default.Requests)
+			assert.Equal(t, []string{"/data"}, config.Health.DiskPaths)
+			// unset settings keep their defaults
+			assert.Equal(t, "articlestore", config.DBProperties.DatabaseName)
+			assert.Equal(t, 10, config.DBProperties.MaxThreadPoolSize)
+			assert.Equal(t, int64(1<<20), config.HTTPProperties.Requests.MaxBodySize)
+		})
+	}
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	file := configFile(t, "config.json", `{"DBProperties": {"URL": "mongodb://file:27017", "DatabaseName": "file"}}`)
+	os.Setenv("ARTICLEAPI_DB_URL", "mongodb://env:27017")
+	os.Setenv("ARTICLEAPI_DB_DATABASE_NAME", "env")
+	os.Setenv("ARTICLEAPI_HEALTH_DISK_PATHS", "/data, /logs")
+	defer os.Unsetenv("ARTICLEAPI_DB_URL")
+	defer os.Unsetenv("ARTICLEAPI_DB_DATABASE_NAME")
+	defer os.Unsetenv("ARTICLEAPI_HEALTH_DISK_PATHS")
+
+	config, err := LoadConfig(file, map[string]string{
+		"DBProperties.DatabaseName": "flag",
+		"DBProperties.Indexes":      `{"Tags": false}`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "mongodb://env:27017", config.DBProperties.URL)
+	assert.Equal(t, "flag", config.DBProperties.DatabaseName)
+	assert.Equal(t, map[string]bool{"Tags": false}, config.DBProperties.Indexes)
+	assert.Equal(t, []string{"/data", "/logs"}, config.Health.DiskPaths)
+}
+
+func TestLoadConfigViolations(t *testing.T) {
+	file := configFile(t, "config.json", `{"DBProperties": {"URL": "", "MaxThreadPoolSize": 0, "Indexes": {"Nope": true}},
+		"Tracing": {"Exporter": "file"}}`)
+
+	_, err := LoadConfig(file, map[string]string{"Health.Timeout": "soon", "Logging.Level": "loud", "Webhooks.MaxBackoff": "0", "Stream.Source": "kafka"})
+	invalid, ok := err.(*Error)
+	assert.True(t, ok)
+	assert.Equal(t, ErrInvalidInput, invalid.Code)
+	fields := map[string]string{}
+	for _, violation := range invalid.Violations {
+		fields[violation.Field] = violation.Rule
+	}
+	assert.Equal(t, map[string]string{
+		"Health.Timeout":                 "type",
+		"DBProperties.URL":               "required",
+		"DBProperties.MaxThreadPoolSize": "min",
+		"DBProperties.Indexes.Nope":      "unknown",
+		"Logging.Level":                  "enum",
+		"Tracing.File":                   "required",
+		"Webhooks.MaxBackoff":            "min",
+		"Stream.Source":                  "enum",
+	}, fields)
+}
+
+func TestLoadConfigDecodeError(t *testing.T) {
+	_, err := LoadConfig(configFile(t, "config.yaml", "DBProperties: [nope"), nil)
+	assert.Error(t, err)
+}
+
+func TestConfigChanged(t *testing.T) {
+	current, next := DefaultConfig(), DefaultConfig()
+	assert.Empty(t, current.Changed(&next))
+
+	next.Logging.Level = "debug"
+	next.HTTPProperties.CacheControl = map[string]string{"/api/articles/{id}": "max-age=60"}
+	next.HTTPProperties.RateLimit.Default.Requests = 5
+	assert.Equal(t, []string{"HTTPProperties.CacheControl", "HTTPProperties.RateLimit.Default.Requests", "Logging.Level"}, current.Changed(&next))
+}
+
+func TestConfigRestore(t *testing.T) {
+	current, next := DefaultConfig(), DefaultConfig()
+	current.HTTPProperties.Auth.Enabled = true
+	next.Logging.Level = "debug"
+
+	restored := next.Restore(&current, func(path string) bool { return strings.HasPrefix(path, "HTTPProperties.Auth.") })
+	assert.True(t, restored.HTTPProperties.Auth.Enabled)
+	assert.Equal(t, "debug", restored.Logging.Level)
+	assert.False(t, next.HTTPProperties.Auth.Enabled, "the configuration restored from is left as it is")
+}
+
+func TestTLSViolations(t *testing.T) {
+	config := DefaultConfig()
+	config.HTTPProperties.TLS = TLSProperties{
+		KeyFile:      "tls.key",
+		ClientCAFile: "ca.crt",
+		ClientAuth:   "optional",
+		MinVersion:   "1.1",
+		RoleMapping:  map[string]string{"reporting": "root"},
+	}
+	config.DBProperties.TLS = ClientTLSProperties{CertFile: "client.crt"}
+	config.DBProperties.Auth = DBAuthProperties{Password: "secret", Mechanism: "MONGODB-X509"}
+
+	invalid, ok := config.Validate().(*Error)
+	assert.True(t, ok)
+	fields := map[string]string{}
+	for _, violation := range invalid.Violations {
+		fields[violation.Field] = violation.Rule
+	}
+	assert.Equal(t, map[string]string{
+		"HTTPProperties.TLS.CertFile":              "required",
+		"HTTPProperties.TLS.ClientAuth":            "enum",
+		"HTTPProperties.TLS.MinVersion":            "enum",
+		"HTTPProperties.TLS.RoleMapping.reporting": "enum",
+		"DBProperties.TLS.KeyFile":                 "required",
+		"DBProperties.TLS.CertFile":                "required",
+		"DBProperties.Auth.Username":               "required",
+	}, fields)
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/xml"
 	"fmt"
 )
 
@@ -14,20 +15,94 @@ const (
 	ErrDuplicate
 	// ErrNotFound is used when attempting to read a non-existing entry
 	ErrNotFound
+	// ErrNotAcceptable is used when none of the accepted response formats can be produced
+	ErrNotAcceptable
+	// ErrUnsupportedMediaType is used when the request body format is not supported
+	ErrUnsupportedMediaType
+	// ErrUnauthorized is used when the caller could not be authenticated
+	ErrUnauthorized
+	// ErrForbidden is used when the caller lacks the permission for the operation
+	ErrForbidden
+	// ErrTooManyRequests is used when the caller exceeded its rate limit
+	ErrTooManyRequests
+	// ErrTooLarge is used when the request body exceeds the allowed size
+	ErrTooLarge
 )
 
-// Error defines an error that separates internal and external error messages
+// errorCodes are the stable names of the error codes sent to clients, never rename one
+var errorCodes = map[int]string{
+	ErrUnknown:              "internal_error",
+	ErrInvalidInput:         "invalid_input",
+	ErrDuplicate:            "duplicate",
+	ErrNotFound:             "not_found",
+	ErrNotAcceptable:        "not_acceptable",
+	ErrUnsupportedMediaType: "unsupported_media_type",
+	ErrUnauthorized:         "unauthorized",
+	ErrForbidden:            "forbidden",
+	ErrTooManyRequests:      "too_many_requests",
+	ErrTooLarge:             "too_large",
+}
+
+// ErrorCode returns the stable name of an error code, internal_error for unknown ones
+func ErrorCode(code int) string {
+	if name, ok := errorCodes[code]; ok {
+		return name
+	}
+	return errorCodes[ErrUnknown]
+}
+
+// ErrorCodes returns the stable names of every error code in code order
+func ErrorCodes() []string {
+	names := make([]string, 0, len(errorCodes))
+	for code := ErrUnknown; code < len(errorCodes); code++ {
+		names = append(names, errorCodes[code])
+	}
+	return names
+}
+
+// ProblemTypePrefix of the type of every Problem, followed by its Code
+const ProblemTypePrefix = "urn:articleapi:problem:"
+
+// Error defines an error that separates internal and external error messages,
+// the Cause is internal and only ever logged
 type Error struct {
-	Code    int    `json:"code,omitempty"`
-	Message string `json:"message,omitempty"`
-	Cause   error  `json:"detail,omitempty"`
+	Code       int
+	Message    string
+	Cause      error
+	Violations []Violation
+}
+
+// Problem details of an error response as per RFC 7807, extended with a stable Code,
+// the RequestID of the failed request and the Violations of an invalid input
+type Problem struct {
+	XMLName    xml.Name    `json:"-" xml:"urn:ietf:rfc:7807 problem" yaml:"-"`
+	Type       string      `json:"type" xml:"type" yaml:"type"`
+	Title      string      `json:"title" xml:"title" yaml:"title"`
+	Status     int         `json:"status" xml:"status" yaml:"status"`
+	Detail     string      `json:"detail,omitempty" xml:"detail,omitempty" yaml:"detail,omitempty"`
+	Instance   string      `json:"instance,omitempty" xml:"instance,omitempty" yaml:"instance,omitempty"`
+	Code       string      `json:"code" xml:"code" yaml:"code"`
+	RequestID  string      `json:"request_id,omitempty" xml:"request_id,omitempty" yaml:"request_id,omitempty"`
+	Violations []Violation `json:"violations,omitempty" xml:"violations>violation,omitempty" yaml:"violations,omitempty"`
+}
+
+// Violation describes one problem of an invalid input,
+// Field is a JSON pointer to the offending value eg: /body/tags/0
+type Violation struct {
+	Field   string `json:"field" xml:"field" yaml:"field"`
+	Rule    string `json:"rule" xml:"rule" yaml:"rule"`
+	Message string `json:"message" xml:"message" yaml:"message"`
 }
 
 func (e *Error) Error() string {
+	message := e.Message
+	for _, v := range e.Violations {
+		message = fmt.Sprintf("%v\n%v: %v", message, v.Field, v.Message)
+	}
 	if e.Cause == nil {
-		return e.Message
+		return message
 	}
-	return fmt.Sprintf("%v\n%v", e.Message, e.Cause)
+	return fmt.Sprintf("%v\n%v", message, e.Cause)
 }
 
 // Errorf creates a new Error with formatting
@@ -43,3 +118,10 @@ func ErrorEf(code int, cause error, format string, args ...interface{}) *Error {
 		Cause:   cause,
 	}
 }
+
+// Violationsf creates a new Error itemizing the violations of an invalid input
+func Violationsf(violations []Violation, format string, args ...interface{}) *Error {
+	err := Errorf(ErrInvalidInput, format, args...)
+	err.Violations = violations
+	return err
+}
@@ -1,56 +1,451 @@
 package model
 
 import (
-	"encoding/json"
-	"fmt"
-	"os"
+	"encoding/xml"
+	"strings"
+	"time"
 )
 
-// Health - API health
+// Health - API health, readiness lists the health of every component it checked
 type Health struct {
-	Status string `json:"status,omitempty"`
+	XMLName    xml.Name           `json:"-" xml:"health" yaml:"-"`
+	Status     string             `json:"status,omitempty" xml:"status,omitempty" yaml:"status,omitempty"`
+	Components []*ComponentHealth `json:"components,omitempty" xml:"component,omitempty" yaml:"components,omitempty"`
+}
+
+// ComponentHealth - health of a dependency and how long checking it took
+type ComponentHealth struct {
+	Name      string  `json:"name" xml:"name" yaml:"name"`
+	Status    string  `json:"status" xml:"status" yaml:"status"`
+	LatencyMS float64 `json:"latency_ms" xml:"latency_ms" yaml:"latency_ms"`
+	Error     string  `json:"error,omitempty" xml:"error,omitempty" yaml:"error,omitempty"`
 }
 
 // Tagsview view of Tags stats
 type Tagsview struct {
-	Tag         string    `json:"tag,omitempty" bson:"Tag,omitempty"`
-	Articles    []*string `json:"articles" bson:"Articles"`
-	RelatedTags []*string `json:"related_tags" bson:"RelatedTags"`
-	Count       int       `json:"count,omitempty" bson:"Count,omitempty"`
+	XMLName      xml.Name  `json:"-" bson:"-" xml:"tagsview" yaml:"-"`
+	Tag          string    `json:"tag,omitempty" bson:"Tag,omitempty" xml:"tag,omitempty" yaml:"tag,omitempty"`
+	Articles     []*string `json:"articles" bson:"Articles" xml:"articles>id" yaml:"articles"`
+	RelatedTags  []*string `json:"related_tags" bson:"RelatedTags" xml:"related_tags>tag" yaml:"related_tags"`
+	Count        int       `json:"count,omitempty" bson:"Count,omitempty" xml:"count,omitempty" yaml:"count,omitempty"`
+	LastModified time.Time `json:"-" bson:"LastModified,omitempty" xml:"-" yaml:"-"`
 }
 
 // Article to be persisted in db
 type Article struct {
-	ArticleID string    `json:"id,omitempty" bson:"ArticleID,omitempty"`
-	Title     string    `json:"title,omitempty" bson:"Title,omitempty"`
-	Date      string    `json:"date,omitempty" bson:"Date,omitempty"`
-	Body      string    `json:"body,omitempty" bson:"Body,omitempty"`
-	Tags      []*string `json:"tags,omitempty" bson:"Tags,omitempty"`
+	XMLName   xml.Name  `json:"-" bson:"-" xml:"article" yaml:"-"`
+	ArticleID string    `json:"id,omitempty" bson:"ArticleID,omitempty" xml:"id,omitempty" yaml:"id,omitempty"`
+	Title     string    `json:"title,omitempty" bson:"Title,omitempty" xml:"title,omitempty" yaml:"title,omitempty"`
+	Date      string    `json:"date,omitempty" bson:"Date,omitempty" xml:"date,omitempty" yaml:"date,omitempty"`
+	Body      string    `json:"body,omitempty" bson:"Body,omitempty" xml:"body,omitempty" yaml:"body,omitempty"`
+	Tags      []*string `json:"tags,omitempty" bson:"Tags,omitempty" xml:"tags>tag,omitempty" yaml:"tags,omitempty"`
+	Author    string    `json:"author,omitempty" bson:"Author,omitempty" xml:"author,omitempty" yaml:"author,omitempty"`
+	// pointers, so that the articles stored before they were recorded render without them
+	CreatedAt *time.Time `json:"created_at,omitempty" bson:"CreatedAt,omitempty" xml:"created_at,omitempty" yaml:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" bson:"UpdatedAt,omitempty" xml:"updated_at,omitempty" yaml:"updated_at,omitempty"`
+}
+
+// API key scopes, ScopeAdmin grants every scope
+const (
+	ScopeArticlesRead  = "articles:read"
+	ScopeArticlesWrite = "articles:write"
+	ScopeAdmin         = "admin"
+)
+
+// Scopes lists the scopes an API key can be granted
+var Scopes = []string{ScopeArticlesRead, ScopeArticlesWrite, ScopeAdmin}
+
+// APIKey identifies a client of the api. Only the hash of the key is persisted,
+// Key holds the plain key once, in the response to minting it.
+type APIKey struct {
+	XMLName   xml.Name   `json:"-" bson:"-" xml:"api_key" yaml:"-"`
+	ID        string     `json:"id" bson:"KeyID" xml:"id" yaml:"id"`
+	Name      string     `json:"name,omitempty" bson:"Name,omitempty" xml:"name,omitempty" yaml:"name,omitempty"`
+	Key       string     `json:"key,omitempty" bson:"-" xml:"key,omitempty" yaml:"key,omitempty"`
+	Hash      string     `json:"-" bson:"Hash" xml:"-" yaml:"-"`
+	Scopes    []string   `json:"scopes" bson:"Scopes" xml:"scopes>scope" yaml:"scopes"`
+	CreatedAt time.Time  `json:"created_at" bson:"CreatedAt" xml:"created_at" yaml:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" bson:"RevokedAt,omitempty" xml:"revoked_at,omitempty" yaml:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key grants the scope
+func (k *APIKey) HasScope(scope string) bool {
+	return hasScope(k.Scopes, scope)
+}
+
+// Roles of token holders, each grants the scopes listed in RoleScopes
+const (
+	RoleReader = "reader"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// RoleScopes maps the roles to the scopes they grant
+var RoleScopes = map[string][]string{
+	RoleReader: {ScopeArticlesRead},
+	RoleEditor: {ScopeArticlesRead, ScopeArticlesWrite},
+	RoleAdmin:  {ScopeAdmin},
+}
+
+// Authentication methods of a Principal
+const (
+	AuthAPIKey = "api_key"
+	AuthJWT    = "jwt"
+	AuthMTLS   = "mtls"
+)
+
+// Principal is the authenticated caller of the api
+type Principal struct {
+	Subject string   // token subject, apikey:<id> for API keys or cert:<name> for client certificates
+	Method  string   // AuthAPIKey, AuthJWT or AuthMTLS
+	Roles   []string // roles granted by a token
+	Scopes  []string
+}
+
+// HasScope reports whether the principal is granted the scope
+func (p *Principal) HasScope(scope string) bool {
+	return hasScope(p.Scopes, scope)
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Article events delivered to the webhooks
+const (
+	EventArticleCreated = "article.created"
+	EventArticleUpdated = "article.updated"
+	EventArticleDeleted = "article.deleted"
+)
+
+// Events lists the events a webhook can subscribe to. Articles can only be created for now,
+// article.updated and article.deleted join once articles can be updated and deleted.
+var Events = []string{EventArticleCreated}
+
+// Webhook subscribes a URL to the article events. The Secret signs every delivery,
+// it is only sent in the response to creating the webhook.
+type Webhook struct {
+	XMLName   xml.Name   `json:"-" bson:"-" xml:"webhook" yaml:"-"`
+	ID        string     `json:"id" bson:"WebhookID" xml:"id" yaml:"id"`
+	URL       string     `json:"url" bson:"URL" xml:"url" yaml:"url"`
+	Events    []string   `json:"events" bson:"Events" xml:"events>event" yaml:"events"` // every event when empty
+	Tags      []string   `json:"tags" bson:"Tags" xml:"tags>tag" yaml:"tags"`           // articles with any of the tags, every article when empty
+	Secret    string     `json:"secret,omitempty" bson:"Secret" xml:"secret,omitempty" yaml:"secret,omitempty"`
+	CreatedAt time.Time  `json:"created_at" bson:"CreatedAt" xml:"created_at" yaml:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" bson:"UpdatedAt" xml:"updated_at" yaml:"updated_at"`
+	DeletedAt *time.Time `json:"-" bson:"DeletedAt,omitempty" xml:"-" yaml:"-"`
+}
+
+// Subscribes reports whether the webhook receives the events of articles tagged tag
+func (w *Webhook) Subscribes(tag string) bool {
+	if len(w.Tags) == 0 {
+		return true
+	}
+	for _, t := range w.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the webhook receives the event of the article
+func (w *Webhook) Matches(event string, article *Article) bool {
+	if len(w.Events) > 0 {
+		subscribed := false
+		for _, e := range w.Events {
+			subscribed = subscribed || e == event
+		}
+		if !subscribed {
+			return false
+		}
+	}
+	return len(w.Tags) == 0 || article.HasAnyTag(w.Tags)
+}
+
+// HasAnyTag reports whether the article is tagged with any of the tags, case insensitively
+func (a *Article) HasAnyTag(tags []string) bool {
+	for _, tag := range a.Tags {
+		for _, t := range tags {
+			if tag != nil && strings.EqualFold(*tag, t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WebhookEvent is the body of a delivery
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Article   *Article  `json:"article"`
+}
+
+// Delivery statuses, failed deliveries are retried until they are delivered or dead.
+// In flight deliveries are being attempted by the instance owning them, until their lease expires.
+const (
+	DeliveryPending   = "pending"
+	DeliveryInFlight  = "in_flight"
+	DeliveryRetrying  = "retrying"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// DeliveryStatuses lists the statuses of a delivery
+var DeliveryStatuses = []string{DeliveryPending, DeliveryInFlight, DeliveryRetrying, DeliveryDelivered, DeliveryDead}
+
+// Delivery of an event to a webhook, with every attempt made. Dead deliveries gave up
+// after the last attempt and wait to be replayed, replays keep the earlier attempts.
+type Delivery struct {
+	XMLName        xml.Name          `json:"-" bson:"-" xml:"delivery" yaml:"-"`
+	ID             string            `json:"id" bson:"DeliveryID" xml:"id" yaml:"id"`
+	WebhookID      string            `json:"webhook_id" bson:"WebhookID" xml:"webhook_id" yaml:"webhook_id"`
+	EventID        string            `json:"event_id" bson:"EventID" xml:"event_id" yaml:"event_id"`
+	EventType      string            `json:"event_type" bson:"EventType" xml:"event_type" yaml:"event_type"`
+	Payload        []byte            `json:"-" bson:"Payload" xml:"-" yaml:"-"` // the signed body, sent again as is on every attempt
+	Status         string            `json:"status" bson:"Status" xml:"status" yaml:"status"`
+	Attempts       []DeliveryAttempt `json:"attempts" bson:"Attempts" xml:"attempts>attempt" yaml:"attempts"`
+	NextAttemptAt  *time.Time        `json:"next_attempt_at,omitempty" bson:"NextAttemptAt,omitempty" xml:"next_attempt_at,omitempty" yaml:"next_attempt_at,omitempty"`
+	ReplayedAt     *time.Time        `json:"replayed_at,omitempty" bson:"ReplayedAt,omitempty" xml:"replayed_at,omitempty" yaml:"replayed_at,omitempty"`                    // attempts before it do not count towards the retries
+	Owner          string            `json:"owner,omitempty" bson:"Owner,omitempty" xml:"owner,omitempty" yaml:"owner,omitempty"`                                           // the instance attempting the in flight delivery
+	LeaseExpiresAt *time.Time        `json:"lease_expires_at,omitempty" bson:"LeaseExpiresAt,omitempty" xml:"lease_expires_at,omitempty" yaml:"lease_expires_at,omitempty"` // another instance may attempt it from then on
+	CreatedAt      time.Time         `json:"created_at" bson:"CreatedAt" xml:"created_at" yaml:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at" bson:"UpdatedAt" xml:"updated_at" yaml:"updated_at"`
+}
+
+// DeliveryAttempt is a POST of a delivery, StatusCode is 0 when no response came back
+type DeliveryAttempt struct {
+	At         time.Time `json:"at" bson:"At" xml:"at" yaml:"at"`
+	StatusCode int       `json:"status_code,omitempty" bson:"StatusCode,omitempty" xml:"status_code,omitempty" yaml:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty" bson:"Error,omitempty" xml:"error,omitempty" yaml:"error,omitempty"`
+	LatencyMS  float64   `json:"latency_ms" bson:"LatencyMS" xml:"latency_ms" yaml:"latency_ms"`
+}
+
+// Envelope wraps every v2 response, Data holds the resource and Errors the failures
+type Envelope struct {
+	XMLName xml.Name      `json:"-" xml:"response" yaml:"-"`
+	Data    interface{}   `json:"data,omitempty" xml:",omitempty" yaml:"data,omitempty"`
+	Meta    EnvelopeMeta  `json:"meta" xml:"meta" yaml:"meta"`
+	Links   EnvelopeLinks `json:"links" xml:"links" yaml:"links"`
+	Errors  []*Problem    `json:"errors,omitempty" xml:"urn:ietf:rfc:7807 problem,omitempty" yaml:"errors,omitempty"`
+}
+
+// EnvelopeMeta describes the response
+type EnvelopeMeta struct {
+	Version string `json:"version" xml:"version" yaml:"version"`
+	Status  int    `json:"status" xml:"status" yaml:"status"`
+}
+
+// EnvelopeLinks relate the response to other resources
+type EnvelopeLinks struct {
+	Self string `json:"self" xml:"self" yaml:"self"`
 }
 
 // Config data of the application
 type Config struct {
-	DBProperties DBProperties
+	DBProperties   DBProperties
+	HTTPProperties HTTPProperties
+	Validation     ArticleRules // applied to the articles created over rest and grpc
+	Logging        LogProperties
+	Tracing        TracingProperties
+	Health         HealthProperties
+	Shutdown       ShutdownProperties
+	Webhooks       WebhookProperties
+	Stream         StreamProperties
+}
+
+// StreamProperties configure the event stream of the articles created, served at /api/stream
+type StreamProperties struct {
+	Source       string // mongo for the change stream of the articles, memory for the articles created by this instance, auto (the default) picks mongo when the database supports it
+	Heartbeat    int    // seconds between the comments keeping idle streams open, 15 by default
+	History      int    // events kept to resume a stream from its Last-Event-ID, 1000 by default
+	ClientBuffer int    // events waiting to be sent to a client, a client falling further behind is disconnected, 64 by default
+	// MaxSubscriptions is the number of tag and date pairs a websocket client may follow at once, 20 by default
+	MaxSubscriptions int
+	// WriteTimeout is the number of seconds a websocket client has to take a message before it is disconnected, 10 by default
+	WriteTimeout int
+}
+
+// WebhookProperties configure the delivery of the article events to the webhooks,
+// failed deliveries are retried with exponential backoff: InitialBackoff, twice that, and so on up to MaxBackoff
+type WebhookProperties struct {
+	Enabled        bool
+	Workers        int // deliveries posted concurrently, 4 by default
+	QueueSize      int // events waiting for a worker, events published to a full queue are logged and dropped
+	MaxAttempts    int // of a delivery before it is dead, 8 by default
+	InitialBackoff int // seconds before the first retry, 1 by default
+	MaxBackoff     int // seconds, longest wait between two attempts, 3600 by default
+	Timeout        int // seconds a receiver has to answer, 10 by default
+}
+
+// ShutdownProperties configure the graceful shutdown on SIGINT or SIGTERM
+type ShutdownProperties struct {
+	GracePeriod int // seconds the requests in flight and the background workers are given to finish, 15 by default
+	DrainDelay  int // seconds readiness fails before the listeners close, so that load balancers stop routing first
+}
+
+// HealthProperties configure the readiness checks
+type HealthProperties struct {
+	Timeout   int      // seconds a check may take before it fails, 2 when 0
+	DiskPaths []string // directories of embedded stores, unready when their disk is short of MinFreeMB
+	MinFreeMB int      // 100 when 0
+}
+
+// TracingProperties configure the OpenTelemetry traces, tracing is off when no Exporter is set
+type TracingProperties struct {
+	Exporter    string  // otlp or file
+	OTLPAddress string  // host:port of the OTLP collector, localhost:55680 when empty
+	Insecure    bool    // connect to the OTLP collector without TLS
+	File        string  // spans are appended to the file as json lines
+	SampleRatio float64 // of the traces sampled, 1 when 0. Traces the caller sampled are always kept
+	ServiceName string  // articleapi when empty
+}
+
+// LogProperties configure the application logs
+type LogProperties struct {
+	Level  string // trace, debug, info, warn or error, info when empty
+	Format string // json or text, json when empty
+}
+
+// HTTPProperties settings
+type HTTPProperties struct {
+	CacheControl map[string]string // {routePattern : Cache-Control header value}
+	GraphQL      GraphQLProperties
+	Debug        bool                             // validate responses against the OpenAPI spec, failing with 500 on a mismatch
+	Deprecations map[string]DeprecationProperties // {apiVersion : deprecation announced on its routes} eg: v1
+	Auth         AuthProperties
+	RateLimit    RateLimitProperties
+	Requests     RequestProperties
+	Metrics      MetricsProperties
+	TLS          TLSProperties
+	CORS         CORSProperties
+	Compression  CompressionProperties
+}
+
+// CORSProperties let browser apps of other origins call the api, cross origin requests get no CORS headers
+// when no AllowedOrigins are set
+type CORSProperties struct {
+	AllowedOrigins   []string // eg: https://app.example.com, https://*.example.com, or * for any origin
+	AllowedMethods   []string // GET, HEAD, POST, PUT and DELETE when empty
+	AllowedHeaders   []string // request headers apps may send, Accept, Authorization, Content-Type, X-API-Key and X-Request-ID when empty
+	ExposedHeaders   []string // response headers apps may read besides the CORS safelisted ones, eg: ETag or RateLimit-Remaining
+	AllowCredentials bool     // let apps send cookies and client certificates, the origin is then echoed instead of *
+	MaxAge           int      // seconds browsers may cache a preflight response
+}
+
+// CompressionProperties configure the compression of the responses the client accepts compressed
+type CompressionProperties struct {
+	Enabled   bool
+	MinSize   int      // bytes, smaller responses are sent as is as compressing them costs more than it saves
+	Encodings []string // br and gzip, in the order preferred when the client accepts both. br, gzip when empty
+}
+
+// TLSProperties configure TLS on the api listener, plain http is served when no CertFile is set.
+// The certificate and the client CAs are read again whenever their files are rotated.
+type TLSProperties struct {
+	CertFile     string            // PEM certificate chain
+	KeyFile      string            // PEM private key of the certificate
+	MinVersion   string            // 1.2 or 1.3, 1.2 when empty
+	ClientCAFile string            // PEM bundle the client certificates are verified against, enables mutual TLS
+	ClientAuth   string            // request verifies the certificates sent, require refuses clients without one. require when empty
+	RoleMapping  map[string]string // {client certificate common name, DNS, URI or email name : reader|editor|admin}
+}
+
+// MetricsProperties configure the Prometheus metrics, served on an admin listener apart from the api
+type MetricsProperties struct {
+	Enabled bool
+	Address string // of the admin listener, :4854 when empty
+}
+
+// RequestProperties settings of the request bodies accepted by the api
+type RequestProperties struct {
+	MaxBodySize        int64 // bytes, larger bodies are refused with 413, 1 MiB by default
+	Strict             bool  // refuse unknown fields and data trailing the document, xml is only checked for trailing data
+	RequireContentType bool  // refuse bodies without a Content-Type instead of reading them as json
+}
+
+// RateLimitProperties settings of the per client token buckets,
+// callers are told apart by API key, token subject or IP address
+type RateLimitProperties struct {
+	Enabled  bool
+	Default  RateLimit            // applied to routes without their own limit, unlimited when Requests is 0
+	Routes   map[string]RateLimit // {routePattern : limit} eg: /api/articles/ for every version or /api/v2/articles/ for one
+	PerIP    RateLimit            // of each IP address across the /api routes, checked before the credentials, unlimited when Requests is 0
+	RedisURL string               // redis://host:port/db shares the buckets across replicas, kept in process when empty
+}
+
+// RateLimit is a token bucket refilled with Requests tokens every Period
+type RateLimit struct {
+	Requests int // sustained number of requests per Period
+	Period   int // seconds, 1 by default
+	Burst    int // bucket size, Requests by default
+}
+
+// AuthProperties settings
+type AuthProperties struct {
+	Enabled bool // enforce the scopes required by each route, the healthcheck and openapi document stay public
+	JWT     JWTProperties
+}
+
+// JWTProperties settings of bearer token verification, tokens are refused when no JWKS is configured
+type JWTProperties struct {
+	JWKSFile            string            // local JWK set, RSA, EC P-256 and oct (HS256) keys are supported
+	JWKSURL             string            // remote JWK set, used when no file is configured
+	JWKSRefreshInterval int               // seconds between fetches of JWKSURL, 3600 by default
+	Issuer              string            // expected iss claim, not checked when empty
+	Audience            string            // expected aud claim, not checked when empty
+	RolesClaim          string            // claim holding the roles as a string or a list, roles by default
+	RoleMapping         map[string]string // {claim value : reader|editor|admin}, values named after a role map to it as is
+}
+
+// DeprecationProperties announce the retirement of an api version
+type DeprecationProperties struct {
+	Deprecation time.Time // sent as Deprecation: @<unix seconds>, may lie in the future
+	Sunset      time.Time // sent as an HTTP-date, the version may stop being served after it
+	Link        string    // migration guide, sent as Link: <url>; rel="deprecation"
+}
+
+// GraphQLProperties settings
+type GraphQLProperties struct {
+	MaxDepth      int // deepest allowed selection nesting
+	MaxComplexity int // highest allowed query cost, fields under lists count once per expected item
 }
 
 // DBProperties settings
 type DBProperties struct {
-	URL               string
-	DatabaseName      string
-	CollectionName    string
-	MaxThreadPoolSize int
-	MaxTimeOut        int
-	Indexes           map[string]bool // {indexfieldName : isUnique}
-}
-
-// LoadConfig from path specified
-func LoadConfig(file *os.File) (*Config, error) {
-	decoder := json.NewDecoder(file)
-	Config := Config{}
-	err := decoder.Decode(&Config)
-	if err != nil {
-		fmt.Println(err.Error())
-		return nil, err
-	}
-	return &Config, err
+	URL                    string
+	DatabaseName           string
+	CollectionName         string
+	MaxThreadPoolSize      int
+	MaxTimeOut             int
+	Indexes                map[string]bool // {indexfieldName : isUnique}
+	KeyCollectionName      string          // collection of the API keys, apikeys by default
+	WebhookCollectionName  string          // collection of the webhooks, webhooks by default
+	DeliveryCollectionName string          // collection of the webhook delivery log, webhook_deliveries by default
+	TLS                    ClientTLSProperties
+	Auth                   DBAuthProperties
+}
+
+// ClientTLSProperties configure TLS to a server, the system roots are trusted when no CAFile is set
+type ClientTLSProperties struct {
+	Enabled  bool
+	CAFile   string // PEM bundle the server certificate is verified against
+	CertFile string // PEM client certificate, for servers verifying their clients
+	KeyFile  string // PEM private key of the client certificate
+	Insecure bool   // skip the verification of the server certificate, for tests only
+}
+
+// DBAuthProperties authenticate the connection to the database, credentials in the URL are used when no Username is set
+type DBAuthProperties struct {
+	Username  string
+	Password  string // better set by ARTICLEAPI_DB_AUTH_PASSWORD than kept in the file
+	Source    string // database of the user, admin when empty
+	Mechanism string // SCRAM-SHA-1, SCRAM-SHA-256 or MONGODB-X509, negotiated when empty
 }
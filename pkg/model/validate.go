@@ -0,0 +1,226 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// dateLayout of article dates eg: 2016-09-22
+const dateLayout = "2006-01-02"
+
+// ArticleRules configure the validation of incoming articles, rules left at their zero value are not checked
+type ArticleRules struct {
+	Required       []string // fields that must be set among id, title, date, body and tags, id, date and tags when empty
+	IDPattern      string   // regular expression the whole id must match eg: [0-9]+
+	MinDate        string   // earliest date accepted, YYYY-MM-DD or today
+	MaxDate        string   // latest date accepted, YYYY-MM-DD or today
+	MaxTags        int
+	TagCharset     string // characters allowed in tags, as the body of a regular expression class eg: a-z0-9-
+	MaxTagLength   int    // characters
+	MaxTitleLength int    // characters
+	MaxBodyLength  int    // characters
+}
+
+// defaultRequired fields of an article
+var defaultRequired = []string{"id", "date", "tags"}
+
+// articleRule checks one aspect of an article, returning a violation per problem found
+type articleRule func(article *Article) []Violation
+
+// ArticleValidator checks articles against every configured rule
+type ArticleValidator struct {
+	rules []articleRule
+	now   func() time.Time
+}
+
+// NewArticleValidator compiles the rules, reporting every invalid one
+func NewArticleValidator(rules ArticleRules) (*ArticleValidator, error) {
+	v := &ArticleValidator{now: time.Now}
+	var problems []string
+
+	required := rules.Required
+	if len(required) == 0 {
+		required = defaultRequired
+	}
+	for _, field := range required {
+		if rule := requiredRule(field); rule != nil {
+			v.rules = append(v.rules, rule)
+		} else {
+			problems = append(problems, fmt.Sprintf("Required: unknown field %s", field))
+		}
+	}
+
+	if rules.IDPattern != "" {
+		pattern, err := regexp.Compile("^(?:" + rules.IDPattern + ")$")
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("IDPattern: %v", err))
+		} else {
+			v.rules = append(v.rules, idPatternRule(pattern, rules.IDPattern))
+		}
+	}
+
+	minDate, err := v.dateBound(rules.MinDate)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("MinDate: %v", err))
+	}
+	maxDate, err := v.dateBound(rules.MaxDate)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("MaxDate: %v", err))
+	}
+	v.rules = append(v.rules, dateRule(minDate, maxDate))
+
+	if rules.MaxTags > 0 {
+		v.rules = append(v.rules, maxTagsRule(rules.MaxTags))
+	}
+	if rules.TagCharset != "" {
+		charset, err := regexp.Compile("^[" + rules.TagCharset + "]*$")
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("TagCharset: %v", err))
+		} else {
+			v.rules = append(v.rules, tagCharsetRule(charset, rules.TagCharset))
+		}
+	}
+	if rules.MaxTagLength > 0 {
+		v.rules = append(v.rules, func(article *Article) []Violation {
+			var violations []Violation
+			for i, tag := range article.Tags {
+				if tag != nil {
+					violations = append(violations, maxLength(fmt.Sprintf("/tags/%d", i), *tag, rules.MaxTagLength)...)
+				}
+			}
+			return violations
+		})
+	}
+	if rules.MaxTitleLength > 0 {
+		v.rules = append(v.rules, func(article *Article) []Violation {
+			return maxLength("/title", article.Title, rules.MaxTitleLength)
+		})
+	}
+	if rules.MaxBodyLength > 0 {
+		v.rules = append(v.rules, func(article *Article) []Violation {
+			return maxLength("/body", article.Body, rules.MaxBodyLength)
+		})
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid article rules - %s", strings.Join(problems, ", "))
+	}
+	return v, nil
+}
+
+// DefaultArticleValidator checks the required fields and the date format only
+func DefaultArticleValidator() *ArticleValidator {
+	v, _ := NewArticleValidator(ArticleRules{})
+	return v
+}
+
+// Validate checks the article against every rule, the error lists all the violations found.
+// Fields are JSON pointers into the article eg: /tags/0
+func (v *ArticleValidator) Validate(article Article) error {
+	var violations []Violation
+	for _, rule := range v.rules {
+		violations = append(violations, rule(&article)...)
+	}
+	if len(violations) > 0 {
+		return Violationsf(violations, "Invalid Article data")
+	}
+	return nil
+}
+
+// dateBound parses a MinDate or MaxDate, returning a func as today moves on
+func (v *ArticleValidator) dateBound(bound string) (func() string, error) {
+	switch bound {
+	case "":
+		return nil, nil
+	case "today":
+		return func() string { return v.now().UTC().Format(dateLayout) }, nil
+	}
+	if _, err := time.Parse(dateLayout, bound); err != nil {
+		return nil, fmt.Errorf("expected YYYY-MM-DD or today, got %s", bound)
+	}
+	return func() string { return bound }, nil
+}
+
+func requiredRule(field string) articleRule {
+	var isSet func(article *Article) bool
+	switch field {
+	case "id":
+		isSet = func(article *Article) bool { return article.ArticleID != "" }
+	case "title":
+		isSet = func(article *Article) bool { return article.Title != "" }
+	case "date":
+		isSet = func(article *Article) bool { return article.Date != "" }
+	case "body":
+		isSet = func(article *Article) bool { return article.Body != "" }
+	case "tags":
+		isSet = func(article *Article) bool { return len(article.Tags) > 0 }
+	default:
+		return nil
+	}
+	return func(article *Article) []Violation {
+		if isSet(article) {
+			return nil
+		}
+		return []Violation{{Field: "/" + field, Rule: "required", Message: "is required"}}
+	}
+}
+
+func idPatternRule(pattern *regexp.Regexp, source string) articleRule {
+	return func(article *Article) []Violation {
+		if article.ArticleID == "" || pattern.MatchString(article.ArticleID) {
+			return nil
+		}
+		return []Violation{{Field: "/id", Rule: "pattern", Message: fmt.Sprintf("must match %s", source)}}
+	}
+}
+
+// dateRule checks the format of the date, then its bounds when set.
+// Dates in the YYYY-MM-DD layout compare as strings.
+func dateRule(minDate func() string, maxDate func() string) articleRule {
+	return func(article *Article) []Violation {
+		if article.Date == "" {
+			return nil
+		}
+		if _, err := time.Parse(dateLayout, article.Date); err != nil {
+			return []Violation{{Field: "/date", Rule: "format", Message: "must be a date of format YYYY-MM-DD"}}
+		}
+		if minDate != nil && article.Date < minDate() {
+			return []Violation{{Field: "/date", Rule: "minDate", Message: fmt.Sprintf("must be on or after %s", minDate())}}
+		}
+		if maxDate != nil && article.Date > maxDate() {
+			return []Violation{{Field: "/date", Rule: "maxDate", Message: fmt.Sprintf("must be on or before %s", maxDate())}}
+		}
+		return nil
+	}
+}
+
+func maxTagsRule(max int) articleRule {
+	return func(article *Article) []Violation {
+		if len(article.Tags) <= max {
+			return nil
+		}
+		return []Violation{{Field: "/tags", Rule: "maxItems", Message: fmt.Sprintf("must hold at most %d tags", max)}}
+	}
+}
+
+func tagCharsetRule(charset *regexp.Regexp, source string) articleRule {
+	return func(article *Article) []Violation {
+		var violations []Violation
+		for i, tag := range article.Tags {
+			if tag != nil && !charset.MatchString(*tag) {
+				violations = append(violations, Violation{Field: fmt.Sprintf("/tags/%d", i), Rule: "charset", Message: fmt.Sprintf("must only use the characters [%s]", source)})
+			}
+		}
+		return violations
+	}
+}
+
+func maxLength(field string, value string, max int) []Violation {
+	if utf8.RuneCountInString(value) <= max {
+		return nil
+	}
+	return []Violation{{Field: field, Rule: "maxLength", Message: fmt.Sprintf("must be at most %d characters long", max)}}
+}
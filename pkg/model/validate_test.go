@@ -0,0 +1,95 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tags(values ...string) []*string {
+	var ptrs []*string
+	for i := range values {
+		ptrs = append(ptrs, &values[i])
+	}
+	return ptrs
+}
+
+func TestArticleValidator(t *testing.T) {
+	validator, err := NewArticleValidator(ArticleRules{
+		Required:       []string{"id", "title", "date", "tags"},
+		IDPattern:      "[0-9]+",
+		MinDate:        "2000-01-01",
+		MaxDate:        "today",
+		MaxTags:        2,
+		TagCharset:     "a-z0-9-",
+		MaxTagLength:   5,
+		MaxTitleLength: 5,
+		MaxBodyLength:  10,
+	})
+	assert.NoError(t, err)
+	validator.now = func() time.Time { return time.Date(2019, 10, 2, 10, 30, 0, 0, time.UTC) }
+
+	testScenarios := []struct {
+		Description string
+		Article     Article
+		Violations  []Violation
+	}{
+		{"Valid", Article{ArticleID: "11", Title: "title", Date: "2019-10-02", Body: "body", Tags: tags("a-1")}, nil},
+		{"Empty", Article{}, []Violation{
+			{Field: "/id", Rule: "required", Message: "is required"},
+			{Field: "/title", Rule: "required", Message: "is required"},
+			{Field: "/date", Rule: "required", Message: "is required"},
+			{Field: "/tags", Rule: "required", Message: "is required"},
+		}},
+		{"Every rule broken", Article{ArticleID: "a1", Title: "ünïcödé", Date: "2019-10-03", Body: "0123456789a", Tags: tags("a", "B c", "abcdef")}, []Violation{
+			{Field: "/id", Rule: "pattern", Message: "must match [0-9]+"},
+			{Field: "/date", Rule: "maxDate", Message: "must be on or before 2019-10-02"},
+			{Field: "/tags", Rule: "maxItems", Message: "must hold at most 2 tags"},
+			{Field: "/tags/1", Rule: "charset", Message: "must only use the characters [a-z0-9-]"},
+			{Field: "/tags/2", Rule: "maxLength", Message: "must be at most 5 characters long"},
+			{Field: "/title", Rule: "maxLength", Message: "must be at most 5 characters long"},
+			{Field: "/body", Rule: "maxLength", Message: "must be at most 10 characters long"},
+		}},
+		{"Date too early", Article{ArticleID: "1", Title: "t", Date: "1999-12-31", Tags: tags("a")}, []Violation{
+			{Field: "/date", Rule: "minDate", Message: "must be on or after 2000-01-01"},
+		}},
+		{"Date format", Article{ArticleID: "1", Title: "t", Date: "02/10/2019", Tags: tags("a")}, []Violation{
+			{Field: "/date", Rule: "format", Message: "must be a date of format YYYY-MM-DD"},
+		}},
+		{"Partial id match", Article{ArticleID: "1a", Title: "t", Date: "2019-10-02", Tags: tags("a")}, []Violation{
+			{Field: "/id", Rule: "pattern", Message: "must match [0-9]+"},
+		}},
+	}
+	for _, td := range testScenarios {
+		t.Run(td.Description, func(t *testing.T) {
+			err := validator.Validate(td.Article)
+			if td.Violations == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Equal(t, ErrInvalidInput, err.(*Error).Code)
+			assert.Equal(t, td.Violations, err.(*Error).Violations)
+		})
+	}
+}
+
+func TestDefaultArticleValidator(t *testing.T) {
+	validator := DefaultArticleValidator()
+	assert.NoError(t, validator.Validate(Article{ArticleID: "abc", Date: "2019-10-02", Tags: tags("Any Tag")}))
+	err := validator.Validate(Article{Date: "2019-13-02"})
+	assert.Equal(t, []Violation{
+		{Field: "/id", Rule: "required", Message: "is required"},
+		{Field: "/tags", Rule: "required", Message: "is required"},
+		{Field: "/date", Rule: "format", Message: "must be a date of format YYYY-MM-DD"},
+	}, err.(*Error).Violations)
+}
+
+func TestInvalidArticleRules(t *testing.T) {
+	_, err := NewArticleValidator(ArticleRules{Required: []string{"author"}, IDPattern: "(", MinDate: "yesterday", TagCharset: "z-a"})
+	assert.Error(t, err)
+	for _, rule := range []string{"Required: unknown field author", "IDPattern:", "MinDate: expected YYYY-MM-DD or today, got yesterday", "TagCharset:"} {
+		assert.Contains(t, err.Error(), rule)
+	}
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// Limiter takes tokens out of the bucket of a client
+type Limiter interface {
+	Take(key string, limit model.RateLimit) (*Result, error)
+}
+
+// Result of taking a token, Reset and RetryAfter are rounded up to whole seconds by the caller
+type Result struct {
+	Allowed    bool
+	Limit      int           // bucket size
+	Remaining  int           // whole tokens left in the bucket
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, zero when allowed
+}
+
+// New returns a redis backed limiter when a RedisURL is configured, an in process one otherwise
+func New(props model.RateLimitProperties) (Limiter, error) {
+	if props.RedisURL != "" {
+		return NewRedisLimiter(props.RedisURL)
+	}
+	return NewMemoryLimiter(), nil
+}
+
+// RouteLimit returns the limit of the route pattern under prefix and the bucket its requests are counted in.
+// Limits configured for prefix+routePattern win over those of the unversioned /api pattern,
+// which are shared by every api version, then over the default limit.
+func RouteLimit(props model.RateLimitProperties, prefix string, routePattern string) (string, model.RateLimit) {
+	if limit, ok := props.Routes[prefix+routePattern]; ok {
+		return prefix + routePattern, limit
+	}
+	if limit, ok := props.Routes["/api"+routePattern]; ok {
+		return "/api" + routePattern, limit
+	}
+	return "/api" + routePattern, props.Default
+}
+
+// ClientKey identifies the caller a bucket belongs to, by API key, token subject or client certificate,
+// anonymous callers by IP address
+func ClientKey(principal *model.Principal, ip string) string {
+	if principal == nil {
+		return "ip:" + ip
+	}
+	if principal.Method == model.AuthJWT {
+		return "jwt:" + principal.Subject
+	}
+	// API key subjects are apikey:<key id>, client certificate ones cert:<name>
+	return principal.Subject
+}
+
+// bucketSize and refillRate fill in the defaults of the limit
+func bucketSize(limit model.RateLimit) float64 {
+	if limit.Burst > 0 {
+		return float64(limit.Burst)
+	}
+	return float64(limit.Requests)
+}
+
+func refillRate(limit model.RateLimit) float64 {
+	period := limit.Period
+	if period <= 0 {
+		period = 1
+	}
+	return float64(limit.Requests) / float64(period)
+}
+
+// newResult describes a bucket left with tokens after a take
+func newResult(allowed bool, tokens float64, limit model.RateLimit) *Result {
+	size, rate := bucketSize(limit), refillRate(limit)
+	result := &Result{
+		Allowed:   allowed,
+		Limit:     int(size),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((size - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
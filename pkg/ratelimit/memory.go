@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// full buckets are dropped from memory this often
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   model.RateLimit
+}
+
+// refill adds the tokens earned since the last update
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(bucketSize(b.limit), b.tokens+elapsed*refillRate(b.limit))
+		b.updated = now
+	}
+}
+
+type memoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+// NewMemoryLimiter keeps the buckets in process, each replica then enforces the limits on its own
+func NewMemoryLimiter() Limiter {
+	return &memoryLimiter{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+func (l *memoryLimiter) Take(key string, limit model.RateLimit) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: bucketSize(limit), updated: now, limit: limit}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(allowed, b.tokens, limit), nil
+}
+
+// sweep forgets the buckets that refilled completely, a new bucket is full as well
+func (l *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < sweepInterval {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= bucketSize(b.limit) {
+			delete(l.buckets, key)
+		}
+	}
+}
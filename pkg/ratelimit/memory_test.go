@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Date(2019, 10, 2, 10, 30, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter().(*memoryLimiter)
+	limiter.now = func() time.Time { return now }
+	// 2 requests every 10 seconds, bursts of 3
+	limit := model.RateLimit{Requests: 2, Period: 10, Burst: 3}
+
+	testScenarios := []struct {
+		Description string
+		Elapsed     time.Duration
+		Key         string
+		Expected    Result
+	}{
+		{"First request", 0, "a", Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 5 * time.Second}},
+		{"Burst", 0, "a", Result{Allowed: true, Limit: 3, Remaining: 1, Reset: 10 * time.Second}},
+		{"Burst exhausted", 0, "a", Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 15 * time.Second}},
+		{"Empty bucket", time.Second, "a", Result{Allowed: false, Limit: 3, Remaining: 0, Reset: 14 * time.Second, RetryAfter: 4 * time.Second}},
+		{"Other client", 0, "b", Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 5 * time.Second}},
+		{"Refilled token", 4 * time.Second, "a", Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 15 * time.Second}},
+		{"Refilled bucket", time.Hour, "a", Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 5 * time.Second}},
+	}
+	for _, td := range testScenarios {
+		t.Run(td.Description, func(t *testing.T) {
+			now = now.Add(td.Elapsed)
+			result, err := limiter.Take(td.Key, limit)
+			assert.NoError(t, err)
+			assert.Equal(t, td.Expected.Allowed, result.Allowed)
+			assert.Equal(t, td.Expected.Limit, result.Limit)
+			assert.Equal(t, td.Expected.Remaining, result.Remaining)
+			assert.InDelta(t, td.Expected.Reset.Seconds(), result.Reset.Seconds(), 0.001)
+			assert.InDelta(t, td.Expected.RetryAfter.Seconds(), result.RetryAfter.Seconds(), 0.001)
+		})
+	}
+
+	// full buckets are forgotten, b refilled during the last hour
+	assert.Contains(t, limiter.buckets, "a")
+	assert.NotContains(t, limiter.buckets, "b")
+	now = now.Add(time.Hour)
+	_, err := limiter.Take("c", limit)
+	assert.NoError(t, err)
+	assert.NotContains(t, limiter.buckets, "a")
+	assert.Contains(t, limiter.buckets, "c")
+}
+
+func TestMemoryLimiterDefaults(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	limit := model.RateLimit{Requests: 1}
+	result, err := limiter.Take("a", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Limit)
+
+	result, err = limiter.Take("a", limit)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > 0 && result.RetryAfter <= time.Second)
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/gomodule/redigo/redis"
+)
+
+// keyPrefix namespaces the buckets in a shared redis
+const keyPrefix = "articleapi:ratelimit:"
+
+// takeScript refills and takes from the bucket atomically, using the clock of redis
+// so that replicas with skewed clocks agree. Buckets expire once they would be full again.
+// Tokens are returned in thousandths as lua numbers are truncated to integers.
+var takeScript = redis.NewScript(1, `
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local size = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or size
+local updated = tonumber(state[2]) or now
+tokens = math.min(size, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((size - tokens) / rate * 1000) + 1000)
+return {allowed, math.floor(tokens * 1000)}
+`)
+
+type redisLimiter struct {
+	pool *redis.Pool
+}
+
+// NewRedisLimiter shares the buckets across replicas through the redis at url
+func NewRedisLimiter(url string) (Limiter, error) {
+	pool := &redis.Pool{
+		MaxIdle:     10,
+		IdleTimeout: 4 * time.Minute,
+		Dial: func() (redis.Conn, error) {
+			return redis.DialURL(url,
+				redis.DialConnectTimeout(time.Second),
+				redis.DialReadTimeout(time.Second),
+				redis.DialWriteTimeout(time.Second))
+		},
+	}
+	conn := pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PING"); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return &redisLimiter{pool: pool}, nil
+}
+
+func (l *redisLimiter) Take(key string, limit model.RateLimit) (*Result, error) {
+	conn := l.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.Int64s(takeScript.Do(conn, keyPrefix+key, refillRate(limit), bucketSize(limit)))
+	if err != nil {
+		return nil, err
+	}
+	return newResult(reply[0] == 1, float64(reply[1])/1000, limit), nil
+}
+
+// Check pings redis, it makes the shared limiter a readiness checker
+func (l *redisLimiter) Check(ctx context.Context) error {
+	conn, err := l.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Do("PING")
+	return err
+}
+
+// Close releases the connections to redis, at shutdown
+func (l *redisLimiter) Close() error {
+	return l.pool.Close()
+}
@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// redisTestLimiter connects to the redis at REDIS_URL, the tests are skipped without one
+func redisTestLimiter(t *testing.T) *redisLimiter {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		t.Skip("REDIS_URL is not set")
+	}
+	limiter, err := NewRedisLimiter(url)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { limiter.(*redisLimiter).Close() })
+	return limiter.(*redisLimiter)
+}
+
+// redisTestKey is a key of the test no other run shares, its bucket is deleted after the test
+func redisTestKey(t *testing.T, limiter *redisLimiter) string {
+	key := fmt.Sprintf("test:%s:%d", t.Name(), time.Now().UnixNano())
+	t.Cleanup(func() { do(t, limiter, "DEL", keyPrefix+key) })
+	return key
+}
+
+func do(t *testing.T, limiter *redisLimiter, command string, args ...interface{}) interface{} {
+	conn := limiter.pool.Get()
+	defer conn.Close()
+	reply, err := conn.Do(command, args...)
+	assert.NoError(t, err)
+	return reply
+}
+
+func TestRedisLimiter(t *testing.T) {
+	limiter := redisTestLimiter(t)
+	key, other := redisTestKey(t, limiter), redisTestKey(t, limiter)
+	// 1 request an hour, bursts of 3: nothing is refilled while the test runs
+	limit := model.RateLimit{Requests: 1, Period: 3600, Burst: 3}
+
+	testScenarios := []struct {
+		Description string
+		Key         string
+		Allowed     bool
+		Remaining   int
+	}{
+		{"First request", key, true, 2},
+		{"Burst", key, true, 1},
+		{"Burst exhausted", key, true, 0},
+		{"Empty bucket", key, false, 0},
+		{"Still empty", key, false, 0},
+		{"Other client", other, true, 2},
+	}
+	for _, td := range testScenarios {
+		t.Run(td.Description, func(t *testing.T) {
+			result, err := limiter.Take(td.Key, limit)
+			assert.NoError(t, err)
+			assert.Equal(t, td.Allowed, result.Allowed)
+			assert.Equal(t, 3, result.Limit)
+			assert.Equal(t, td.Remaining, result.Remaining)
+			if !td.Allowed {
+				assert.InDelta(t, time.Hour.Seconds(), result.RetryAfter.Seconds(), 1)
+			}
+		})
+	}
+}
+
+func TestRedisLimiterRefill(t *testing.T) {
+	limiter := redisTestLimiter(t)
+	key := redisTestKey(t, limiter)
+	// a token every 100ms, no burst
+	limit := model.RateLimit{Requests: 10, Period: 1}
+
+	for i := 0; i < 10; i++ {
+		result, err := limiter.Take(key, limit)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+	result, err := limiter.Take(key, limit)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > 0 && result.RetryAfter <= 100*time.Millisecond, result.RetryAfter)
+
+	time.Sleep(250 * time.Millisecond)
+	result, err = limiter.Take(key, limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed, "the bucket is refilled with the clock of redis")
+	assert.True(t, result.Remaining >= 1, result.Remaining)
+}
+
+func TestRedisLimiterExpiry(t *testing.T) {
+	limiter := redisTestLimiter(t)
+	key := redisTestKey(t, limiter)
+	limit := model.RateLimit{Requests: 1, Period: 3600, Burst: 3}
+
+	// the bucket expires once it would be full again, a second later
+	_, err := limiter.Take(key, limit)
+	assert.NoError(t, err)
+	ttl, err := redis.Int64(do(t, limiter, "PTTL", keyPrefix+key), nil)
+	assert.NoError(t, err)
+	assert.InDelta(t, 3601000, ttl, 1000)
+
+	_, err = limiter.Take(key, limit)
+	assert.NoError(t, err)
+	ttl, err = redis.Int64(do(t, limiter, "PTTL", keyPrefix+key), nil)
+	assert.NoError(t, err)
+	assert.InDelta(t, 7201000, ttl, 1000)
+}
+
+func TestRedisLimiterBucketState(t *testing.T) {
+	limiter := redisTestLimiter(t)
+	limit := model.RateLimit{Requests: 1, Period: 3600, Burst: 3}
+	now := func() string {
+		clock, err := redis.Int64s(do(t, limiter, "TIME"), nil)
+		assert.NoError(t, err)
+		return fmt.Sprintf("%d.%06d", clock[0], clock[1])
+	}
+
+	testScenarios := []struct {
+		Description string
+		Tokens      string
+		Allowed     bool
+		Remaining   int
+		Thousandths int64
+	}{
+		// a bucket left from a larger burst setting is capped to the current one
+		{"Capped to the burst", "10", true, 2, 2000},
+		// tokens are returned in thousandths, rounded down
+		{"Thousandths", "1.5999", true, 0, 599},
+		{"Less than a token", "0.9996", false, 0, 999},
+	}
+	for _, td := range testScenarios {
+		t.Run(td.Description, func(t *testing.T) {
+			key := redisTestKey(t, limiter)
+			do(t, limiter, "HMSET", keyPrefix+key, "tokens", td.Tokens, "updated", now())
+
+			conn := limiter.pool.Get()
+			reply, err := redis.Int64s(takeScript.Do(conn, keyPrefix+key, refillRate(limit), bucketSize(limit)))
+			conn.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, td.Allowed, reply[0] == 1)
+			assert.Equal(t, td.Thousandths, reply[1])
+
+			result := newResult(reply[0] == 1, float64(reply[1])/1000, limit)
+			assert.Equal(t, td.Remaining, result.Remaining)
+		})
+	}
+}
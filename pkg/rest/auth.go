@@ -0,0 +1,249 @@
+package rest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/auth"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// apiKeyHeader carries the API key of the caller
+const apiKeyHeader = "X-API-Key"
+
+type principalCtxKey struct{}
+
+type challengesCtxKey struct{}
+
+// Authenticate resolves the caller from a bearer token in the Authorization header or from an API key,
+// requests without credentials pass on as the client certificate holder or anonymously and are left to requireScope.
+// Invalid credentials are rejected.
+func (d *delegate) Authenticate(next http.Handler) http.Handler {
+	var challenges []string
+	if d.tokenVerifier != nil {
+		challenges = append(challenges, `Bearer realm="articleapi"`)
+	}
+	if d.keyStore != nil {
+		challenges = append(challenges, `ApiKey header="`+apiKeyHeader+`"`)
+	}
+
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), challengesCtxKey{}, challenges)
+		r = r.WithContext(ctx)
+
+		var principal *model.Principal
+		var err error
+		authorization := r.Header.Get("Authorization")
+		key := r.Header.Get(apiKeyHeader)
+		switch {
+		case len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer "):
+			principal, err = d.verifyToken(strings.TrimSpace(authorization[7:]))
+		case key != "":
+			principal, err = d.verifyAPIKey(r.Context(), key)
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			renderUnauthorized(w, r, err)
+			return
+		}
+		ctx = context.WithValue(r.Context(), principalCtxKey{}, principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func (d *delegate) verifyToken(token string) (*model.Principal, error) {
+	if d.tokenVerifier == nil {
+		return nil, model.Errorf(model.ErrUnauthorized, "Bearer tokens are not supported")
+	}
+	return d.tokenVerifier.Verify(token)
+}
+
+func (d *delegate) verifyAPIKey(ctx context.Context, key string) (*model.Principal, error) {
+	if d.keyStore == nil {
+		return nil, model.Errorf(model.ErrUnauthorized, "API keys are not supported")
+	}
+	apiKey, err := d.keyStore.Authenticate(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return auth.KeyPrincipal(apiKey), nil
+}
+
+// authenticateClientCert identifies the callers presenting a verified client certificate,
+// credentials in the headers take precedence over the certificate
+func authenticateClientCert(props model.TLSProperties) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			principal := auth.CertPrincipal(r.TLS.VerifiedChains[0][0], props.RoleMapping)
+			ctx := context.WithValue(r.Context(), principalCtxKey{}, principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// principalFrom returns the authenticated caller, nil for anonymous requests
+func principalFrom(r *http.Request) *model.Principal {
+	principal, _ := r.Context().Value(principalCtxKey{}).(*model.Principal)
+	return principal
+}
+
+// requireScope rejects callers lacking the scope, when auth is enabled.
+// Token roles grant the scopes listed in model.RoleScopes.
+func requireScope(props model.AuthProperties, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !props.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// responses depend on the caller, shared caches must not serve them to others
+			w.Header().Add("Vary", "Authorization")
+			w.Header().Add("Vary", apiKeyHeader)
+			principal := principalFrom(r)
+			if principal == nil {
+				renderUnauthorized(w, r, model.Errorf(model.ErrUnauthorized, "A bearer token or an API key in the %s header is required", apiKeyHeader))
+				return
+			}
+			if !principal.HasScope(scope) {
+				renderErrorResponse(w, r, model.Errorf(model.ErrForbidden, "%s lacks the %s scope", principal.Subject, scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// requireAuth renders a 404 when auth is disabled, for the routes no anonymous caller may ever reach
+func requireAuth(props model.AuthProperties) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !props.Enabled {
+				renderErrorResponse(w, r, model.Errorf(model.ErrNotFound, "%s is only served when auth is enabled", r.URL.Path))
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// keysEnabled renders a 404 when no API key store is configured
+func (d *delegate) keysEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if d.keyStore == nil {
+		renderErrorResponse(w, r, model.Errorf(model.ErrNotFound, "API keys are not enabled"))
+		return false
+	}
+	return true
+}
+
+// renderUnauthorized challenges the caller for the supported credentials along with the error
+func renderUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	if specificError, ok := err.(*model.Error); ok && specificError.Code == model.ErrUnauthorized {
+		challenges, _ := r.Context().Value(challengesCtxKey{}).([]string)
+		for _, challenge := range challenges {
+			w.Header().Add("WWW-Authenticate", challenge)
+		}
+	}
+	renderErrorResponse(w, r, err)
+}
+
+// apiKeyRequest is the body of a request minting an API key
+type apiKeyRequest struct {
+	Name   string   `json:"name" xml:"name" yaml:"name"`
+	Scopes []string `json:"scopes" xml:"scopes>scope" yaml:"scopes"`
+}
+
+// MintAPIKey handles a POST request to create an API key, the key is only ever sent in this response
+func (d *delegate) MintAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !d.keysEnabled(w, r) {
+		return
+	}
+	c, err := requestCodec(r)
+	if err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		renderErrorResponse(w, r, model.ErrorEf(model.ErrInvalidInput, err, "Bad request body"))
+		return
+	}
+	var req apiKeyRequest
+	if err = unmarshalBody(r, c, data, &req, "Invalid API key data"); err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	if violations := validateScopes(req.Scopes); len(violations) > 0 {
+		renderErrorResponse(w, r, model.Violationsf(violations, "Invalid API key data"))
+		return
+	}
+
+	apiKey, err := d.keyStore.CreateAPIKey(r.Context(), req.Name, req.Scopes)
+	if err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	respond(w, r, apiKey)
+}
+
+// ListAPIKeys handles a GET request listing the API keys, hashes are never sent
+func (d *delegate) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if !d.keysEnabled(w, r) {
+		return
+	}
+	keys, err := d.keyStore.ListAPIKeys(r.Context())
+	if err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	if keys == nil {
+		keys = []*model.APIKey{}
+	}
+	render.Status(r, http.StatusOK)
+	respond(w, r, keys)
+}
+
+// RevokeAPIKey handles a DELETE request revoking an API key, revoked keys stay listed
+func (d *delegate) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !d.keysEnabled(w, r) {
+		return
+	}
+	if err := d.keyStore.RevokeAPIKey(r.Context(), chi.URLParam(r, "id")); err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateScopes(scopes []string) []model.Violation {
+	if len(scopes) == 0 {
+		return []model.Violation{{Field: "/body/scopes", Rule: "minItems", Message: "must have at least 1 items"}}
+	}
+	var violations []model.Violation
+	for i, scope := range scopes {
+		if !contains(model.Scopes, scope) {
+			violations = append(violations, model.Violation{
+				Field: "/body/scopes/" + strconv.Itoa(i), Rule: "enum", Message: "must be one of " + strings.Join(model.Scopes, ", ")})
+		}
+	}
+	return violations
+}
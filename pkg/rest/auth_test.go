@@ -0,0 +1,282 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyScopes(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{KeyStore: newMockAPIKeyStore()}),
+		model.HTTPProperties{Auth: model.AuthProperties{Enabled: true}})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	article := `{"id":"11","date":"2019-10-02","tags":["a"]}`
+	testScenarios := []struct {
+		Description string
+		Method      string
+		URL         string
+		Key         string
+		StatusCode  int
+	}{
+		{"Public healthcheck", "GET", "/api/healthcheck", "", 200},
+		{"Public spec", "GET", "/api/openapi.json", "", 200},
+		{"No key", "GET", "/api/articles/1", "", 401},
+		{"Unknown key", "GET", "/api/healthcheck", "unknown", 401},
+		{"Revoked key", "GET", "/api/articles/1", "revoked", 401},
+		{"Reader reads", "GET", "/api/v1/articles/1", "reader", 200},
+		{"Reader searches", "GET", "/api/v2/tags/tagName/2019-10-02", "reader", 200},
+		{"Reader queries", "GET", "/api/graphql?query={tags{name}}", "reader", 200},
+		{"Reader writes", "POST", "/api/articles/", "reader", 403},
+		{"Writer writes", "POST", "/api/articles/", "writer", 200},
+		{"Writer reads", "GET", "/api/articles/1", "writer", 403},
+		{"Admin writes", "POST", "/api/v2/articles/", "admin", 201},
+		{"Reader lists keys", "GET", "/api/admin/keys", "reader", 403},
+		{"Admin lists keys", "GET", "/api/admin/keys", "admin", 200},
+	}
+	for _, td := range testScenarios {
+		t.Run(fmt.Sprintf("%s - %s %s : %d", td.Description, td.Method, td.URL, td.StatusCode), func(t *testing.T) {
+			req, _ := http.NewRequest(td.Method, server.URL+td.URL, bytes.NewBufferString(article))
+			if td.Key != "" {
+				req.Header.Set("X-API-Key", td.Key)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, td.StatusCode, resp.StatusCode)
+			if td.StatusCode == 401 {
+				assert.Equal(t, `ApiKey header="X-API-Key"`, resp.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAPIKeyAdmin(t *testing.T) {
+	keyStore := newMockAPIKeyStore()
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{KeyStore: keyStore}),
+		model.HTTPProperties{Auth: model.AuthProperties{Enabled: true}, Debug: true})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	do := func(method string, url string, body string) *http.Response {
+		req, _ := http.NewRequest(method, server.URL+url, bytes.NewBufferString(body))
+		req.Header.Set("X-API-Key", "admin")
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp := do("POST", "/api/admin/keys", `{"name":"ci","scopes":["articles:read","root"]}`)
+	assert.Equal(t, 400, resp.StatusCode)
+	var invalid model.Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&invalid))
+	assert.Equal(t, []model.Violation{{Field: "/body/scopes/1", Rule: "enum", Message: "must be one of articles:read, articles:write, admin"}}, invalid.Violations)
+
+	resp = do("POST", "/api/admin/keys", `{"name":"ci","scopes":["articles:read"]}`)
+	assert.Equal(t, 201, resp.StatusCode)
+	var minted map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&minted))
+	assert.Equal(t, "ci", minted["name"])
+	assert.NotEmpty(t, minted["key"])
+	assert.NotContains(t, minted, "Hash")
+
+	resp = do("GET", "/api/admin/keys", "")
+	assert.Equal(t, 200, resp.StatusCode)
+	var keys []map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&keys))
+	assert.Len(t, keys, 5)
+	for _, key := range keys {
+		assert.NotContains(t, key, "key")
+	}
+
+	assert.Equal(t, 204, do("DELETE", "/api/admin/keys/"+minted["id"].(string), "").StatusCode)
+	assert.Equal(t, 404, do("DELETE", "/api/admin/keys/missing", "").StatusCode)
+	_, err := keyStore.Authenticate(context.Background(), minted["key"].(string))
+	assert.Error(t, err)
+}
+
+func TestBearerRoles(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{KeyStore: newMockAPIKeyStore(), TokenVerifier: mockTokenVerifier{}}),
+		model.HTTPProperties{Auth: model.AuthProperties{Enabled: true}})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	article := `{"id":"11","date":"2019-10-02","tags":["a"],"author":"someone else"}`
+	testScenarios := []struct {
+		Description string
+		Method      string
+		URL         string
+		Token       string
+		StatusCode  int
+	}{
+		{"Invalid token", "GET", "/api/articles/1", "invalid", 401},
+		{"Reader reads", "GET", "/api/articles/1", "reader", 200},
+		{"Reader writes", "POST", "/api/v2/articles/", "reader", 403},
+		{"Editor reads", "GET", "/api/v2/tags/tagName/2019-10-02", "editor", 200},
+		{"Editor writes", "POST", "/api/v2/articles/", "editor", 201},
+		{"Editor lists keys", "GET", "/api/admin/keys", "editor", 403},
+		{"Admin lists keys", "GET", "/api/admin/keys", "admin", 200},
+	}
+	for _, td := range testScenarios {
+		t.Run(fmt.Sprintf("%s - %s %s : %d", td.Description, td.Method, td.URL, td.StatusCode), func(t *testing.T) {
+			req, _ := http.NewRequest(td.Method, server.URL+td.URL, bytes.NewBufferString(article))
+			req.Header.Set("Authorization", "Bearer "+td.Token)
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, td.StatusCode, resp.StatusCode)
+			switch td.StatusCode {
+			case 401:
+				assert.Equal(t, []string{`Bearer realm="articleapi"`, `ApiKey header="X-API-Key"`}, resp.Header["Www-Authenticate"])
+			case 201:
+				// the author is the authenticated subject, never the one sent by the client
+				var created model.Envelope
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+				assert.Equal(t, "editor-subject", created.Data.(map[string]interface{})["author"])
+			}
+		})
+	}
+}
+
+func TestAdminRoutesNeedAuth(t *testing.T) {
+	// anonymous callers would manage the keys and webhooks with the auth disabled
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{KeyStore: newMockAPIKeyStore(), Webhooks: newMockWebhookService()}),
+		model.HTTPProperties{})
+	for _, url := range []string{"/api/admin/keys", "/api/webhooks/", "/api/webhooks/dead-letters"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		assert.Equal(t, 404, w.Code, url)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/articles/1", nil))
+	assert.Equal(t, 200, w.Code)
+
+	// admins identified by a token, without any key store
+	router = chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{TokenVerifier: mockTokenVerifier{}}),
+		model.HTTPProperties{Auth: model.AuthProperties{Enabled: true}})
+	for _, method := range []string{"GET", "POST"} {
+		req := httptest.NewRequest(method, "/api/admin/keys", bytes.NewBufferString(`{"name":"ci","scopes":["articles:read"]}`))
+		req.Header.Set("Authorization", "Bearer admin")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 404, w.Code, method)
+	}
+}
+
+type mockTokenVerifier struct{}
+
+func (mockTokenVerifier) Verify(token string) (*model.Principal, error) {
+	if _, ok := model.RoleScopes[token]; !ok {
+		return nil, model.Errorf(model.ErrUnauthorized, "Invalid bearer token - invalid signature")
+	}
+	return &model.Principal{Subject: token + "-subject", Method: model.AuthJWT, Roles: []string{token}, Scopes: model.RoleScopes[token]}, nil
+}
+
+type mockAPIKeyStore struct {
+	keys map[string]*model.APIKey // {plain key : key}
+}
+
+func newMockAPIKeyStore() *mockAPIKeyStore {
+	store := &mockAPIKeyStore{keys: map[string]*model.APIKey{}}
+	for _, name := range []string{"reader", "writer", "admin", "revoked"} {
+		store.keys[name] = &model.APIKey{ID: name, Name: name, CreatedAt: mockUpdatedAt}
+	}
+	store.keys["reader"].Scopes = []string{model.ScopeArticlesRead}
+	store.keys["writer"].Scopes = []string{model.ScopeArticlesWrite}
+	store.keys["admin"].Scopes = []string{model.ScopeAdmin}
+	store.keys["revoked"].Scopes = []string{model.ScopeAdmin}
+	revokedAt := mockUpdatedAt
+	store.keys["revoked"].RevokedAt = &revokedAt
+	return store
+}
+
+func (store *mockAPIKeyStore) CreateAPIKey(ctx context.Context, name string, scopes []string) (*model.APIKey, error) {
+	key := &model.APIKey{ID: "minted", Name: name, Key: "minted.secret", Hash: "hash", Scopes: scopes, CreatedAt: time.Now().UTC()}
+	stored := *key
+	stored.Key = ""
+	store.keys[key.Key] = &stored
+	return key, nil
+}
+
+func (store *mockAPIKeyStore) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	apiKey, ok := store.keys[key]
+	if !ok || apiKey.RevokedAt != nil {
+		return nil, model.Errorf(model.ErrUnauthorized, "Unknown API key")
+	}
+	return apiKey, nil
+}
+
+func (store *mockAPIKeyStore) ListAPIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	for _, key := range store.keys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (store *mockAPIKeyStore) RevokeAPIKey(ctx context.Context, keyID string) error {
+	for _, key := range store.keys {
+		if key.ID == keyID {
+			now := time.Now().UTC()
+			key.RevokedAt = &now
+			return nil
+		}
+	}
+	return model.Errorf(model.ErrNotFound, "API key %s not found", keyID)
+}
+
+func TestClientCertificateScopes(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{KeyStore: newMockAPIKeyStore()}),
+		model.HTTPProperties{
+			Auth: model.AuthProperties{Enabled: true},
+			TLS:  model.TLSProperties{RoleMapping: map[string]string{"reporting": model.RoleReader}},
+		})
+
+	testScenarios := []struct {
+		Description string
+		Method      string
+		CommonName  string
+		Key         string
+		StatusCode  int
+	}{
+		{"Mapped certificate reads", "GET", "reporting", "", 200},
+		{"Mapped certificate writes", "POST", "reporting", "", 403},
+		{"Unmapped certificate reads", "GET", "unknown", "", 403},
+		{"API key wins over the certificate", "POST", "reporting", "writer", 200},
+		{"No certificate", "GET", "", "", 401},
+	}
+	for _, td := range testScenarios {
+		t.Run(td.Description, func(t *testing.T) {
+			req := httptest.NewRequest(td.Method, "/api/articles/", bytes.NewBufferString(`{"id":"11","date":"2019-10-02","tags":["a"]}`))
+			if td.Method == "GET" {
+				req = httptest.NewRequest(td.Method, "/api/articles/1", nil)
+			}
+			if td.CommonName != "" {
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: td.CommonName}}}}}
+			}
+			if td.Key != "" {
+				req.Header.Set("X-API-Key", td.Key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, td.StatusCode, w.Code)
+		})
+	}
+}
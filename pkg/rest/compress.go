@@ -0,0 +1,216 @@
+package rest
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/andybalholm/brotli"
+)
+
+// compressionEncodings are preferred in this order when the client accepts them equally
+var compressionEncodings = []string{"br", "gzip"}
+
+// compress encodes the responses in the encoding the client accepts best, once they reach props.MinSize bytes.
+// Compressed responses get a weak ETag, they are equivalent to but not the bytes of the identity representation.
+func compress(props model.CompressionProperties) func(http.Handler) http.Handler {
+	encodings := props.Encodings
+	if len(encodings) == 0 {
+		encodings = compressionEncodings
+	}
+	return func(next http.Handler) http.Handler {
+		if !props.Enabled {
+			return next
+		}
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), encodings)
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: props.MinSize}
+			next.ServeHTTP(cw, r)
+			// not deferred, the response of a panic is left to recoverHandler
+			if err := cw.Close(); err != nil {
+				loggerOf(r).WithError(err).Errorln("Could not compress the response")
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// negotiateEncoding returns the encoding of encodings with the highest Accept-Encoding quality,
+// the first one on a tie, or an empty string when the client accepts none of them
+func negotiateEncoding(acceptEncoding string, encodings []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	qualities := map[string]float64{}
+	for _, accepted := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(accepted, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+		qualities[coding] = q
+	}
+	best, bestQ := "", 0.0
+	for _, encoding := range encodings {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressible reports whether the response is text worth compressing, event streams are left
+// as is so that every event reaches the client when flushed
+func compressible(header http.Header, status int) bool {
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	if mediaType == "text/event-stream" {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") || mediaType == "application/javascript" {
+		return true
+	}
+	for _, suffix := range []string{"json", "xml", "yaml"} {
+		if strings.HasSuffix(mediaType, "/"+suffix) || strings.HasSuffix(mediaType, "+"+suffix) || strings.HasSuffix(mediaType, "/x-"+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter holds the response back until it reaches minSize bytes or ends,
+// then sends it compressed or as is
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.decided {
+		if cw.encoder != nil {
+			return cw.encoder.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.minSize && len(cw.buf) > 0 {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide sends the status and the held back bytes, compressed when large enough and compressible
+func (cw *compressWriter) decide(largeEnough bool) error {
+	cw.decided = true
+	header := cw.Header()
+	if largeEnough && compressible(header, cw.status) {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", cw.encoding)
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		cw.ResponseWriter.WriteHeader(cw.status)
+		if cw.encoding == "br" {
+			cw.encoder = brotli.NewWriter(cw.ResponseWriter)
+		} else {
+			cw.encoder = gzip.NewWriter(cw.ResponseWriter)
+		}
+		_, err := cw.encoder.Write(cw.buf)
+		cw.buf = nil
+		return err
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	_, err := cw.ResponseWriter.Write(cw.buf)
+	cw.buf = nil
+	return err
+}
+
+// Close sends what was held back and ends the compressed stream
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if cw.status == 0 {
+			// nothing was written, eg: the connection was hijacked
+			return nil
+		}
+		return cw.decide(false)
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+	return nil
+}
+
+type flusher interface {
+	Flush() error
+}
+
+// Flush sends what was written so far, a response flushed before it reaches minSize is sent as is
+func (cw *compressWriter) Flush() {
+	if !cw.decided && cw.status != 0 {
+		if err := cw.decide(len(cw.buf) >= cw.minSize && len(cw.buf) > 0); err != nil {
+			return
+		}
+	}
+	if f, ok := cw.encoder.(flusher); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over, eg: to a websocket
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("the response writer does not support hijacking")
+}
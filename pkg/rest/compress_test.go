@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	preferred := []string{"br", "gzip"}
+	assert.Equal(t, "br", negotiateEncoding("gzip, deflate, br", preferred))
+	assert.Equal(t, "gzip", negotiateEncoding("gzip;q=1.0, br;q=0.5", preferred))
+	assert.Equal(t, "gzip", negotiateEncoding("gzip, br;q=0", preferred))
+	assert.Equal(t, "br", negotiateEncoding("*", preferred))
+	assert.Equal(t, "gzip", negotiateEncoding("br;q=0, *;q=0.1", preferred))
+	assert.Equal(t, "", negotiateEncoding("identity", preferred))
+	assert.Equal(t, "", negotiateEncoding("", preferred))
+	assert.Equal(t, "gzip", negotiateEncoding("gzip, br", []string{"gzip"}))
+}
+
+func TestCompression(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}),
+		model.HTTPProperties{Compression: model.CompressionProperties{Enabled: true, MinSize: 1024}})
+	router.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(strings.Repeat("data: ping\n\n", 200)))
+		w.(http.Flusher).Flush()
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	testScenarios := []struct {
+		Description    string
+		URL            string
+		AcceptEncoding string
+		Encoding       string
+	}{
+		{"Brotli", "/api/openapi.json", "gzip, br", "br"},
+		{"Gzip", "/api/openapi.json", "gzip", "gzip"},
+		{"Identity", "/api/openapi.json", "", ""},
+		{"Below the minimum size", "/api/articles/1", "gzip, br", ""},
+		{"Event stream", "/stream", "gzip, br", ""},
+	}
+	for _, td := range testScenarios {
+		t.Run(td.Description, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", server.URL+td.URL, nil)
+			// set explicitly, the transport would otherwise ask for and decode gzip itself
+			req.Header.Set("Accept-Encoding", td.AcceptEncoding)
+			resp, err := http.DefaultTransport.RoundTrip(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, 200, resp.StatusCode)
+			assert.Equal(t, td.Encoding, resp.Header.Get("Content-Encoding"))
+			assert.Contains(t, resp.Header.Values("Vary"), "Accept-Encoding")
+
+			var body io.Reader = resp.Body
+			switch td.Encoding {
+			case "br":
+				body = brotli.NewReader(resp.Body)
+			case "gzip":
+				body, err = gzip.NewReader(resp.Body)
+				assert.NoError(t, err)
+			}
+			data, err := ioutil.ReadAll(body)
+			assert.NoError(t, err)
+			if td.URL == "/api/openapi.json" {
+				assert.True(t, strings.HasPrefix(string(data), `{"openapi":"3.0.3"`), string(data[:20]))
+			}
+		})
+	}
+}
+
+func TestCompressionWeakensETags(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}),
+		model.HTTPProperties{Compression: model.CompressionProperties{Enabled: true}})
+
+	req := httptest.NewRequest("GET", "/api/articles/1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	etag := w.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`), etag)
+
+	// the weak etag still validates the cached representation
+	req = httptest.NewRequest("GET", "/api/articles/1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 304, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+}
@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// renderCacheable renders v in the negotiated format along with its ETag and Last-Modified validators.
+// When the request preconditions show the client already holds this representation
+// a 304 is sent instead of the body.
+func renderCacheable(w http.ResponseWriter, r *http.Request, v interface{}, lastModified time.Time) {
+	c := responseCodec(r)
+	body, err := c.marshal(envelope(r, v))
+	if err != nil {
+		renderErrorResponse(w, r, model.ErrorEf(model.ErrUnknown, err, "Unable to render response"))
+		return
+	}
+
+	// the negotiated format is part of the representation hence of its etag
+	etag := strongETag(body)
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeBody(w, r, c.contentType, body)
+}
+
+// strongETag identifies the exact bytes of a representation
+func strongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since as per RFC 7232.
+// If-Modified-Since is ignored when If-None-Match is present.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			// If-None-Match uses the weak comparison function
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// http dates have second precision
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
@@ -0,0 +1,308 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// validateContract rejects requests that don't match openAPISpec with model.ErrInvalidInput.
+// In debug mode responses are validated as well, and a response breaking the
+// contract is replaced by an internal server error listing the violations.
+func validateContract(debug bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			operation, pathParams := findOperation(r)
+			if operation == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if violations := validateRequest(r, operation, pathParams); len(violations) > 0 {
+				renderErrorResponse(w, r, model.Violationsf(violations, "Request does not match the API contract"))
+				return
+			}
+			if !debug || streamed(operation) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			if violations := validateResponse(recorder, operation); len(violations) > 0 {
+				loggerOf(r).WithField("violations", violations).Errorln("Response of", r.Method, r.URL.Path, "does not match the API contract")
+				renderErrorResponse(w, r, &model.Error{
+					Code:       model.ErrUnknown,
+					Message:    "Response does not match the API contract",
+					Violations: violations,
+				})
+				return
+			}
+			recorder.flush()
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// findOperation matches the request against the documented paths, trailing slashes are ignored
+// as they are by the router. Like the router, literal segments win over parameters, eg: /webhooks/dead-letters over /webhooks/{id}.
+func findOperation(r *http.Request) (*apiOperation, map[string]string) {
+	segments := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	var found *apiOperation
+	var foundParams map[string]string
+	for template, operations := range openAPISpec.Paths {
+		operation, ok := operations[strings.ToLower(r.Method)]
+		if !ok {
+			continue
+		}
+		templateSegments := strings.Split(strings.TrimSuffix(template, "/"), "/")
+		if len(templateSegments) != len(segments) {
+			continue
+		}
+		params := map[string]string{}
+		for i, segment := range templateSegments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && segments[i] != "" {
+				params[segment[1:len(segment)-1]] = segments[i]
+			} else if segment != segments[i] {
+				params = nil
+				break
+			}
+		}
+		if params != nil && (found == nil || len(params) < len(foundParams)) {
+			found, foundParams = operation, params
+		}
+	}
+	return found, foundParams
+}
+
+func validateRequest(r *http.Request, operation *apiOperation, pathParams map[string]string) []model.Violation {
+	var violations []model.Violation
+	query := r.URL.Query()
+	for _, param := range operation.Parameters {
+		pointer := "/" + param.In + "/" + escapePointer(param.Name)
+		var value string
+		var present bool
+		switch param.In {
+		case "path":
+			value, present = pathParams[param.Name]
+		case "query":
+			_, present = query[param.Name]
+			value = query.Get(param.Name)
+		case "header":
+			value = r.Header.Get(param.Name)
+			present = value != ""
+		}
+		if !present {
+			if param.Required {
+				violations = append(violations, model.Violation{Field: pointer, Rule: "required", Message: "is required"})
+			}
+			continue
+		}
+		violations = append(violations, validateSchema(value, param.Schema, pointer)...)
+	}
+
+	if operation.RequestBody == nil {
+		return violations
+	}
+	body, err := readAndRestoreBody(r)
+	if err != nil {
+		return append(violations, model.Violation{Field: "/body", Rule: "readable", Message: err.Error()})
+	}
+	if len(body) == 0 {
+		if operation.RequestBody.Required {
+			violations = append(violations, model.Violation{Field: "/body", Rule: "required", Message: "is required"})
+		}
+		return violations
+	}
+	mediaType := "application/json"
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err = mime.ParseMediaType(contentType)
+	}
+	content, ok := operation.RequestBody.Content[mediaType]
+	// only json bodies are checked here, other formats are left to the handler once decoded
+	if err != nil || !ok || mediaType != "application/json" {
+		return violations
+	}
+	return append(violations, validateJSON(body, content.Schema, "/body")...)
+}
+
+// streamed reports whether the operation streams its response, or switches to a websocket,
+// which cannot be held back to be validated
+func streamed(operation *apiOperation) bool {
+	if _, ok := operation.Responses["101"]; ok {
+		return true
+	}
+	response, ok := operation.Responses["200"]
+	if !ok {
+		return false
+	}
+	_, ok = response.Content["text/event-stream"]
+	return ok
+}
+
+func validateResponse(recorder *responseRecorder, operation *apiOperation) []model.Violation {
+	response, ok := operation.Responses[strconv.Itoa(recorder.status)]
+	if !ok || recorder.body.Len() == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(recorder.Header().Get("Content-Type"))
+	content, ok := response.Content[mediaType]
+	if !ok || (mediaType != "application/json" && mediaType != "application/problem+json") {
+		return nil
+	}
+	return validateJSON(recorder.body.Bytes(), content.Schema, "/response")
+}
+
+func validateJSON(data []byte, schema *apiSchema, pointer string) []model.Violation {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []model.Violation{{Field: pointer, Rule: "json", Message: "is not valid JSON: " + err.Error()}}
+	}
+	return validateSchema(value, schema, pointer)
+}
+
+// validateSchema checks a decoded JSON value against the schema, the pointer locates the value
+func validateSchema(value interface{}, schema *apiSchema, pointer string) []model.Violation {
+	if schema == nil {
+		return nil
+	}
+	if schema.Ref != "" {
+		return validateSchema(value, openAPISpec.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")], pointer)
+	}
+	violation := func(rule string, format string, args ...interface{}) []model.Violation {
+		return []model.Violation{{Field: pointer, Rule: rule, Message: fmt.Sprintf(format, args...)}}
+	}
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return violation("type", "must be of type %s, got null", schema.Type)
+	}
+
+	var violations []model.Violation
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return violation("type", "must be of type object")
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				violations = append(violations, model.Violation{
+					Field: pointer + "/" + escapePointer(name), Rule: "required", Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := schema.Properties[name]; ok {
+				violations = append(violations, validateSchema(object[name], property, pointer+"/"+escapePointer(name))...)
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return violation("type", "must be of type array")
+		}
+		if schema.MinItems != nil && len(array) < *schema.MinItems {
+			violations = append(violations, violation("minItems", "must have at least %d items", *schema.MinItems)...)
+		}
+		for i, item := range array {
+			violations = append(violations, validateSchema(item, schema.Items, pointer+"/"+strconv.Itoa(i))...)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return violation("type", "must be of type string")
+		}
+		if schema.MinLength != nil && utf8.RuneCountInString(s) < *schema.MinLength {
+			violations = append(violations, violation("minLength", "must be at least %d characters long", *schema.MinLength)...)
+		}
+		if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+			violations = append(violations, violation("enum", "must be one of %s", strings.Join(schema.Enum, ", "))...)
+		}
+		if schema.Format == "date" {
+			if _, err := time.Parse("2006-01-02", s); err != nil {
+				violations = append(violations, violation("format", "must be a date of format YYYY-MM-DD")...)
+			}
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				violations = append(violations, violation("format", "must be an RFC 3339 date-time")...)
+			}
+		}
+	case "integer":
+		n, ok := value.(json.Number)
+		if _, err := n.Int64(); !ok || err != nil {
+			return violation("type", "must be of type integer")
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return violation("type", "must be of type number")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return violation("type", "must be of type boolean")
+		}
+	}
+	return violations
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// escapePointer escapes a JSON pointer reference token as per RFC 6901
+func escapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+func readAndRestoreBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+// responseRecorder holds back a response until it has been validated
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	return rec.body.Write(b)
+}
+
+func (rec *responseRecorder) flush() {
+	rec.ResponseWriter.WriteHeader(rec.status)
+	rec.ResponseWriter.Write(rec.body.Bytes())
+}
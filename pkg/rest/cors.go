@@ -0,0 +1,125 @@
+package rest
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// defaults of the CORS settings
+var (
+	corsMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete}
+	corsHeaders = []string{"Accept", "Authorization", "Content-Type", apiKeyHeader, "X-Request-ID", "If-None-Match", "If-Modified-Since"}
+)
+
+// cors answers the preflight requests of the allowed origins and lets them read the responses.
+// Requests of other origins are served without CORS headers, the browser then keeps the response from the app.
+// Preflights are answered before authentication and rate limiting, browsers send them without credentials.
+func cors(props model.CORSProperties) func(http.Handler) http.Handler {
+	methods := props.AllowedMethods
+	if len(methods) == 0 {
+		methods = corsMethods
+	}
+	headers := props.AllowedHeaders
+	if len(headers) == 0 {
+		headers = corsHeaders
+	}
+	return func(next http.Handler) http.Handler {
+		if len(props.AllowedOrigins) == 0 {
+			return next
+		}
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			w.Header().Add("Vary", "Origin")
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+			}
+			allowed, any := originAllowed(props.AllowedOrigins, origin)
+			switch {
+			case !allowed && preflight:
+				w.WriteHeader(http.StatusNoContent)
+				return
+			case !allowed:
+				next.ServeHTTP(w, r)
+				return
+			case any && !props.AllowCredentials:
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			default:
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if props.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			if !preflight {
+				if len(props.ExposedHeaders) > 0 {
+					w.Header().Set("Access-Control-Expose-Headers", strings.Join(props.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			// the browser checks the requested method and headers against the allowed ones
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+			if props.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(props.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// checkWebSocketOrigin refuses websockets opened by the pages of other origins than the server and the
+// allowed ones, browsers leave the origin checks of websockets to the server. Clients other than browsers
+// send no Origin and are let through.
+func checkWebSocketOrigin(props model.CORSProperties) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin != "" {
+				parsed, err := url.Parse(origin)
+				sameOrigin := err == nil && strings.EqualFold(parsed.Host, r.Host)
+				if allowed, _ := originAllowed(props.AllowedOrigins, origin); !sameOrigin && !allowed {
+					renderErrorResponse(w, r, model.Errorf(model.ErrForbidden, "The origin %s is not allowed", origin))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// originAllowed matches the origin against the allowed ones, exactly or by a *. subdomain wildcard
+// eg: https://*.example.com. any reports that the origin was allowed by *.
+func originAllowed(allowedOrigins []string, origin string) (allowed bool, any bool) {
+	for _, allowedOrigin := range allowedOrigins {
+		if allowedOrigin == "*" {
+			return true, true
+		}
+		if strings.EqualFold(allowedOrigin, origin) {
+			return true, false
+		}
+		if i := strings.Index(allowedOrigin, "*."); i >= 0 {
+			prefix, suffix := strings.ToLower(allowedOrigin[:i]), strings.ToLower(allowedOrigin[i+1:])
+			lower := strings.ToLower(origin)
+			if strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, suffix) && len(lower) > len(prefix)+len(suffix) {
+				// the wildcard stands for subdomains only
+				if subdomain := lower[len(prefix) : len(lower)-len(suffix)]; !strings.ContainsAny(subdomain, "/:@") {
+					return true, false
+				}
+			}
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	newRouter := func(props model.CORSProperties) http.Handler {
+		router := chi.NewRouter()
+		SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{KeyStore: newMockAPIKeyStore()}),
+			model.HTTPProperties{Auth: model.AuthProperties{Enabled: true}, CORS: props})
+		return router
+	}
+	listed := newRouter(model.CORSProperties{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		ExposedHeaders:   []string{"ETag", "RateLimit-Remaining"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	})
+	anyOrigin := newRouter(model.CORSProperties{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}})
+
+	testScenarios := []struct {
+		Description string
+		Router      http.Handler
+		Method      string
+		Origin      string
+		StatusCode  int
+		Headers     map[string]string
+	}{
+		{"Listed origin", listed, "GET", "https://app.example.com", 401, map[string]string{
+			"Access-Control-Allow-Origin":      "https://app.example.com",
+			"Access-Control-Allow-Credentials": "true",
+			"Access-Control-Expose-Headers":    "ETag, RateLimit-Remaining",
+		}},
+		{"Listed preflight", listed, "OPTIONS", "https://app.example.com", 204, map[string]string{
+			"Access-Control-Allow-Origin":  "https://app.example.com",
+			"Access-Control-Allow-Methods": "GET, HEAD, POST, PUT, DELETE",
+			"Access-Control-Allow-Headers": "Accept, Authorization, Content-Type, X-API-Key, X-Request-ID, If-None-Match, If-Modified-Since",
+			"Access-Control-Max-Age":       "600",
+		}},
+		{"Subdomain", listed, "GET", "https://news.example.org", 401, map[string]string{"Access-Control-Allow-Origin": "https://news.example.org"}},
+		{"Wildcard does not match the domain", listed, "GET", "https://example.org", 401, map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"Wildcard does not match an other host", listed, "GET", "https://evil.com/.example.org", 401, map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"Unlisted origin", listed, "GET", "https://evil.com", 401, map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"Unlisted preflight", listed, "OPTIONS", "https://evil.com", 204, map[string]string{"Access-Control-Allow-Methods": ""}},
+		{"Same origin", listed, "GET", "", 401, map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"Any origin", anyOrigin, "GET", "https://evil.com", 401, map[string]string{
+			"Access-Control-Allow-Origin":      "*",
+			"Access-Control-Allow-Credentials": "",
+		}},
+		{"Any origin preflight", anyOrigin, "OPTIONS", "https://evil.com", 204, map[string]string{
+			"Access-Control-Allow-Methods": "GET",
+			"Access-Control-Max-Age":       "",
+		}},
+	}
+	for _, td := range testScenarios {
+		t.Run(td.Description, func(t *testing.T) {
+			req := httptest.NewRequest(td.Method, "/api/articles/1", nil)
+			if td.Origin != "" {
+				req.Header.Set("Origin", td.Origin)
+			}
+			if td.Method == "OPTIONS" {
+				req.Header.Set("Access-Control-Request-Method", "GET")
+				req.Header.Set("Access-Control-Request-Headers", "x-api-key")
+			}
+			w := httptest.NewRecorder()
+			td.Router.ServeHTTP(w, req)
+			assert.Equal(t, td.StatusCode, w.Code)
+			for name, value := range td.Headers {
+				assert.Equal(t, value, w.Header().Get(name), name)
+			}
+		})
+	}
+}
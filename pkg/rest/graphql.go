@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/gql"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/render"
+)
+
+// GraphQL handles GET and POST GraphQL queries over articles and tags
+func (d *delegate) GraphQL(w http.ResponseWriter, r *http.Request) {
+	request, err := readGraphQLRequest(r)
+	if err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	props, _ := r.Context().Value(graphQLLimitsCtxKey{}).(model.GraphQLProperties)
+	result := gql.Do(r.Context(), d.articleStore, *request, props)
+
+	// requests that could not be executed at all have no data
+	if result.Data == nil && result.HasErrors() {
+		render.Status(r, http.StatusBadRequest)
+	} else {
+		render.Status(r, http.StatusOK)
+	}
+	render.JSON(w, r, result)
+}
+
+func readGraphQLRequest(r *http.Request) (*gql.Request, error) {
+	var request gql.Request
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		request.Query = query.Get("query")
+		request.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				return nil, model.ErrorEf(model.ErrInvalidInput, err, "Invalid GraphQL variables")
+			}
+		}
+	} else {
+		if r.Body == nil {
+			return nil, model.Errorf(model.ErrInvalidInput, "No request body")
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, model.ErrorEf(model.ErrInvalidInput, err, "Bad request body")
+		}
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "application/graphql":
+			request.Query = string(body)
+		case "application/json", "":
+			if err := unmarshalBody(r, jsonCodec, body, &request, "Invalid GraphQL request"); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, model.Errorf(model.ErrUnsupportedMediaType,
+				"Unsupported Content-Type %s - expected application/json or application/graphql", mediaType)
+		}
+	}
+	if request.Query == "" {
+		return nil, model.Errorf(model.ErrInvalidInput, "query is mandatory")
+	}
+	return &request, nil
+}
@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphQLRoute(t *testing.T) {
+	router := chi.NewRouter()
+	mockArticleStore := &mockArticleStore{status: true}
+	SetupRoutes(router, NewArticleDelegate(mockArticleStore, DelegateOptions{}), model.HTTPProperties{
+		GraphQL: model.GraphQLProperties{MaxDepth: 2},
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	testScenarios := []struct {
+		Description string
+		Method      string
+		ContentType string
+		Body        string
+		Query       string
+		StatusCode  int
+	}{
+		{"Post json", "POST", "application/json", `{"query": "{ article(id: \"1\") { id } }"}`, "", 200},
+		{"Post graphql", "POST", "application/graphql", `{ tags { name count } }`, "", 200},
+		{"Get", "GET", "", "", `{ article(id: "1") { id } }`, 200},
+		{"Too deep", "POST", "application/json", `{"query": "{ article(id: \"1\") { tags { name } } }"}`, "", 400},
+		{"Invalid query", "POST", "application/graphql", `{ article`, "", 400},
+		{"Missing query", "POST", "application/json", `{}`, "", 400},
+		{"Not json", "POST", "application/json", `query`, "", 400},
+		{"Unsupported type", "POST", "text/plain", `{ tags { name } }`, "", 415},
+	}
+	for _, td := range testScenarios {
+		t.Run(fmt.Sprintf("%s - method %s : %v", td.Description, td.Method, td.StatusCode), func(t *testing.T) {
+			req, _ := http.NewRequest(td.Method, server.URL+"/api/graphql?query="+url.QueryEscape(td.Query), bytes.NewBufferString(td.Body))
+			req.Header.Set("Content-Type", td.ContentType)
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, td.StatusCode, resp.StatusCode)
+		})
+	}
+
+	resp, err := http.Post(server.URL+"/api/graphql", "application/json",
+		bytes.NewBufferString(`{"query": "query A($id: String!) { article(id: $id) { id } }", "variables": {"id": "1"}}`))
+	assert.NoError(t, err)
+	var result struct {
+		Data struct {
+			Article struct{ ID string }
+		}
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "1", result.Data.Article.ID)
+}
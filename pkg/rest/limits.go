@@ -0,0 +1,205 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/vmihailenco/msgpack/v4"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// defaultMaxBodySize caps request bodies when no MaxBodySize is configured
+const defaultMaxBodySize = 1 << 20
+
+type requestLimitsCtxKey struct{}
+
+// limitRequest buffers request bodies of up to props.MaxBodySize bytes and refuses larger ones with a 413,
+// then hands the limits to the handlers decoding the body
+func limitRequest(props model.RequestProperties) func(http.Handler) http.Handler {
+	maxBodySize := props.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+	tooLarge := model.Violation{Field: "/body", Rule: "maxBodySize", Message: fmt.Sprintf("must be at most %d bytes long", maxBodySize)}
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil && r.Body != http.NoBody {
+				if r.ContentLength > maxBodySize {
+					renderErrorResponse(w, r, bodyTooLarge(tooLarge))
+					return
+				}
+				// one byte past the limit tells a body of exactly the limit from a larger one
+				body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+				r.Body.Close()
+				if err != nil {
+					renderErrorResponse(w, r, model.ErrorEf(model.ErrInvalidInput, err, "Bad request body"))
+					return
+				}
+				if int64(len(body)) > maxBodySize {
+					renderErrorResponse(w, r, bodyTooLarge(tooLarge))
+					return
+				}
+				if len(body) > 0 && props.RequireContentType && r.Header.Get("Content-Type") == "" {
+					renderErrorResponse(w, r, model.Violationsf([]model.Violation{
+						{Field: "/header/Content-Type", Rule: "required", Message: "is required for request bodies"},
+					}, "Missing Content-Type"))
+					return
+				}
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+			ctx := context.WithValue(r.Context(), requestLimitsCtxKey{}, props)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func bodyTooLarge(violation model.Violation) error {
+	err := model.Errorf(model.ErrTooLarge, "Request body too large")
+	err.Violations = []model.Violation{violation}
+	return err
+}
+
+// requestLimitsOf returns the limits set by limitRequest, none outside of it
+func requestLimitsOf(r *http.Request) model.RequestProperties {
+	props, _ := r.Context().Value(requestLimitsCtxKey{}).(model.RequestProperties)
+	return props
+}
+
+// unmarshalBody decodes the request body with c, strictly when the request limits ask for it.
+// Strict decoding failures are itemized as violations under the message.
+func unmarshalBody(r *http.Request, c *codec, data []byte, v interface{}, message string) error {
+	unmarshal := c.unmarshal
+	if requestLimitsOf(r).Strict {
+		unmarshal = c.strictUnmarshal
+	}
+	err := unmarshal(data, v)
+	if strict, ok := err.(*strictError); ok {
+		return model.Violationsf(strict.violations, message)
+	}
+	if err != nil {
+		return model.ErrorEf(model.ErrInvalidInput, err, message)
+	}
+	return nil
+}
+
+// strictError lists the unknown fields and trailing data refused by a strict decoding
+type strictError struct {
+	violations []model.Violation
+}
+
+func (e *strictError) Error() string {
+	var problems []string
+	for _, v := range e.violations {
+		problems = append(problems, v.Field+" "+v.Message)
+	}
+	return strings.Join(problems, ", ")
+}
+
+func unknownFields(names ...string) error {
+	err := &strictError{}
+	for _, name := range names {
+		err.violations = append(err.violations, model.Violation{Field: "/body/" + escapePointer(name), Rule: "unknownField", Message: "is not a known field"})
+	}
+	return err
+}
+
+var errTrailingData = &strictError{[]model.Violation{{Field: "/body", Rule: "trailingData", Message: "must hold a single document"}}}
+
+func strictJSONUnmarshal(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		if strings.HasPrefix(err.Error(), "json: unknown field ") {
+			if name, unquoteErr := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field ")); unquoteErr == nil {
+				return unknownFields(name)
+			}
+		}
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errTrailingData
+	}
+	return nil
+}
+
+// encoding/xml can't tell unknown elements apart, only trailing data is refused
+func strictXMLUnmarshal(data []byte, v interface{}) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errTrailingData
+		}
+		switch token := token.(type) {
+		case xml.Comment, xml.ProcInst:
+		case xml.CharData:
+			if len(bytes.TrimSpace(token)) > 0 {
+				return errTrailingData
+			}
+		default:
+			return errTrailingData
+		}
+	}
+}
+
+var yamlUnknownField = regexp.MustCompile(`field (\S+) not found in type`)
+
+func strictYAMLUnmarshal(data []byte, v interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.SetStrict(true)
+	if err := decoder.Decode(v); err != nil {
+		if typeErr, ok := err.(*yaml.TypeError); ok {
+			var names []string
+			for _, problem := range typeErr.Errors {
+				if match := yamlUnknownField.FindStringSubmatch(problem); match != nil {
+					names = append(names, match[1])
+				}
+			}
+			if len(names) == len(typeErr.Errors) {
+				return unknownFields(names...)
+			}
+		}
+		return err
+	}
+	var next interface{}
+	if err := decoder.Decode(&next); err != io.EOF {
+		return errTrailingData
+	}
+	return nil
+}
+
+func strictMsgpackUnmarshal(data []byte, v interface{}) error {
+	reader := bytes.NewReader(data)
+	decoder := msgpack.NewDecoder(reader).UseJSONTag(true)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		if strings.HasPrefix(err.Error(), "msgpack: unknown field ") {
+			if name, unquoteErr := strconv.Unquote(strings.TrimPrefix(err.Error(), "msgpack: unknown field ")); unquoteErr == nil {
+				return unknownFields(name)
+			}
+		}
+		return err
+	}
+	if reader.Len() > 0 {
+		return errTrailingData
+	}
+	return nil
+}
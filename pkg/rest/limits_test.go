@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLimits(t *testing.T) {
+	props := model.HTTPProperties{Requests: model.RequestProperties{
+		MaxBodySize:        200,
+		Strict:             true,
+		RequireContentType: true,
+	}}
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), props)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	testScenarios := []struct {
+		Description string
+		URL         string
+		ContentType string
+		Body        string
+		StatusCode  int
+		Violations  []model.Violation
+	}{
+		{"Valid article", "/api/articles/", "application/json", `{"id":"11","title":"ab","date":"2019-10-02","tags":["a"]}`, 200, nil},
+		{"Body too large", "/api/articles/", "application/json", `{"id":"11","date":"2019-10-02","tags":["a"],"body":"` + strings.Repeat("a", 200) + `"}`, 413,
+			[]model.Violation{{Field: "/body", Rule: "maxBodySize", Message: "must be at most 200 bytes long"}}},
+		{"Missing Content-Type", "/api/articles/", "", `{"id":"11","date":"2019-10-02","tags":["a"]}`, 400,
+			[]model.Violation{{Field: "/header/Content-Type", Rule: "required", Message: "is required for request bodies"}}},
+		{"Unsupported Content-Type", "/api/articles/", "text/plain", `id: 11`, 415, nil},
+		{"Unknown json field", "/api/articles/", "application/json", `{"id":"11","date":"2019-10-02","tags":["a"],"draft":true}`, 400,
+			[]model.Violation{{Field: "/body/draft", Rule: "unknownField", Message: "is not a known field"}}},
+		{"Trailing json", "/api/articles/", "application/json", `{"id":"11","date":"2019-10-02","tags":["a"]}{}`, 400,
+			[]model.Violation{{Field: "/body", Rule: "trailingData", Message: "must hold a single document"}}},
+		{"Unknown yaml fields", "/api/articles/", "application/yaml", "id: \"11\"\ndate: 2019-10-02\ntags: [a]\ndraft: true\nstatus: new\n", 400,
+			[]model.Violation{
+				{Field: "/body/draft", Rule: "unknownField", Message: "is not a known field"},
+				{Field: "/body/status", Rule: "unknownField", Message: "is not a known field"},
+			}},
+		{"Trailing yaml", "/api/articles/", "application/yaml", "id: \"11\"\ndate: 2019-10-02\ntags: [a]\n---\nid: \"12\"\n", 400,
+			[]model.Violation{{Field: "/body", Rule: "trailingData", Message: "must hold a single document"}}},
+		{"Trailing xml", "/api/articles/", "application/xml", `<article><id>11</id><date>2019-10-02</date><tags><tag>a</tag></tags></article><article/>`, 400,
+			[]model.Violation{{Field: "/body", Rule: "trailingData", Message: "must hold a single document"}}},
+		{"Unknown GraphQL request field", "/api/graphql", "application/json", `{"query":"{tags{name}}","extensions":{}}`, 400,
+			[]model.Violation{{Field: "/body/extensions", Rule: "unknownField", Message: "is not a known field"}}},
+	}
+	for _, td := range testScenarios {
+		t.Run(fmt.Sprintf("%s : %d", td.Description, td.StatusCode), func(t *testing.T) {
+			req, _ := http.NewRequest("POST", server.URL+td.URL, bytes.NewBufferString(td.Body))
+			if td.ContentType != "" {
+				req.Header.Set("Content-Type", td.ContentType)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, td.StatusCode, resp.StatusCode)
+			if td.Violations != nil {
+				var invalid model.Problem
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&invalid))
+				assert.Equal(t, td.Violations, invalid.Violations)
+			}
+		})
+	}
+}
+
+func TestRequestLimitsDefaults(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// lenient decoding of bodies without a Content-Type
+	resp, err := http.Post(server.URL+"/api/articles/", "", bytes.NewBufferString(`{"id":"11","date":"2019-10-02","tags":["a"],"draft":true}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// bodies are capped at 1 MiB, v2 envelopes the error
+	resp, err = http.Post(server.URL+"/api/v2/articles/", "application/json", bytes.NewReader(make([]byte, defaultMaxBodySize+1)))
+	assert.NoError(t, err)
+	assert.Equal(t, 413, resp.StatusCode)
+	var enveloped model.Envelope
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&enveloped))
+	assert.Equal(t, "too_large", enveloped.Errors[0].Code)
+}
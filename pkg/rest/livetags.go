@@ -0,0 +1,338 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/metrics"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/stream"
+	"github.com/gorilla/websocket"
+)
+
+// messages of the live tags websocket
+const (
+	liveSubscribe    = "subscribe"
+	liveUnsubscribe  = "unsubscribe"
+	liveSnapshot     = "snapshot"
+	liveUnsubscribed = "unsubscribed"
+	liveError        = "error"
+)
+
+// liveTagsReadLimit is the largest message a client may send, subscriptions are a tag and a date
+const liveTagsReadLimit = 4096
+
+// liveTagsReplies is the number of replies waiting to be sent, a client sending requests
+// without reading the replies is disconnected
+const liveTagsReplies = 16
+
+// the origin is checked by checkWebSocketOrigin, which renders a problem rather than a bare 403
+var liveTagsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// liveTagsMessage is a message of the live tags websocket, both ways. Clients send subscribe and unsubscribe
+// messages, the server answers with snapshot, unsubscribed and error messages.
+type liveTagsMessage struct {
+	Type     string          `json:"type"`
+	Tag      string          `json:"tag,omitempty"`
+	Date     string          `json:"date,omitempty"`
+	Tagsview *model.Tagsview `json:"tagsview,omitempty"`
+	Code     string          `json:"code,omitempty"`
+	Message  string          `json:"message,omitempty"`
+}
+
+// liveTag is a tag and date pair a client follows
+type liveTag struct {
+	tag  string
+	date string
+}
+
+// LiveTags handles a websocket following the stats of tag and date pairs. Clients subscribe to a pair and get
+// a snapshot of its stats right away, then a new one whenever an article of the tag and date is created.
+// Snapshots waiting to be sent are coalesced, a client only ever gets the latest stats of a pair,
+// and clients not taking their messages within the write timeout are disconnected.
+func (d *delegate) LiveTags(w http.ResponseWriter, r *http.Request) {
+	if d.streams == nil {
+		renderErrorResponse(w, r, model.Errorf(model.ErrNotFound, "The stream is not enabled"))
+		return
+	}
+	conn, err := liveTagsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader replied with the error
+		loggerOf(r).WithError(err).Warnln("Could not open the live tags websocket")
+		return
+	}
+	sub, _ := d.streams.Subscribe(nil, "")
+	metrics.ObserveStreamClient("websocket", 1)
+	defer metrics.ObserveStreamClient("websocket", -1)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	live := &liveTags{
+		delegate:      d,
+		r:             r,
+		conn:          conn,
+		cancel:        cancel,
+		subscriptions: map[liveTag]bool{},
+		pending:       map[liveTag]bool{},
+		wake:          make(chan struct{}, 1),
+		replies:       make(chan liveTagsMessage, liveTagsReplies),
+		done:          make(chan struct{}),
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		live.follow(sub)
+	}()
+	go func() {
+		defer wg.Done()
+		live.write(ctx)
+	}()
+	live.read()
+	sub.Close()
+	wg.Wait()
+}
+
+// liveTags is a live tags websocket. Its messages are written by write alone,
+// the pairs to send a snapshot of are queued in pending and write is woken up.
+type liveTags struct {
+	delegate *delegate
+	r        *http.Request
+	conn     *websocket.Conn
+	cancel   context.CancelFunc
+
+	mu            sync.Mutex
+	subscriptions map[liveTag]bool
+	pending       map[liveTag]bool
+	wake          chan struct{}
+
+	replies  chan liveTagsMessage
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// stop ends the websocket, closing the connection unblocks the reads
+func (l *liveTags) stop() {
+	l.stopOnce.Do(func() {
+		close(l.done)
+		l.cancel()
+		l.conn.Close()
+	})
+}
+
+// closeWith sends a close message before the websocket is stopped
+func (l *liveTags) closeWith(code int, text string) {
+	deadline := time.Now().Add(l.delegate.streams.WriteTimeout())
+	l.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), deadline)
+	l.stop()
+}
+
+// read handles the messages of the client until the websocket ends. A client has to answer the pings,
+// or send a message, within two heartbeats.
+func (l *liveTags) read() {
+	defer l.stop()
+	timeout := 2 * l.delegate.streams.Heartbeat()
+	l.conn.SetReadLimit(liveTagsReadLimit)
+	l.conn.SetReadDeadline(time.Now().Add(timeout))
+	l.conn.SetPongHandler(func(string) error {
+		return l.conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+	for {
+		messageType, data, err := l.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		l.conn.SetReadDeadline(time.Now().Add(timeout))
+		var message liveTagsMessage
+		if messageType != websocket.TextMessage || json.Unmarshal(data, &message) != nil {
+			l.reply(liveTagsMessage{Type: liveError, Code: model.ErrorCode(model.ErrInvalidInput), Message: "Messages are json text"})
+			continue
+		}
+		l.handle(message)
+	}
+}
+
+func (l *liveTags) handle(message liveTagsMessage) {
+	key := liveTag{tag: message.Tag, date: message.Date}
+	fail := func(code int, text string) {
+		l.reply(liveTagsMessage{Type: liveError, Tag: key.tag, Date: key.date, Code: model.ErrorCode(code), Message: text})
+	}
+	if message.Type != liveSubscribe && message.Type != liveUnsubscribe {
+		fail(model.ErrInvalidInput, "The type is either subscribe or unsubscribe")
+		return
+	}
+	if _, err := time.Parse("2006-01-02", key.date); err != nil || key.tag == "" {
+		fail(model.ErrInvalidInput, "tag and date, YYYY-MM-DD, are mandatory")
+		return
+	}
+
+	l.mu.Lock()
+	if message.Type == liveUnsubscribe {
+		delete(l.subscriptions, key)
+		delete(l.pending, key)
+		l.mu.Unlock()
+		l.reply(liveTagsMessage{Type: liveUnsubscribed, Tag: key.tag, Date: key.date})
+		return
+	}
+	if !l.subscriptions[key] && len(l.subscriptions) >= l.delegate.streams.MaxSubscriptions() {
+		l.mu.Unlock()
+		fail(model.ErrTooManyRequests, "The connection already follows the most tags allowed, unsubscribe from one first")
+		return
+	}
+	// subscribing again asks for a new snapshot
+	l.subscriptions[key] = true
+	l.queue(key)
+	l.mu.Unlock()
+}
+
+// reply queues a message for the client, a client that let the replies pile up is disconnected
+func (l *liveTags) reply(message liveTagsMessage) {
+	select {
+	case l.replies <- message:
+	default:
+		metrics.ObserveStreamLagging("websocket")
+		loggerOf(l.r).Warnln("Disconnected a live tags client not reading its replies")
+		l.closeWith(websocket.ClosePolicyViolation, "the replies were not read")
+	}
+}
+
+// queue asks for a snapshot of the pair, it must be called with the lock held
+func (l *liveTags) queue(key liveTag) {
+	l.pending[key] = true
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// follow queues a snapshot of the pairs of every article created, until the subscription ends
+func (l *liveTags) follow(sub *stream.Subscription) {
+	for {
+		select {
+		case <-l.done:
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				select {
+				case <-l.done:
+					// the client is gone
+					return
+				default:
+				}
+				if sub.Lagging() {
+					metrics.ObserveStreamLagging("websocket")
+					loggerOf(l.r).Warnln("Disconnected a live tags client falling behind the articles created")
+					l.closeWith(websocket.CloseTryAgainLater, "the client fell behind")
+					return
+				}
+				l.closeWith(websocket.CloseGoingAway, "the server is shutting down")
+				return
+			}
+			l.mu.Lock()
+			for key := range l.subscriptions {
+				if key.date == event.Article.Date && hasTag(event.Article, key.tag) {
+					l.queue(key)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// hasTag reports whether the article has the tag, as case sensitive as the tag stats
+func hasTag(article *model.Article, tag string) bool {
+	for _, t := range article.Tags {
+		if t != nil && *t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// write sends the replies, the pending snapshots and the pings, one message at a time
+func (l *liveTags) write(ctx context.Context) {
+	defer l.stop()
+	ping := time.NewTicker(l.delegate.streams.Heartbeat())
+	defer ping.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case message := <-l.replies:
+			if !l.send(message) {
+				return
+			}
+		case <-ping.C:
+			if err := l.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(l.delegate.streams.WriteTimeout())); err != nil {
+				l.writeFailed(err)
+				return
+			}
+		case <-l.wake:
+			l.mu.Lock()
+			keys := make([]liveTag, 0, len(l.pending))
+			for key := range l.pending {
+				keys = append(keys, key)
+			}
+			l.pending = map[liveTag]bool{}
+			l.mu.Unlock()
+			for _, key := range keys {
+				message, ok := l.snapshot(ctx, key)
+				if ok && !l.send(message) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// snapshot reads the stats of the pair, ok is false once the client unsubscribed from it.
+// A pair without articles yet gets empty stats.
+func (l *liveTags) snapshot(ctx context.Context, key liveTag) (message liveTagsMessage, ok bool) {
+	tags, err := l.delegate.articleStore.SearchTagsByDate(ctx, key.date, key.tag)
+	l.mu.Lock()
+	subscribed := l.subscriptions[key]
+	l.mu.Unlock()
+	if !subscribed {
+		return message, false
+	}
+	if err != nil && !notFound(err) {
+		loggerOf(l.r).WithError(err).Errorln("Could not read the stats of tag", key.tag, "on", key.date)
+		return liveTagsMessage{Type: liveError, Tag: key.tag, Date: key.date, Code: model.ErrorCode(model.ErrUnknown),
+			Message: "Could not read the tag stats"}, true
+	}
+	view := &model.Tagsview{Tag: key.tag, Articles: []*string{}, RelatedTags: []*string{}}
+	if err == nil && len(tags) > 0 {
+		view = tags[0]
+	}
+	return liveTagsMessage{Type: liveSnapshot, Tag: key.tag, Date: key.date, Tagsview: view}, true
+}
+
+// notFound reports whether the store found nothing to read
+func notFound(err error) bool {
+	specificError, ok := err.(*model.Error)
+	return ok && specificError.Code == model.ErrNotFound
+}
+
+func (l *liveTags) send(message liveTagsMessage) bool {
+	l.conn.SetWriteDeadline(time.Now().Add(l.delegate.streams.WriteTimeout()))
+	if err := l.conn.WriteJSON(message); err != nil {
+		l.writeFailed(err)
+		return false
+	}
+	return true
+}
+
+// writeFailed tells clients too slow to take their messages apart from the ones that left
+func (l *liveTags) writeFailed(err error) {
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		metrics.ObserveStreamLagging("websocket")
+		loggerOf(l.r).Warnln("Disconnected a live tags client not taking its messages")
+	}
+}
@@ -0,0 +1,144 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/stream"
+	"github.com/go-chi/chi"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingTagStore counts the articles of each tag search, so that snapshots change as articles are created
+type countingTagStore struct {
+	mockArticleStore
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (store *countingTagStore) SearchTagsByDate(ctx context.Context, date string, tag string) ([]*model.Tagsview, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	count, ok := store.counts[tag+"/"+date]
+	if !ok {
+		return nil, model.Errorf(model.ErrNotFound, "Not found error")
+	}
+	return []*model.Tagsview{{Tag: tag, Count: count, Articles: []*string{}, RelatedTags: []*string{}}}, nil
+}
+
+func (store *countingTagStore) create(broker *stream.Broker, date string, tags ...string) {
+	store.mu.Lock()
+	for _, tag := range tags {
+		store.counts[tag+"/"+date]++
+	}
+	store.mu.Unlock()
+	broker.Publish(context.Background(), model.EventArticleCreated, &model.Article{Date: date, Tags: toPointers(tags)})
+}
+
+func liveTagsServer(t *testing.T, store *countingTagStore, broker *stream.Broker, props model.HTTPProperties) *httptest.Server {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(store, DelegateOptions{Streams: broker}), props)
+	return httptest.NewServer(router)
+}
+
+func dialLiveTags(t *testing.T, server *httptest.Server, header http.Header) *websocket.Conn {
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/stream/tags", header)
+	if err != nil {
+		t.Fatalf("could not open the websocket: %v %v", err, resp)
+	}
+	return conn
+}
+
+func readLive(t *testing.T, conn *websocket.Conn) liveTagsMessage {
+	var message liveTagsMessage
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatalf("no message: %v", err)
+	}
+	return message
+}
+
+func TestLiveTags(t *testing.T) {
+	broker := stream.NewBroker(model.StreamProperties{MaxSubscriptions: 2})
+	store := &countingTagStore{counts: map[string]int{"health/2016-09-22": 1}}
+	server := liveTagsServer(t, store, broker, model.HTTPProperties{Debug: true})
+	defer server.Close()
+	conn := dialLiveTags(t, server, nil)
+	defer conn.Close()
+
+	conn.WriteJSON(liveTagsMessage{Type: "subscribe", Tag: "health", Date: "2016-09-22"})
+	snapshot := readLive(t, conn)
+	assert.Equal(t, "snapshot", snapshot.Type)
+	assert.Equal(t, "health", snapshot.Tag)
+	assert.Equal(t, "2016-09-22", snapshot.Date)
+	assert.Equal(t, 1, snapshot.Tagsview.Count)
+
+	conn.WriteJSON(liveTagsMessage{Type: "subscribe", Tag: "science", Date: "2016-09-22"})
+	empty := readLive(t, conn)
+	assert.Equal(t, "snapshot", empty.Type, "tags without articles yet get empty stats")
+	assert.Equal(t, "science", empty.Tagsview.Tag)
+	assert.Equal(t, 0, empty.Tagsview.Count)
+	assert.NotNil(t, empty.Tagsview.Articles)
+
+	conn.WriteJSON(liveTagsMessage{Type: "subscribe", Tag: "fitness", Date: "2016-09-22"})
+	limited := readLive(t, conn)
+	assert.Equal(t, "error", limited.Type)
+	assert.Equal(t, "too_many_requests", limited.Code)
+	assert.Equal(t, "fitness", limited.Tag)
+
+	conn.WriteJSON(liveTagsMessage{Type: "subscribe", Tag: "health", Date: "22/09/2016"})
+	assert.Equal(t, "invalid_input", readLive(t, conn).Code)
+	conn.WriteMessage(websocket.TextMessage, []byte("subscribe"))
+	assert.Equal(t, "invalid_input", readLive(t, conn).Code)
+
+	// articles of other tags, or of other dates, send nothing
+	store.create(broker, "2016-09-22", "fitness")
+	store.create(broker, "2016-09-23", "health")
+	store.create(broker, "2016-09-22", "health", "fitness")
+	updated := readLive(t, conn)
+	assert.Equal(t, "health", updated.Tag)
+	assert.Equal(t, 2, updated.Tagsview.Count)
+
+	conn.WriteJSON(liveTagsMessage{Type: "unsubscribe", Tag: "health", Date: "2016-09-22"})
+	assert.Equal(t, liveTagsMessage{Type: "unsubscribed", Tag: "health", Date: "2016-09-22"}, readLive(t, conn))
+	store.create(broker, "2016-09-22", "health")
+	store.create(broker, "2016-09-22", "science")
+	assert.Equal(t, "science", readLive(t, conn).Tag)
+
+	broker.Close()
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "clients are told the server is shutting down")
+}
+
+func TestLiveTagsOrigin(t *testing.T) {
+	broker := stream.NewBroker(model.StreamProperties{})
+	server := liveTagsServer(t, &countingTagStore{}, broker, model.HTTPProperties{CORS: model.CORSProperties{AllowedOrigins: []string{"https://*.example.com"}}})
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/stream/tags"
+
+	for origin, status := range map[string]int{"https://evil.com": 403, "https://wall.example.com": 101, server.URL: 101} {
+		conn, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {origin}})
+		assert.Equal(t, status, resp.StatusCode, origin)
+		if err == nil {
+			conn.Close()
+		}
+	}
+}
+
+func TestLiveTagsDisabled(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/stream/tags", nil)
+	assert.Error(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
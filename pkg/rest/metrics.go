@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/metrics"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+// instrumentRequest records the count and latency of the requests by chi route pattern and status,
+// requests matching no route share a single label
+func instrumentRequest(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := metrics.UnmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		metrics.ObserveRequest(r.Method, route, status, time.Since(start))
+	}
+
+	return http.HandlerFunc(fn)
+}
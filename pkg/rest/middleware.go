@@ -1,18 +1,79 @@
 package rest
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/api/trace"
 )
 
+// requestIDHeader correlates a request with its logs and problem details
+const requestIDHeader = "X-Request-ID"
+
+type requestIDCtxKey struct{}
+
+// requestID takes the request ID from the X-Request-ID header or generates one, and echoes it back
+func requestID(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestIDCtxKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// validRequestID accepts printable ascii IDs of up to 128 characters, others are replaced
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestIDOf returns the ID of the request, empty outside of requestID
+func requestIDOf(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+// loggerOf returns a logger whose entries carry the request ID, and the trace ID of sampled requests
+func loggerOf(r *http.Request) *log.Entry {
+	entry := log.WithField("request_id", requestIDOf(r))
+	if spanContext := trace.SpanFromContext(r.Context()).SpanContext(); spanContext.IsValid() {
+		entry = entry.WithField("trace_id", spanContext.TraceID.String())
+	}
+	return entry
+}
+
 func recoverHandler(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("panic: %+v", err)
-				http.Error(w, http.StatusText(500), 500)
+				renderErrorResponse(w, r, model.ErrorEf(model.ErrUnknown, fmt.Errorf("panic: %+v", err), "An unexpected error occurred"))
 			}
 		}()
 
@@ -22,14 +83,64 @@ func recoverHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(fn)
 }
 
+// apiLogger logs a structured access log line per request once it is served,
+// queries are left out as they may carry credentials
 func apiLogger(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
-		log.Infoln("Audit log : Request", r.URL)
 		start := time.Now()
-		next.ServeHTTP(w, r)
-		end := time.Now()
-		log.Infoln("Audit Log : Request ", r.URL, " processed in ", end.Sub(start))
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := r.URL.Path
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		loggerOf(r).WithFields(log.Fields{
+			"method":     r.Method,
+			"route":      route,
+			"status":     status,
+			"bytes":      ww.BytesWritten(),
+			"latency_ms": time.Since(start).Seconds() * 1000,
+			"client":     clientIP(r),
+		}).Infoln("Request served")
 	}
 
 	return http.HandlerFunc(fn)
 }
+
+// cacheControl applies the Cache-Control policy configured for the route pattern under prefix,
+// falling back to the policy of the unversioned /api pattern
+func cacheControl(props model.HTTPProperties, prefix string, routePattern string) func(http.Handler) http.Handler {
+	policy, ok := props.CacheControl[prefix+routePattern]
+	if !ok {
+		policy = props.CacheControl["/api"+routePattern]
+	}
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if policy != "" {
+				w.Header().Set("Cache-Control", policy)
+			}
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+type graphQLLimitsCtxKey struct{}
+
+// graphQLLimits hands the configured query limits to the GraphQL handler
+func graphQLLimits(props model.GraphQLProperties) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), graphQLLimitsCtxKey{}, props)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
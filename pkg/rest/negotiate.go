@@ -0,0 +1,200 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/render"
+	"github.com/vmihailenco/msgpack/v4"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// codec renders and parses one of the supported media types
+type codec struct {
+	contentType        string
+	problemContentType string // of RFC 7807 problem details
+	marshal            func(v interface{}) ([]byte, error)
+	unmarshal          func(data []byte, v interface{}) error
+	strictUnmarshal    func(data []byte, v interface{}) error // refuses unknown fields and trailing data
+}
+
+var jsonCodec = &codec{
+	contentType:        "application/json; charset=utf-8",
+	problemContentType: "application/problem+json; charset=utf-8",
+	marshal: func(v interface{}) ([]byte, error) {
+		// mirrors render.JSON
+		buf := &bytes.Buffer{}
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(true)
+		err := enc.Encode(v)
+		return buf.Bytes(), err
+	},
+	unmarshal:       json.Unmarshal,
+	strictUnmarshal: strictJSONUnmarshal,
+}
+
+var xmlCodec = &codec{
+	contentType:        "application/xml; charset=utf-8",
+	problemContentType: "application/problem+xml; charset=utf-8",
+	marshal: func(v interface{}) ([]byte, error) {
+		body, err := xml.Marshal(v)
+		return append([]byte(xml.Header), body...), err
+	},
+	unmarshal:       xml.Unmarshal,
+	strictUnmarshal: strictXMLUnmarshal,
+}
+
+var yamlCodec = &codec{
+	contentType:        "application/yaml; charset=utf-8",
+	problemContentType: "application/yaml; charset=utf-8",
+	marshal:            yaml.Marshal,
+	unmarshal:          yaml.Unmarshal,
+	strictUnmarshal:    strictYAMLUnmarshal,
+}
+
+var msgpackCodec = &codec{
+	contentType:        "application/msgpack",
+	problemContentType: "application/msgpack",
+	marshal: func(v interface{}) ([]byte, error) {
+		buf := &bytes.Buffer{}
+		err := msgpack.NewEncoder(buf).UseJSONTag(true).Encode(v)
+		return buf.Bytes(), err
+	},
+	unmarshal: func(data []byte, v interface{}) error {
+		return msgpack.NewDecoder(bytes.NewReader(data)).UseJSONTag(true).Decode(v)
+	},
+	strictUnmarshal: strictMsgpackUnmarshal,
+}
+
+// maps from media types to their codecs
+var codecs = map[string]*codec{
+	"application/json":      jsonCodec,
+	"application/xml":       xmlCodec,
+	"text/xml":              xmlCodec,
+	"application/yaml":      yamlCodec,
+	"application/x-yaml":    yamlCodec,
+	"text/yaml":             yamlCodec,
+	"application/msgpack":   msgpackCodec,
+	"application/x-msgpack": msgpackCodec,
+}
+
+type codecCtxKey struct{}
+
+// negotiateContent picks the response codec from the Accept header
+// and rejects requests for formats that can't be produced with a 406
+func negotiateContent(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		c := acceptedCodec(r.Header.Get("Accept"))
+		if c == nil {
+			renderErrorResponse(w, r, model.Errorf(model.ErrNotAcceptable,
+				"Not Acceptable - supported formats are json, xml, yaml and msgpack"))
+			return
+		}
+		w.Header().Add("Vary", "Accept")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), codecCtxKey{}, c)))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// acceptedCodec returns the most preferred supported codec of an Accept header,
+// json when there is no preference and nil when nothing acceptable is supported
+func acceptedCodec(accept string) *codec {
+	if strings.TrimSpace(accept) == "" {
+		return jsonCodec
+	}
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qv, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qv, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType, q})
+		}
+	}
+	// highest quality first, order of appearance breaks ties
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, mr := range ranges {
+		if mr.mediaType == "*/*" || mr.mediaType == "application/*" {
+			return jsonCodec
+		}
+		if c, ok := codecs[mr.mediaType]; ok {
+			return c
+		}
+		if mr.mediaType == "text/*" {
+			return xmlCodec
+		}
+	}
+	return nil
+}
+
+// responseCodec is the codec negotiated for the request, json by default
+func responseCodec(r *http.Request) *codec {
+	if c, ok := r.Context().Value(codecCtxKey{}).(*codec); ok {
+		return c
+	}
+	return jsonCodec
+}
+
+// requestCodec resolves the codec of the request body from its Content-Type,
+// json is assumed when the Content-Type is missing
+func requestCodec(r *http.Request) (*codec, error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return jsonCodec, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, model.ErrorEf(model.ErrUnsupportedMediaType, err, "Invalid Content-Type")
+	}
+	c, ok := codecs[mediaType]
+	if !ok {
+		return nil, model.Errorf(model.ErrUnsupportedMediaType,
+			"Unsupported Content-Type %s - supported formats are json, xml, yaml and msgpack", mediaType)
+	}
+	return c, nil
+}
+
+// respond renders v in the negotiated format and api version shape with the status set by render.Status,
+// problems outside of the v2 envelope get the problem media type of the format
+func respond(w http.ResponseWriter, r *http.Request, v interface{}) {
+	c := responseCodec(r)
+	body, err := c.marshal(envelope(r, v))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	contentType := c.contentType
+	if _, ok := v.(*model.Problem); ok && apiVersionOf(r) != apiV2 {
+		contentType = c.problemContentType
+	}
+	writeBody(w, r, contentType, body)
+}
+
+func writeBody(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	if status, ok := r.Context().Value(render.StatusCtxKey).(int); ok {
+		w.WriteHeader(status)
+	}
+	w.Write(body)
+}
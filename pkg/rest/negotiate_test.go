@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	testScenarios := []struct {
+		Description string
+		Accept      string
+		StatusCode  int
+		ContentType string
+		Unmarshal   func([]byte, interface{}) error
+	}{
+		{"No preference", "", 200, "application/json; charset=utf-8", json.Unmarshal},
+		{"Wildcard", "*/*", 200, "application/json; charset=utf-8", json.Unmarshal},
+		{"XML", "application/xml", 200, "application/xml; charset=utf-8", xml.Unmarshal},
+		{"YAML", "application/yaml", 200, "application/yaml; charset=utf-8", yaml.Unmarshal},
+		{"MessagePack", "application/msgpack", 200, "application/msgpack", msgpackCodec.unmarshal},
+		{"Quality preference", "application/json;q=0.5, text/xml", 200, "application/xml; charset=utf-8", xml.Unmarshal},
+		{"Unsupported skipped", "text/html, application/x-yaml;q=0.1", 200, "application/yaml; charset=utf-8", yaml.Unmarshal},
+		{"Unsupported only", "text/html", 406, "application/problem+json; charset=utf-8", nil},
+	}
+	for _, td := range testScenarios {
+		t.Run(fmt.Sprintf("%s - Accept %q : %v", td.Description, td.Accept, td.StatusCode), func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/articles/1", nil)
+			req.Header.Set("Accept", td.Accept)
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, td.StatusCode, resp.StatusCode)
+			assert.Equal(t, td.ContentType, resp.Header.Get("Content-Type"))
+			if td.Unmarshal == nil {
+				return
+			}
+			body, _ := ioutil.ReadAll(resp.Body)
+			var article model.Article
+			assert.NoError(t, td.Unmarshal(body, &article))
+			assert.Equal(t, "1", article.ArticleID)
+			assert.True(t, mockUpdatedAt.Equal(*article.UpdatedAt))
+		})
+	}
+}
+
+func TestContentNegotiationErrorsAndHealth(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/articles/error", nil)
+	req.Header.Set("Accept", "application/xml")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	var apiErr model.Problem
+	assert.NoError(t, xml.Unmarshal(body, &apiErr))
+	assert.Equal(t, "not_found", apiErr.Code)
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/api/healthcheck", nil)
+	req.Header.Set("Accept", "application/yaml")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	body, _ = ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "status: success\n", string(body))
+}
+
+func TestPostArticleContentTypes(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	s := "success"
+	article := &model.Article{ArticleID: "11", Date: "2019-02-01", Tags: []*string{&s}}
+	xmlBody, _ := xml.Marshal(article)
+	yamlBody, _ := yaml.Marshal(article)
+	msgpackBody, _ := msgpackCodec.marshal(article)
+
+	testScenarios := []struct {
+		Description string
+		ContentType string
+		Body        []byte
+		StatusCode  int
+	}{
+		{"XML", "application/xml", xmlBody, 200},
+		{"YAML", "application/x-yaml", yamlBody, 200},
+		{"MessagePack", "application/msgpack", msgpackBody, 200},
+		{"Mismatched body", "application/xml", msgpackBody, 400},
+		{"Unsupported", "text/plain", []byte("id: 11"), 415},
+		{"Malformed", "application/", xmlBody, 415},
+	}
+	for _, td := range testScenarios {
+		t.Run(fmt.Sprintf("%s - Content-Type %q : %v", td.Description, td.ContentType, td.StatusCode), func(t *testing.T) {
+			resp, err := http.Post(server.URL+"/api/articles/", td.ContentType, bytes.NewBuffer(td.Body))
+			assert.NoError(t, err)
+			assert.Equal(t, td.StatusCode, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,738 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+
+	"github.com/go-chi/render"
+)
+
+// openAPIDocument is the subset of OpenAPI 3 used to describe this api
+type openAPIDocument struct {
+	OpenAPI    string                              `json:"openapi"`
+	Info       openAPIInfo                         `json:"info"`
+	Paths      map[string]map[string]*apiOperation `json:"paths"`
+	Components openAPIComponents                   `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*apiSchema         `json:"schemas"`
+	SecuritySchemes map[string]*apiSecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type apiSecurityScheme struct {
+	Type         string `json:"type"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type apiOperation struct {
+	OperationID string                  `json:"operationId"`
+	Summary     string                  `json:"summary,omitempty"`
+	Description string                  `json:"description,omitempty"`
+	Security    []map[string][]string   `json:"security,omitempty"`
+	Parameters  []*apiParameter         `json:"parameters,omitempty"`
+	RequestBody *apiRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*apiResponse `json:"responses"`
+}
+
+type apiParameter struct {
+	Name        string     `json:"name"`
+	In          string     `json:"in"`
+	Description string     `json:"description,omitempty"`
+	Required    bool       `json:"required,omitempty"`
+	Schema      *apiSchema `json:"schema"`
+}
+
+type apiRequestBody struct {
+	Required bool                     `json:"required,omitempty"`
+	Content  map[string]*apiMediaType `json:"content"`
+}
+
+type apiResponse struct {
+	Description string                   `json:"description"`
+	Content     map[string]*apiMediaType `json:"content,omitempty"`
+}
+
+type apiMediaType struct {
+	Schema *apiSchema `json:"schema"`
+}
+
+// apiSchema is the subset of the OpenAPI schema object enforced by validateSchema
+type apiSchema struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Type        string                `json:"type,omitempty"`
+	Format      string                `json:"format,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Nullable    bool                  `json:"nullable,omitempty"`
+	Required    []string              `json:"required,omitempty"`
+	Properties  map[string]*apiSchema `json:"properties,omitempty"`
+	Items       *apiSchema            `json:"items,omitempty"`
+	MinItems    *int                  `json:"minItems,omitempty"`
+	MinLength   *int                  `json:"minLength,omitempty"`
+	Enum        []string              `json:"enum,omitempty"`
+}
+
+func ref(name string) *apiSchema {
+	return &apiSchema{Ref: "#/components/schemas/" + name}
+}
+
+func jsonContent(schema *apiSchema) map[string]*apiMediaType {
+	return map[string]*apiMediaType{"application/json": {Schema: schema}}
+}
+
+// problemContent of error responses, RFC 7807 problem details
+func problemContent() map[string]*apiMediaType {
+	return map[string]*apiMediaType{"application/problem+json": {Schema: ref("Problem")}}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+var errorResponse = &apiResponse{Description: "Error", Content: problemContent()}
+var notModifiedResponse = &apiResponse{Description: "Not Modified - the client's copy matches If-None-Match or If-Modified-Since"}
+var notAcceptableResponse = &apiResponse{Description: "Not Acceptable", Content: problemContent()}
+
+// requireScopeOperation documents the API key scope required by the operation
+func requireScopeOperation(scope string, operation *apiOperation) *apiOperation {
+	operation.Description = "Requires the " + scope + " scope when auth is enabled, granted to an API key or by the roles of a bearer token"
+	operation.Security = []map[string][]string{{"apiKey": {}}, {"bearer": {}}}
+	operation.Responses["401"] = &apiResponse{Description: "Missing or invalid credentials", Content: problemContent()}
+	operation.Responses["403"] = &apiResponse{Description: "The caller lacks the scope", Content: problemContent()}
+	if scope == model.ScopeAdmin {
+		// see requireAuth
+		operation.Description = "Requires the " + scope + " scope, granted to an API key or by the roles of a bearer token. Not found when auth is disabled"
+		if _, ok := operation.Responses["404"]; !ok {
+			operation.Responses["404"] = errorResponse
+		}
+	}
+	// every authorized route is rate limited as well
+	operation.Responses["429"] = &apiResponse{Description: "Rate limit exceeded, retry after the Retry-After seconds", Content: problemContent()}
+	return operation
+}
+
+func graphQLOperation(operationID string) *apiOperation {
+	operation := &apiOperation{
+		OperationID: operationID,
+		Summary:     "GraphQL queries over articles and tags",
+		Responses: map[string]*apiResponse{
+			"200": {Description: "Query result", Content: jsonContent(ref("GraphQLResult"))},
+			"400": {Description: "Query could not be executed", Content: jsonContent(ref("GraphQLResult"))},
+		},
+	}
+	if operationID == "graphQLGet" {
+		operation.Parameters = []*apiParameter{
+			{Name: "query", In: "query", Required: true, Schema: &apiSchema{Type: "string", MinLength: intPtr(1)}},
+			{Name: "operationName", In: "query", Schema: &apiSchema{Type: "string"}},
+			{Name: "variables", In: "query", Description: "JSON encoded variables", Schema: &apiSchema{Type: "string"}},
+		}
+	} else {
+		operation.RequestBody = &apiRequestBody{
+			Required: true,
+			Content: map[string]*apiMediaType{
+				"application/json":    {Schema: ref("GraphQLRequest")},
+				"application/graphql": {Schema: &apiSchema{Type: "string"}},
+			},
+		}
+		operation.Responses["413"] = &apiResponse{Description: "Request body too large", Content: problemContent()}
+	}
+	return operation
+}
+
+// openAPISpec describes every route registered by SetupRoutes
+var openAPISpec = newOpenAPISpec()
+
+func newOpenAPISpec() *openAPIDocument {
+	doc := &openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: "ArticleAPI", Version: "2.0.0"},
+		Paths: map[string]map[string]*apiOperation{
+			"/api/openapi.json": {
+				"get": {
+					OperationID: "openAPI",
+					Summary:     "This document",
+					Responses: map[string]*apiResponse{
+						"200": {Description: "OpenAPI 3 document", Content: jsonContent(&apiSchema{Type: "object"})},
+					},
+				},
+			},
+			"/livez": {
+				"get": {
+					OperationID: "livez",
+					Summary:     "Liveness probe, dependencies are not checked",
+					Responses: map[string]*apiResponse{
+						"200": {Description: "Live", Content: jsonContent(ref("Health"))},
+						"406": notAcceptableResponse,
+					},
+				},
+			},
+			"/readyz": {
+				"get": {
+					OperationID: "readyz",
+					Summary:     "Readiness probe, with the status and latency of every component",
+					Responses: map[string]*apiResponse{
+						"200": {Description: "Ready", Content: jsonContent(ref("Health"))},
+						"406": notAcceptableResponse,
+						"503": {Description: "A component failed or the server is shutting down", Content: jsonContent(ref("Health"))},
+					},
+				},
+			},
+		},
+		Components: openAPIComponents{
+			Schemas: componentSchemas,
+			SecuritySchemes: map[string]*apiSecurityScheme{
+				"apiKey": {Type: "apiKey", In: "header", Name: apiKeyHeader},
+				"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	addPaths(doc, "/api/admin", "", adminOperations())
+	addPaths(doc, "/api/webhooks", "", webhookOperations())
+	addPaths(doc, "/api", "", streamOperations())
+	addPaths(doc, "/api", "", resourceOperations(), graphQLOperations())
+	addPaths(doc, "/api/v1", "v1", resourceOperations(), graphQLOperations())
+	addPaths(doc, "/api/v2", "v2", envelopeOperations(resourceOperations()))
+	return doc
+}
+
+// addPaths documents the operations under prefix, the version keeps operation ids unique
+func addPaths(doc *openAPIDocument, prefix string, version string, operations ...map[string]map[string]*apiOperation) {
+	for _, paths := range operations {
+		for path, methods := range paths {
+			for _, operation := range methods {
+				if version != "" {
+					operation.OperationID = version + strings.Title(operation.OperationID)
+				}
+			}
+			doc.Paths[prefix+path] = methods
+		}
+	}
+}
+
+// resourceOperations describes the article and tag routes relative to their api version
+func resourceOperations() map[string]map[string]*apiOperation {
+	return map[string]map[string]*apiOperation{
+		"/healthcheck": {
+			"get": {
+				OperationID: "healthCheck",
+				Summary:     "Database connectivity",
+				Responses: map[string]*apiResponse{
+					"200": {Description: "Healthy", Content: jsonContent(ref("Health"))},
+					"406": notAcceptableResponse,
+					"429": {Description: "Rate limit of the IP address exceeded", Content: problemContent()},
+					"500": {Description: "Unhealthy", Content: jsonContent(ref("Health"))},
+				},
+			},
+		},
+		"/tags/{tagName}/{date}": {
+			"get": requireScopeOperation(model.ScopeArticlesRead, &apiOperation{
+				OperationID: "searchTags",
+				Summary:     "Stats of a tag on a date",
+				Parameters: []*apiParameter{
+					{Name: "tagName", In: "path", Required: true, Description: "case sensitive", Schema: &apiSchema{Type: "string"}},
+					{Name: "date", In: "path", Required: true, Description: "YYYY-MM-DD", Schema: &apiSchema{Type: "string"}},
+				},
+				Responses: map[string]*apiResponse{
+					"200": {Description: "Tag stats", Content: jsonContent(ref("Tagsview"))},
+					"304": notModifiedResponse,
+					"400": errorResponse,
+					"404": errorResponse,
+					"406": notAcceptableResponse,
+				},
+			}),
+		},
+		"/articles/": {
+			"post": requireScopeOperation(model.ScopeArticlesWrite, &apiOperation{
+				OperationID: "postArticle",
+				Summary:     "Create an article",
+				RequestBody: &apiRequestBody{Required: true, Content: map[string]*apiMediaType{
+					"application/json":    {Schema: ref("NewArticle")},
+					"application/xml":     {Schema: ref("NewArticle")},
+					"application/yaml":    {Schema: ref("NewArticle")},
+					"application/msgpack": {Schema: ref("NewArticle")},
+				}},
+				Responses: map[string]*apiResponse{
+					"201": {Description: "Created"},
+					"400": errorResponse,
+					"406": notAcceptableResponse,
+					"409": errorResponse,
+					"413": errorResponse,
+					"415": errorResponse,
+				},
+			}),
+		},
+		"/articles/{id}": {
+			"get": requireScopeOperation(model.ScopeArticlesRead, &apiOperation{
+				OperationID: "getArticle",
+				Summary:     "Read an article",
+				Parameters: []*apiParameter{
+					{Name: "id", In: "path", Required: true, Schema: &apiSchema{Type: "string"}},
+				},
+				Responses: map[string]*apiResponse{
+					"200": {Description: "Article", Content: jsonContent(ref("Article"))},
+					"304": notModifiedResponse,
+					"404": errorResponse,
+					"406": notAcceptableResponse,
+				},
+			}),
+		},
+	}
+}
+
+// adminOperations describes the API key management routes
+func adminOperations() map[string]map[string]*apiOperation {
+	return map[string]map[string]*apiOperation{
+		"/keys": {
+			"get": requireScopeOperation(model.ScopeAdmin, &apiOperation{
+				OperationID: "listAPIKeys",
+				Summary:     "List the API keys, revoked ones included",
+				Responses: map[string]*apiResponse{
+					"200": {Description: "API keys", Content: jsonContent(&apiSchema{Type: "array", Items: ref("APIKey")})},
+					"406": notAcceptableResponse,
+				},
+			}),
+			"post": requireScopeOperation(model.ScopeAdmin, &apiOperation{
+				OperationID: "mintAPIKey",
+				Summary:     "Mint an API key, the key is only sent in this response",
+				RequestBody: &apiRequestBody{Required: true, Content: map[string]*apiMediaType{
+					"application/json":    {Schema: ref("NewAPIKey")},
+					"application/xml":     {Schema: ref("NewAPIKey")},
+					"application/yaml":    {Schema: ref("NewAPIKey")},
+					"application/msgpack": {Schema: ref("NewAPIKey")},
+				}},
+				Responses: map[string]*apiResponse{
+					"201": {Description: "Minted", Content: jsonContent(ref("APIKey"))},
+					"400": errorResponse,
+					"406": notAcceptableResponse,
+					"413": errorResponse,
+					"415": errorResponse,
+				},
+			}),
+		},
+		"/keys/{id}": {
+			"delete": requireScopeOperation(model.ScopeAdmin, &apiOperation{
+				OperationID: "revokeAPIKey",
+				Summary:     "Revoke an API key",
+				Parameters: []*apiParameter{
+					{Name: "id", In: "path", Required: true, Schema: &apiSchema{Type: "string"}},
+				},
+				Responses: map[string]*apiResponse{
+					"204": {Description: "Revoked"},
+					"404": errorResponse,
+				},
+			}),
+		},
+	}
+}
+
+// webhookOperations describes the routes managing the webhooks and their deliveries
+func webhookOperations() map[string]map[string]*apiOperation {
+	webhookBody := &apiRequestBody{Required: true, Content: map[string]*apiMediaType{
+		"application/json":    {Schema: ref("NewWebhook")},
+		"application/xml":     {Schema: ref("NewWebhook")},
+		"application/yaml":    {Schema: ref("NewWebhook")},
+		"application/msgpack": {Schema: ref("NewWebhook")},
+	}}
+	webhookID := &apiParameter{Name: "id", In: "path", Required: true, Schema: &apiSchema{Type: "string"}}
+	deliveries := jsonContent(&apiSchema{Type: "array", Items: ref("Delivery")})
+	return map[string]map[string]*apiOperation{
+		"/": {
+			"get": requireScopeOperation(model.ScopeAdmin, &apiOperation{
+				OperationID: "listWebhooks",
+				Summary:     "List the webhooks, without their secrets",
+				Parameters: []*apiParameter{
+					{Name: "tag", In: "query", Description: "Only the webhooks receiving the events of the articles with the tag, those without tags included",
+						Schema: &apiSchema{Type: "string"}},
+				},
+				Responses: map[string]*apiResponse{
+					"200": {Description: "Webhooks", Content: jsonContent(&apiSchema{Type: "array", Items: ref("Webhook")})},
+					"404": errorResponse,
+					"406": notAcceptableResponse,
+				},
+			}),
+			"post": requireScopeOperation(model.ScopeAdmin, &apiOperation{
+				OperationID: "createWebhook",
+				Summary:     "Subscribe a URL to the article events, the signing secret is only sent in this response",
+				RequestBody: webhookBody,
+				Responses: map[string]*apiResponse{
+					"201": {Description: "Created", Content: jsonContent(ref("Webhook"))},
+					"400": errorResponse,
+					"404": errorResponse,
+					"406": notAcceptableResponse,
+					"413": errorResponse,
+					"415": errorResponse,
+				},
+			}),
+		},
+		"/{id}": {
+			"get": requireScopeOperation(model.ScopeAdmin, &apiOperation{
+				OperationID: "getWebhook",
+				Summary:     "Get a webhook, without its secret",
+				Parameters:  []*apiParameter{webhookID},
+				Responses: map[string]*apiResponse{
+					"200": {Description: "Webhook", Content: jsonContent(ref("Webhook"))},
+					"404": errorResponse,
+					"406": notAcceptableResponse,
+				},
+			}),
+			"put": requireScopeOperation(model.ScopeAdmin, &apiOperation{
+				OperationID: "updateWebhook",
+				Summary:     "Replace the URL, events and tags of a webhook, its secret is kept",
+				Parameters:  []*apiParameter{webhookID},
+				RequestBody: webhookBody,
+				Responses: map[string]*apiResponse{
+					"200": {Description: "Updated", Content: jsonContent(ref("Webhook"))},
+					"400": errorResponse,
+					"404": errorResponse,
+					"406": notAcceptableResponse,
+					"413": errorResponse,
+					"415": errorResponse,
+				},
+			}),
+			"delete": requireScopeOperation(model.ScopeAdmin, &apiOperation{
+				OperationID: "deleteWebhook",
+				Summary:     "Stop the deliveries to a webhook, its delivery log is kept",
+				Parameters:  []*apiParameter{webhookID},
+				Responses: map[string]*apiResponse{
+					"204": {Description: "Deleted"},
+					"404": errorResponse,
+				},
+			}),
+		},
+		"/{id}/deliveries": {
+			"get": requireScopeOperation(model.ScopeAdmin, &apiOperation{
+				OperationID: "listDeliveries",
+				Summary:     "The delivery log of a webhook, newest first",
+				Parameters: []*apiParameter{
+					webhookID,
+					{Name: "status", In: "query", Schema: &apiSchema{Type: "string", Enum: model.DeliveryStatuses}},
+				},
+				Responses: map[string]*apiResponse{
+					"200": {Description: "Deliveries", Content: deliveries},
+					"400": errorResponse,
+					"404": errorResponse,
+					"406": notAcceptableResponse,
+				},
+			}),
+		},
+		"/dead-letters": {
+			"get": requireScopeOperation(model.ScopeAdmin, &apiOperation{
+				OperationID: "listDeadLetters",
+				Summary:     "The deliveries of every webhook that gave up after their last attempt, newest first",
+				Responses: map[string]*apiResponse{
+					"200": {Description: "Dead deliveries", Content: deliveries},
+					"404": errorResponse,
+					"406": notAcceptableResponse,
+				},
+			}),
+		},
+		"/dead-letters/{deliveryID}/replay": {
+			"post": requireScopeOperation(model.ScopeAdmin, &apiOperation{
+				OperationID: "replayDelivery",
+				Summary:     "Attempt a dead delivery again, in the background and with as many retries as a new one",
+				Parameters: []*apiParameter{
+					{Name: "deliveryID", In: "path", Required: true, Schema: &apiSchema{Type: "string"}},
+				},
+				Responses: map[string]*apiResponse{
+					"202": {Description: "Pending", Content: jsonContent(ref("Delivery"))},
+					"404": errorResponse,
+					"406": notAcceptableResponse,
+					"409": {Description: "The delivery is not dead", Content: problemContent()},
+				},
+			}),
+		},
+	}
+}
+
+// streamOperations describes the stream of the articles created and the websocket of the tag stats
+func streamOperations() map[string]map[string]*apiOperation {
+	return map[string]map[string]*apiOperation{
+		"/stream": {
+			"get": requireScopeOperation(model.ScopeArticlesRead, &apiOperation{
+				OperationID: "streamArticles",
+				Summary:     "Follow the articles created as server-sent events",
+				Parameters: []*apiParameter{
+					{Name: "tags", In: "query", Description: "comma separated, articles with any of the tags, every article when empty",
+						Schema: &apiSchema{Type: "string"}},
+					{Name: "Last-Event-ID", In: "header", Description: "resumes the stream after this event, while it is in the history",
+						Schema: &apiSchema{Type: "string"}},
+				},
+				Responses: map[string]*apiResponse{
+					"200": {Description: "article.created events, their data is an Article, and heartbeat comments",
+						Content: map[string]*apiMediaType{"text/event-stream": {Schema: &apiSchema{Type: "string"}}}},
+					"404": errorResponse,
+				},
+			}),
+		},
+		"/stream/tags": {
+			"get": requireScopeOperation(model.ScopeArticlesRead, &apiOperation{
+				OperationID: "liveTags",
+				Summary:     "Follow the stats of tags on dates over a websocket",
+				Responses: map[string]*apiResponse{
+					"101": {Description: "Switched to the websocket protocol. " +
+						"Clients send {\"type\": \"subscribe\" or \"unsubscribe\", \"tag\", \"date\": \"YYYY-MM-DD\"} text messages. " +
+						"The server sends {\"type\": \"snapshot\", \"tag\", \"date\", \"tagsview\": Tagsview} on subscribe " +
+						"and whenever an article of the tag and date is created, {\"type\": \"unsubscribed\", \"tag\", \"date\"} " +
+						"and {\"type\": \"error\", \"tag\", \"date\", \"code\", \"message\"}, too_many_requests past the subscriptions " +
+						"allowed per connection. Clients must answer the pings."},
+					"400": errorResponse,
+					"403": errorResponse,
+					"404": errorResponse,
+				},
+			}),
+		},
+	}
+}
+
+func graphQLOperations() map[string]map[string]*apiOperation {
+	return map[string]map[string]*apiOperation{
+		"/graphql": {
+			"get":  requireScopeOperation(model.ScopeArticlesRead, graphQLOperation("graphQLGet")),
+			"post": requireScopeOperation(model.ScopeArticlesRead, graphQLOperation("graphQLPost")),
+		},
+	}
+}
+
+// envelopeOperations wraps the responses of the operations in the v2 envelope,
+// v2 also sends created articles back
+func envelopeOperations(paths map[string]map[string]*apiOperation) map[string]map[string]*apiOperation {
+	for _, methods := range paths {
+		for _, operation := range methods {
+			responses := map[string]*apiResponse{}
+			for status, response := range operation.Responses {
+				enveloped := &apiResponse{Description: response.Description}
+				for mediaType, content := range response.Content {
+					if enveloped.Content == nil {
+						enveloped.Content = map[string]*apiMediaType{}
+					}
+					// v2 lists problems in the errors of a plain json envelope
+					if mediaType == "application/problem+json" {
+						mediaType = "application/json"
+					}
+					enveloped.Content[mediaType] = &apiMediaType{Schema: envelopeSchema(content.Schema)}
+				}
+				responses[status] = enveloped
+			}
+			if operation.OperationID == "postArticle" {
+				responses["201"] = &apiResponse{Description: "Created", Content: jsonContent(envelopeSchema(ref("Article")))}
+			}
+			operation.Responses = responses
+		}
+	}
+	return paths
+}
+
+func envelopeSchema(data *apiSchema) *apiSchema {
+	if data.Ref == ref("Problem").Ref {
+		return ref("ErrorEnvelope")
+	}
+	return &apiSchema{
+		Type:     "object",
+		Required: []string{"data", "meta", "links"},
+		Properties: map[string]*apiSchema{
+			"data":  data,
+			"meta":  ref("EnvelopeMeta"),
+			"links": ref("EnvelopeLinks"),
+		},
+	}
+}
+
+var componentSchemas = map[string]*apiSchema{
+	"Health": {
+		Type: "object",
+		Properties: map[string]*apiSchema{
+			"status":     {Type: "string", Enum: []string{"success", "failure"}},
+			"components": {Type: "array", Items: ref("ComponentHealth")},
+		},
+	},
+	"ComponentHealth": {
+		Type:     "object",
+		Required: []string{"name", "status", "latency_ms"},
+		Properties: map[string]*apiSchema{
+			"name":       {Type: "string"},
+			"status":     {Type: "string", Enum: []string{"success", "failure"}},
+			"latency_ms": {Type: "number"},
+			"error":      {Type: "string"},
+		},
+	},
+	"NewArticle": {
+		Type:     "object",
+		Required: []string{"id", "date", "tags"},
+		Properties: map[string]*apiSchema{
+			"id":    {Type: "string", MinLength: intPtr(1)},
+			"title": {Type: "string"},
+			"date":  {Type: "string", Format: "date"},
+			"body":  {Type: "string"},
+			"tags":  {Type: "array", MinItems: intPtr(1), Items: &apiSchema{Type: "string"}},
+		},
+	},
+	"Article": {
+		Type: "object",
+		Properties: map[string]*apiSchema{
+			"id":         {Type: "string"},
+			"title":      {Type: "string"},
+			"date":       {Type: "string", Format: "date"},
+			"body":       {Type: "string"},
+			"tags":       {Type: "array", Items: &apiSchema{Type: "string"}},
+			"author":     {Type: "string", Description: "Subject of the caller who created the article"},
+			"created_at": {Type: "string", Format: "date-time"},
+			"updated_at": {Type: "string", Format: "date-time"},
+		},
+	},
+	"Tagsview": {
+		Type: "object",
+		Properties: map[string]*apiSchema{
+			"tag":          {Type: "string"},
+			"articles":     {Type: "array", Nullable: true, Items: &apiSchema{Type: "string"}, Description: "Ids of the 10 latest articles"},
+			"related_tags": {Type: "array", Nullable: true, Items: &apiSchema{Type: "string"}},
+			"count":        {Type: "integer"},
+		},
+	},
+	"Violation": {
+		Type:     "object",
+		Required: []string{"field", "rule", "message"},
+		Properties: map[string]*apiSchema{
+			"field":   {Type: "string", Description: "JSON pointer to the offending value"},
+			"rule":    {Type: "string"},
+			"message": {Type: "string"},
+		},
+	},
+	"Problem": {
+		Type:     "object",
+		Required: []string{"type", "title", "status", "code"},
+		Properties: map[string]*apiSchema{
+			"type":       {Type: "string", Format: "uri", Description: "urn:articleapi:problem: followed by the code"},
+			"title":      {Type: "string"},
+			"status":     {Type: "integer"},
+			"detail":     {Type: "string"},
+			"instance":   {Type: "string", Format: "uri-reference"},
+			"code":       {Type: "string", Enum: model.ErrorCodes()},
+			"request_id": {Type: "string", Description: "The X-Request-ID of the failed request"},
+			"violations": {Type: "array", Items: ref("Violation")},
+		},
+	},
+	"NewAPIKey": {
+		Type:     "object",
+		Required: []string{"scopes"},
+		Properties: map[string]*apiSchema{
+			"name":   {Type: "string"},
+			"scopes": {Type: "array", MinItems: intPtr(1), Items: &apiSchema{Type: "string", Enum: model.Scopes}},
+		},
+	},
+	"APIKey": {
+		Type:     "object",
+		Required: []string{"id", "scopes", "created_at"},
+		Properties: map[string]*apiSchema{
+			"id":         {Type: "string"},
+			"name":       {Type: "string"},
+			"key":        {Type: "string", Description: "The plain key, only sent when minted"},
+			"scopes":     {Type: "array", Items: &apiSchema{Type: "string", Enum: model.Scopes}},
+			"created_at": {Type: "string", Format: "date-time"},
+			"revoked_at": {Type: "string", Format: "date-time"},
+		},
+	},
+	"NewWebhook": {
+		Type:     "object",
+		Required: []string{"url"},
+		Properties: map[string]*apiSchema{
+			"url":    {Type: "string", Format: "uri", Description: "Receives the events as signed POST requests, localhost and private addresses are refused"},
+			"events": {Type: "array", Items: &apiSchema{Type: "string", Enum: model.Events}, Description: "Every event when empty"},
+			"tags":   {Type: "array", Items: &apiSchema{Type: "string", MinLength: intPtr(1)}, Description: "Articles with any of the tags, every article when empty"},
+		},
+	},
+	"Webhook": {
+		Type:     "object",
+		Required: []string{"id", "url", "created_at"},
+		Properties: map[string]*apiSchema{
+			"id":         {Type: "string"},
+			"url":        {Type: "string", Format: "uri"},
+			"events":     {Type: "array", Nullable: true, Items: &apiSchema{Type: "string", Enum: model.Events}},
+			"tags":       {Type: "array", Nullable: true, Items: &apiSchema{Type: "string"}},
+			"secret":     {Type: "string", Description: "Key of the HMAC-SHA256 signatures, only sent when created"},
+			"created_at": {Type: "string", Format: "date-time"},
+			"updated_at": {Type: "string", Format: "date-time"},
+		},
+	},
+	"Delivery": {
+		Type:     "object",
+		Required: []string{"id", "webhook_id", "event_id", "event_type", "status", "created_at"},
+		Properties: map[string]*apiSchema{
+			"id":               {Type: "string"},
+			"webhook_id":       {Type: "string"},
+			"event_id":         {Type: "string"},
+			"event_type":       {Type: "string", Enum: model.Events},
+			"status":           {Type: "string", Enum: model.DeliveryStatuses},
+			"attempts":         {Type: "array", Nullable: true, Items: ref("DeliveryAttempt")},
+			"next_attempt_at":  {Type: "string", Format: "date-time"},
+			"replayed_at":      {Type: "string", Format: "date-time"},
+			"owner":            {Type: "string", Description: "Instance attempting the in flight delivery"},
+			"lease_expires_at": {Type: "string", Format: "date-time"},
+			"created_at":       {Type: "string", Format: "date-time"},
+			"updated_at":       {Type: "string", Format: "date-time"},
+		},
+	},
+	"DeliveryAttempt": {
+		Type:     "object",
+		Required: []string{"at", "latency_ms"},
+		Properties: map[string]*apiSchema{
+			"at":          {Type: "string", Format: "date-time"},
+			"status_code": {Type: "integer", Description: "Absent when the receiver did not answer"},
+			"error":       {Type: "string"},
+			"latency_ms":  {Type: "number"},
+		},
+	},
+	"EnvelopeMeta": {
+		Type:     "object",
+		Required: []string{"version", "status"},
+		Properties: map[string]*apiSchema{
+			"version": {Type: "string"},
+			"status":  {Type: "integer"},
+		},
+	},
+	"EnvelopeLinks": {
+		Type:       "object",
+		Required:   []string{"self"},
+		Properties: map[string]*apiSchema{"self": {Type: "string"}},
+	},
+	"ErrorEnvelope": {
+		Type:     "object",
+		Required: []string{"errors", "meta", "links"},
+		Properties: map[string]*apiSchema{
+			"errors": {Type: "array", MinItems: intPtr(1), Items: ref("Problem")},
+			"meta":   ref("EnvelopeMeta"),
+			"links":  ref("EnvelopeLinks"),
+		},
+	},
+	"GraphQLRequest": {
+		Type:     "object",
+		Required: []string{"query"},
+		Properties: map[string]*apiSchema{
+			"query":         {Type: "string", MinLength: intPtr(1)},
+			"operationName": {Type: "string"},
+			"variables":     {Type: "object", Nullable: true},
+		},
+	},
+	"GraphQLResult": {
+		Type: "object",
+		Properties: map[string]*apiSchema{
+			"data":   {Type: "object", Nullable: true},
+			"errors": {Type: "array", Items: &apiSchema{Type: "object"}},
+		},
+	},
+}
+
+// serveOpenAPI serves the OpenAPI 3 document of the api
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, openAPISpec)
+}
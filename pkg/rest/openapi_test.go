@@ -0,0 +1,159 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	// routes depending on the settings are walked under each of them
+	for _, props := range []model.HTTPProperties{{}, {Auth: model.AuthProperties{Enabled: true}}} {
+		router := chi.NewRouter()
+		SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), props)
+		routes := walkRoutes(t, router)
+		for path, operations := range openAPISpec.Paths {
+			for method := range operations {
+				assert.True(t, routes[method+" "+path], "Documented operation %s %s is not routed, auth %v", method, path, props.Auth.Enabled)
+			}
+		}
+	}
+
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/api/openapi.json")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	var spec map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&spec))
+	assert.Equal(t, "3.0.3", spec["openapi"])
+}
+
+// walkRoutes checks every route of the router against the spec, as validateContract finds them,
+// and returns them as "method path"
+func walkRoutes(t *testing.T, router chi.Router) map[string]bool {
+	routes := map[string]bool{}
+	err := chi.Walk(router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		// chi v4 keeps the mount wildcards of subrouters in walked routes
+		route = strings.Replace(route, "/*/", "/", -1)
+		method = strings.ToLower(method)
+		key := method + " " + route
+		routes[key] = true
+		operation := openAPISpec.Paths[route][method]
+		if !assert.NotNil(t, operation, "Route %s is not documented", key) {
+			return nil
+		}
+
+		// the path parameters of the route are the documented ones
+		var routeParams, documentedParams []string
+		for _, segment := range strings.Split(route, "/") {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				routeParams = append(routeParams, segment[1:len(segment)-1])
+			}
+		}
+		for _, param := range operation.Parameters {
+			if param.In == "path" {
+				documentedParams = append(documentedParams, param.Name)
+			}
+		}
+		assert.ElementsMatch(t, routeParams, documentedParams, "Path parameters of %s", key)
+
+		// requests of the route are validated against its own operation
+		path := route
+		for _, param := range routeParams {
+			path = strings.Replace(path, "{"+param+"}", "value", 1)
+		}
+		found, _ := findOperation(httptest.NewRequest(method, path, nil))
+		assert.True(t, found == operation, "Requests of %s are validated against another operation", key)
+		return nil
+	})
+	assert.NoError(t, err)
+	return routes
+}
+
+func TestContractRequestViolations(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	testScenarios := []struct {
+		Description string
+		Method      string
+		URL         string
+		Body        string
+		StatusCode  int
+		Violations  []model.Violation
+	}{
+		{"Valid article", "POST", "/api/articles", `{"id":"11","date":"2019-10-02","tags":["a"]}`, 200, nil},
+		{"Wrong types", "POST", "/api/articles/", `{"id":9,"date":"2019-10-02","tags":"a"}`, 400, []model.Violation{
+			{Field: "/body/id", Rule: "type", Message: "must be of type string"},
+			{Field: "/body/tags", Rule: "type", Message: "must be of type array"},
+		}},
+		{"Missing and malformed fields", "POST", "/api/articles/", `{"date":"02-10-2019","tags":[1]}`, 400, []model.Violation{
+			{Field: "/body/id", Rule: "required", Message: "is required"},
+			{Field: "/body/date", Rule: "format", Message: "must be a date of format YYYY-MM-DD"},
+			{Field: "/body/tags/0", Rule: "type", Message: "must be of type string"},
+		}},
+		{"Empty tags", "POST", "/api/articles/", `{"id":"9","date":"2019-10-02","tags":[]}`, 400, []model.Violation{
+			{Field: "/body/tags", Rule: "minItems", Message: "must have at least 1 items"},
+		}},
+		{"Missing graphql query", "GET", "/api/graphql", "", 400, []model.Violation{
+			{Field: "/query/query", Rule: "required", Message: "is required"},
+		}},
+	}
+	for _, td := range testScenarios {
+		t.Run(fmt.Sprintf("%s - %s %s : %d", td.Description, td.Method, td.URL, td.StatusCode), func(t *testing.T) {
+			req, _ := http.NewRequest(td.Method, server.URL+td.URL, bytes.NewBufferString(td.Body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, td.StatusCode, resp.StatusCode)
+			if td.Violations != nil {
+				var body model.Problem
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, "invalid_input", body.Code)
+				assert.Equal(t, td.Violations, body.Violations)
+			}
+		})
+	}
+}
+
+func TestContractResponseValidation(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(validateContract(true))
+	router.Get("/api/articles/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if chi.URLParam(r, "id") == "bad" {
+			w.Write([]byte(`{"id":1,"tags":null}`))
+			return
+		}
+		w.Write([]byte(`{"id":"1","tags":["a"]}`))
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/articles/good")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/api/articles/bad")
+	assert.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+	var body model.Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, []model.Violation{
+		{Field: "/response/id", Rule: "type", Message: "must be of type string"},
+		{Field: "/response/tags", Rule: "type", Message: "must be of type array, got null"},
+	}, body.Violations)
+}
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/ratelimit"
+)
+
+// RateLimit takes a token from the bucket of the caller for the route pattern under prefix, see ratelimit.RouteLimit.
+// Callers are told apart by API key, token subject or client certificate, anonymous ones by IP address.
+func (d *delegate) RateLimit(props model.RateLimitProperties, prefix string, routePattern string) func(http.Handler) http.Handler {
+	bucket, limit := ratelimit.RouteLimit(props, prefix, routePattern)
+	return func(next http.Handler) http.Handler {
+		if !props.Enabled || limit.Requests <= 0 {
+			return next
+		}
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if d.take(w, r, ratelimit.ClientKey(principalFrom(r), clientIP(r)), bucket, limit) {
+				next.ServeHTTP(w, r)
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// LimitIP takes a token from the bucket of the IP address of the caller, shared by every /api route, with the props.PerIP limit.
+// It is mounted before Authenticate so that callers trying out API keys or tokens are limited as well,
+// RateLimit then limits each caller once authenticated.
+func (d *delegate) LimitIP(props model.RateLimitProperties) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !props.Enabled || props.PerIP.Requests <= 0 {
+			return next
+		}
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if d.take(w, r, "ip:"+clientIP(r), "/api", props.PerIP) {
+				next.ServeHTTP(w, r)
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// take takes a token from the bucket of key and tells whether the request may go on, a 429 is rendered otherwise
+func (d *delegate) take(w http.ResponseWriter, r *http.Request, key string, bucket string, limit model.RateLimit) bool {
+	result, err := d.limiter.Take(key+" "+bucket, limit)
+	if err != nil {
+		// an unavailable store must not take the api down with it
+		loggerOf(r).WithError(err).WithField("bucket", bucket).Errorln("Could not apply the rate limit")
+		return true
+	}
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", ceilSeconds(result.Reset))
+	if !result.Allowed {
+		w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+		renderErrorResponse(w, r, model.Errorf(model.ErrTooManyRequests, "Rate limit of %s exceeded, retry in %s seconds", bucket, ceilSeconds(result.RetryAfter)))
+		return false
+	}
+	return true
+}
+
+// clientIP is the host of the remote address of the request
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
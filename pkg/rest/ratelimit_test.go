@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/ratelimit"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	props := model.HTTPProperties{
+		Debug: true,
+		Auth:  model.AuthProperties{Enabled: true},
+		RateLimit: model.RateLimitProperties{
+			Enabled: true,
+			Default: model.RateLimit{Requests: 2, Period: 60},
+			Routes: map[string]model.RateLimit{
+				"/api/articles/":    {Requests: 1, Period: 60},
+				"/api/v2/articles/": {Requests: 3, Period: 60},
+			},
+		},
+	}
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{KeyStore: newMockAPIKeyStore(), TokenVerifier: mockTokenVerifier{}}), props)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	do := func(method string, url string, header string, value string) *http.Response {
+		req, _ := http.NewRequest(method, server.URL+url, bytes.NewBufferString(`{"id":"11","date":"2019-10-02","tags":["a"]}`))
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	// the default limit, shared by the api versions
+	resp := do("GET", "/api/v1/articles/1", "X-API-Key", "reader")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "1", resp.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", resp.Header.Get("RateLimit-Reset"))
+	assert.Equal(t, 200, do("GET", "/api/v2/articles/1", "X-API-Key", "reader").StatusCode)
+	resp = do("GET", "/api/articles/1", "X-API-Key", "reader")
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", resp.Header.Get("Retry-After"))
+	var limited model.Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&limited))
+	assert.Equal(t, "too_many_requests", limited.Code)
+
+	// other routes and callers have their own buckets
+	assert.Equal(t, 200, do("GET", "/api/tags/tagName/2019-10-02", "X-API-Key", "reader").StatusCode)
+	assert.Equal(t, 200, do("GET", "/api/articles/1", "Authorization", "Bearer reader").StatusCode)
+	assert.Equal(t, 401, do("GET", "/api/articles/1", "", "").StatusCode)
+	assert.Equal(t, 401, do("GET", "/api/articles/1", "", "").StatusCode)
+	assert.Equal(t, 429, do("GET", "/api/articles/1", "", "").StatusCode)
+
+	// route limits, a version specific one wins
+	assert.Equal(t, 200, do("POST", "/api/articles/", "X-API-Key", "writer").StatusCode)
+	assert.Equal(t, 429, do("POST", "/api/v1/articles/", "X-API-Key", "writer").StatusCode)
+	resp = do("POST", "/api/v2/articles/", "X-API-Key", "writer")
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, "3", resp.Header.Get("RateLimit-Limit"))
+
+	// the healthcheck and the contract are never limited by the route limits
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 200, do("GET", "/api/healthcheck", "", "").StatusCode)
+		assert.Equal(t, 200, do("GET", "/api/openapi.json", "", "").StatusCode)
+	}
+}
+
+func TestRateLimitPerIP(t *testing.T) {
+	props := model.HTTPProperties{
+		Auth: model.AuthProperties{Enabled: true},
+		RateLimit: model.RateLimitProperties{
+			Enabled: true,
+			Default: model.RateLimit{Requests: 10, Period: 60},
+			PerIP:   model.RateLimit{Requests: 3, Period: 60},
+		},
+	}
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{KeyStore: newMockAPIKeyStore(), TokenVerifier: mockTokenVerifier{}}), props)
+	do := func(url string, key string, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// invalid keys are limited before they are checked, along with the valid ones from the same address
+	assert.Equal(t, 401, do("/api/articles/1", "guess-1", "192.0.2.1:1234").Code)
+	assert.Equal(t, 401, do("/api/admin/keys", "guess-2", "192.0.2.1:1234").Code)
+	assert.Equal(t, 200, do("/api/v2/articles/1", "reader", "192.0.2.1:1234").Code)
+	limited := do("/api/articles/1", "guess-3", "192.0.2.1:1234")
+	assert.Equal(t, 429, limited.Code)
+	assert.Empty(t, limited.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, "20", limited.Header().Get("Retry-After"))
+	assert.Equal(t, 429, do("/api/healthcheck", "", "192.0.2.1:1234").Code)
+	assert.Equal(t, 200, do("/api/openapi.json", "", "192.0.2.1:1234").Code)
+
+	// other addresses have their own bucket, the route limits still apply per key
+	resp := do("/api/articles/1", "reader", "192.0.2.2:1234")
+	assert.Equal(t, 200, resp.Code)
+	assert.Equal(t, "10", resp.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "8", resp.Header().Get("RateLimit-Remaining"))
+}
+
+func TestRateLimitStoreFailure(t *testing.T) {
+	props := model.HTTPProperties{RateLimit: model.RateLimitProperties{Enabled: true, Default: model.RateLimit{Requests: 1}}}
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{Limiter: failingLimiter{}}), props)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(server.URL + "/api/articles/1")
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("RateLimit-Limit"))
+	}
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Take(key string, limit model.RateLimit) (*ratelimit.Result, error) {
+	return nil, errors.New("connection refused")
+}
@@ -1,89 +1,230 @@
 package rest
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"time"
 
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/auth"
 	"github.com/PadmavathiSundaram/ArticleAPI/pkg/client"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/health"
 	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/ratelimit"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/stream"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/webhook"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
+	log "github.com/sirupsen/logrus"
 )
 
-// SetupRoutes sets up Article service routes for the given router
-func SetupRoutes(r chi.Router, d Delegate) {
-	r.Use(recoverHandler, apiLogger)
+// SetupRoutes sets up the routes of the article service:
+//
+//	/livez, /readyz                   public probes
+//	/api/openapi.json                 the contract every /api request is validated against
+//	/api/v1/..., /api/...             articles, tags and graphql, /api is the alias of v1
+//	/api/v2/...                       articles and tags, responses wrapped in a model.Envelope
+//	/api/admin/keys, /api/webhooks/   API keys and webhooks, admin scope, only served when props.Auth is enabled
+//	/api/stream, /api/stream/tags     the articles created as server-sent events, the tag stats over a websocket
+//
+// Callers are identified by bearer token, API key or client certificate. Every /api route requires the scope
+// it declares when props.Auth is enabled, and is rate limited per IP address, then per client, when props.RateLimit is.
+func SetupRoutes(r chi.Router, d Delegate, props model.HTTPProperties) {
+	d = traceDelegate(d)
+	r.Use(requestID, traceRequest, recoverHandler, apiLogger, instrumentRequest, cors(props.CORS), compress(props.Compression))
+	// probes are public and never limited
+	r.With(negotiateContent).Get("/livez", d.Livez)
+	r.With(negotiateContent).Get("/readyz", d.Readyz)
 	r.Route("/api", func(r chi.Router) {
+		r.Use(authenticateClientCert(props.TLS))
+		r.Get("/openapi.json", serveOpenAPI)
+		r.Route("/v1", func(r chi.Router) {
+			r.Use(apiVersion(apiV1), deprecation(props, apiV1), d.LimitIP(props.RateLimit), d.Authenticate, limitRequest(props.Requests), validateContract(props.Debug))
+			resourceRoutes(r, d, props, "/api/v1")
+			graphQLRoutes(r, d, props, "/api/v1")
+		})
+		r.Route("/v2", func(r chi.Router) {
+			r.Use(apiVersion(apiV2), deprecation(props, apiV2), d.LimitIP(props.RateLimit), d.Authenticate, limitRequest(props.Requests), validateContract(props.Debug))
+			// graphql results carry their own data and errors envelope
+			resourceRoutes(r, d, props, "/api/v2")
+		})
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(requireAuth(props.Auth), d.LimitIP(props.RateLimit), d.Authenticate, d.RateLimit(props.RateLimit, "/api", "/admin/keys"), requireScope(props.Auth, model.ScopeAdmin),
+				limitRequest(props.Requests), validateContract(props.Debug), negotiateContent)
+			r.Get("/keys", d.ListAPIKeys)
+			r.Post("/keys", d.MintAPIKey)
+			r.Delete("/keys/{id}", d.RevokeAPIKey)
+		})
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Use(requireAuth(props.Auth), d.LimitIP(props.RateLimit), d.Authenticate, d.RateLimit(props.RateLimit, "/api", "/webhooks/"), requireScope(props.Auth, model.ScopeAdmin),
+				limitRequest(props.Requests), validateContract(props.Debug), negotiateContent)
+			r.Get("/", d.ListWebhooks)
+			r.Post("/", d.CreateWebhook)
+			r.Get("/dead-letters", d.ListDeadLetters)
+			r.Post("/dead-letters/{deliveryID}/replay", d.ReplayDelivery)
+			r.Get("/{id}", d.GetWebhook)
+			r.Put("/{id}", d.UpdateWebhook)
+			r.Delete("/{id}", d.DeleteWebhook)
+			r.Get("/{id}/deliveries", d.ListDeliveries)
+		})
+		// the stream lasts as long as the client, see LongLived
+		r.With(d.LimitIP(props.RateLimit), d.Authenticate, d.RateLimit(props.RateLimit, "/api", "/stream"), requireScope(props.Auth, model.ScopeArticlesRead),
+			validateContract(props.Debug)).Get("/stream", d.StreamArticles)
+		r.With(d.LimitIP(props.RateLimit), d.Authenticate, d.RateLimit(props.RateLimit, "/api", "/stream/tags"), requireScope(props.Auth, model.ScopeArticlesRead),
+			checkWebSocketOrigin(props.CORS), validateContract(props.Debug)).Get("/stream/tags", d.LiveTags)
+		r.Group(func(r chi.Router) {
+			r.Use(apiVersion(apiV1), deprecation(props, apiV1), d.LimitIP(props.RateLimit), d.Authenticate, limitRequest(props.Requests), validateContract(props.Debug))
+			resourceRoutes(r, d, props, "/api")
+			graphQLRoutes(r, d, props, "/api")
+		})
+	})
+}
+
+// resourceRoutes sets up the article and tag routes of an api version mounted at prefix
+func resourceRoutes(r chi.Router, d Delegate, props model.HTTPProperties, prefix string) {
+	read := requireScope(props.Auth, model.ScopeArticlesRead)
+	write := requireScope(props.Auth, model.ScopeArticlesWrite)
+	r.Group(func(r chi.Router) {
+		r.Use(negotiateContent)
 		r.Get("/healthcheck", d.HealthCheck)
-		r.Get("/tags/{tagName}/{date}", d.SearchTags)
+		r.With(d.RateLimit(props.RateLimit, prefix, "/tags/{tagName}/{date}"), read, cacheControl(props, prefix, "/tags/{tagName}/{date}")).
+			Get("/tags/{tagName}/{date}", d.SearchTags)
 		r.Route("/articles", func(r chi.Router) {
-			r.Post("/", d.PostArticle)
-			r.Get("/{id}", d.GetArticle)
+			r.With(d.RateLimit(props.RateLimit, prefix, "/articles/"), write).Post("/", d.PostArticle)
+			r.With(d.RateLimit(props.RateLimit, prefix, "/articles/{id}"), read, cacheControl(props, prefix, "/articles/{id}")).
+				Get("/{id}", d.GetArticle)
 		})
 	})
+}
 
+// graphQLRoutes sets up the graphql endpoint, its responses are always json
+func graphQLRoutes(r chi.Router, d Delegate, props model.HTTPProperties, prefix string) {
+	r.With(d.RateLimit(props.RateLimit, prefix, "/graphql"), requireScope(props.Auth, model.ScopeArticlesRead), graphQLLimits(props.GraphQL)).
+		Get("/graphql", d.GraphQL)
+	r.With(d.RateLimit(props.RateLimit, prefix, "/graphql"), requireScope(props.Auth, model.ScopeArticlesRead), graphQLLimits(props.GraphQL)).
+		Post("/graphql", d.GraphQL)
 }
 
 // maps from internal errors to response status codes
 // renderErrorResponse defaults to internal server error
 // if a specific error code is not defined.
 var errStatusMap = map[int]int{
-	model.ErrDuplicate:    http.StatusConflict,
-	model.ErrInvalidInput: http.StatusBadRequest,
-	model.ErrNotFound:     http.StatusNotFound,
+	model.ErrDuplicate:            http.StatusConflict,
+	model.ErrInvalidInput:         http.StatusBadRequest,
+	model.ErrNotFound:             http.StatusNotFound,
+	model.ErrNotAcceptable:        http.StatusNotAcceptable,
+	model.ErrUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	model.ErrUnauthorized:         http.StatusUnauthorized,
+	model.ErrForbidden:            http.StatusForbidden,
+	model.ErrTooManyRequests:      http.StatusTooManyRequests,
+	model.ErrTooLarge:             http.StatusRequestEntityTooLarge,
 }
 
-// renderErrorResponse handles http responses in the case of an error
+// renderErrorResponse handles http responses in the case of an error,
+// rendering them as RFC 7807 problem details correlated to the request ID.
+// Causes and unexpected errors are logged, never sent.
 func renderErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
-	responseStatus := http.StatusInternalServerError
-	// artricle service model.Errors store more specific response information
-	if specificError, ok := err.(*model.Error); ok {
-		if status, ok := errStatusMap[specificError.Code]; ok {
-			responseStatus = status
-		}
+	specificError, ok := err.(*model.Error)
+	if !ok {
+		specificError = model.ErrorEf(model.ErrUnknown, err, "An unexpected error occurred")
+	}
+	responseStatus, ok := errStatusMap[specificError.Code]
+	if !ok {
+		responseStatus = http.StatusInternalServerError
+	}
+	problem := &model.Problem{
+		Type:       model.ProblemTypePrefix + model.ErrorCode(specificError.Code),
+		Title:      http.StatusText(responseStatus),
+		Status:     responseStatus,
+		Detail:     specificError.Message,
+		Instance:   r.URL.RequestURI(),
+		Code:       model.ErrorCode(specificError.Code),
+		RequestID:  requestIDOf(r),
+		Violations: specificError.Violations,
 	}
 
-	render.Status(r, responseStatus)
-	render.JSON(w, r, err)
-}
-
-func validateRequestArticle(article model.Article) error {
-	if article.ArticleID == "" || article.Date == "" || len(article.Tags) <= 0 {
-		return model.Errorf(model.ErrInvalidInput, "Invalid Article data - id, date and atleast 1 tag is mandatory")
-
+	entry := loggerOf(r).WithFields(log.Fields{"status": responseStatus, "code": problem.Code})
+	if specificError.Cause != nil {
+		entry = entry.WithError(specificError.Cause)
 	}
-	layout := "2006-01-02"
-	if _, err := time.Parse(layout, article.Date); err != nil {
-		return model.ErrorEf(model.ErrInvalidInput, err, "Invalid date Format expected format YYYY-MM-DD 2016-09-22")
+	if responseStatus >= http.StatusInternalServerError {
+		entry.Errorln(r.Method, r.URL.Path, specificError.Message)
+	} else {
+		entry.Infoln(r.Method, r.URL.Path, specificError.Message)
 	}
-	return nil
 
+	// error responses must not be cached under the route's policy
+	if w.Header().Get("Cache-Control") != "" {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	render.Status(r, responseStatus)
+	respond(w, r, problem)
 }
 
-func readArticleBody(r *http.Request) (*model.Article, error) {
+func readArticleBody(r *http.Request, validator *model.ArticleValidator) (*model.Article, error) {
 	if r.Body == nil {
 		return nil, model.Errorf(model.ErrInvalidInput, "No request body")
 	}
+	c, err := requestCodec(r)
+	if err != nil {
+		return nil, err
+	}
 	articleData, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		return nil, model.ErrorEf(model.ErrInvalidInput, err, "Bad request body")
 	}
 	var article model.Article
-	if err = json.Unmarshal(articleData, &article); err != nil {
-		return nil, model.ErrorEf(model.ErrInvalidInput, err, "Invalid Article data")
+	if err = unmarshalBody(r, c, articleData, &article, "Invalid Article data"); err != nil {
+		return nil, err
 	}
 
-	if err = validateRequestArticle(article); err != nil {
+	if err = validator.Validate(article); err != nil {
+		// violations point into the request body
+		if invalid, ok := err.(*model.Error); ok {
+			for i := range invalid.Violations {
+				invalid.Violations[i].Field = "/body" + invalid.Violations[i].Field
+			}
+		}
 		return nil, err
 	}
 	return &article, nil
 }
 
-// NewArticleDelegate creates a new article service
-func NewArticleDelegate(articleStore client.ArticleStore) Delegate {
-	return &delegate{articleStore: articleStore}
+// DelegateOptions are the dependencies of the article delegate besides its store, each may be left nil.
+// Without a KeyStore API keys are refused, and bearer tokens without a TokenVerifier. A nil Limiter keeps
+// the rate limit buckets in process, a nil Validator only checks the mandatory fields and nil Probes
+// only check the article store. The webhook routes answer 404 without Webhooks, the streams without Streams.
+type DelegateOptions struct {
+	KeyStore      client.APIKeyStore
+	TokenVerifier auth.TokenVerifier
+	Limiter       ratelimit.Limiter
+	Validator     *model.ArticleValidator
+	Probes        *health.Registry
+	Webhooks      webhook.Service
+	Streams       *stream.Broker
+}
+
+// NewArticleDelegate creates a new article service of the articleStore
+func NewArticleDelegate(articleStore client.ArticleStore, options DelegateOptions) Delegate {
+	if options.Limiter == nil {
+		options.Limiter = ratelimit.NewMemoryLimiter()
+	}
+	if options.Validator == nil {
+		options.Validator = model.DefaultArticleValidator()
+	}
+	if options.Probes == nil {
+		options.Probes = health.NewRegistry(model.HealthProperties{})
+		options.Probes.Register("database", health.CheckerFunc(func(ctx context.Context) error {
+			if !articleStore.HealthCheck() {
+				return errors.New("unreachable")
+			}
+			return nil
+		}))
+	}
+	return &delegate{articleStore: articleStore, keyStore: options.KeyStore, tokenVerifier: options.TokenVerifier,
+		limiter: options.Limiter, validator: options.Validator, probes: options.Probes, webhooks: options.Webhooks,
+		streams: options.Streams}
 }
 
 // Delegate defines a rest api for interaction
@@ -92,10 +233,36 @@ type Delegate interface {
 	SearchTags(w http.ResponseWriter, r *http.Request)
 	PostArticle(w http.ResponseWriter, r *http.Request)
 	HealthCheck(w http.ResponseWriter, r *http.Request)
+	Livez(w http.ResponseWriter, r *http.Request)
+	Readyz(w http.ResponseWriter, r *http.Request)
+	GraphQL(w http.ResponseWriter, r *http.Request)
+	MintAPIKey(w http.ResponseWriter, r *http.Request)
+	ListAPIKeys(w http.ResponseWriter, r *http.Request)
+	RevokeAPIKey(w http.ResponseWriter, r *http.Request)
+	ListWebhooks(w http.ResponseWriter, r *http.Request)
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	GetWebhook(w http.ResponseWriter, r *http.Request)
+	UpdateWebhook(w http.ResponseWriter, r *http.Request)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request)
+	ListDeliveries(w http.ResponseWriter, r *http.Request)
+	ListDeadLetters(w http.ResponseWriter, r *http.Request)
+	ReplayDelivery(w http.ResponseWriter, r *http.Request)
+	StreamArticles(w http.ResponseWriter, r *http.Request)
+	LiveTags(w http.ResponseWriter, r *http.Request)
+	Authenticate(next http.Handler) http.Handler
+	RateLimit(props model.RateLimitProperties, prefix string, routePattern string) func(http.Handler) http.Handler
+	LimitIP(props model.RateLimitProperties) func(http.Handler) http.Handler
 }
 
 type delegate struct {
-	articleStore client.ArticleStore
+	articleStore  client.ArticleStore
+	keyStore      client.APIKeyStore
+	tokenVerifier auth.TokenVerifier
+	limiter       ratelimit.Limiter
+	validator     *model.ArticleValidator
+	probes        *health.Registry
+	webhooks      webhook.Service
+	streams       *stream.Broker
 }
 
 func (d *delegate) HealthCheck(w http.ResponseWriter, r *http.Request) {
@@ -103,11 +270,29 @@ func (d *delegate) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	if !d.articleStore.HealthCheck() {
 		render.Status(r, http.StatusInternalServerError)
 		health.Status = "failure"
-		render.JSON(w, r, health)
+		respond(w, r, health)
 		return
 	}
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, health)
+	respond(w, r, health)
+}
+
+// Livez handles the liveness probe, it succeeds as long as the app serves requests
+func (d *delegate) Livez(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusOK)
+	respond(w, r, d.probes.Live())
+}
+
+// Readyz handles the readiness probe, reporting the status and latency of every checked component.
+// It fails once shutdown starts.
+func (d *delegate) Readyz(w http.ResponseWriter, r *http.Request) {
+	health, ready := d.probes.Ready(r.Context())
+	if ready {
+		render.Status(r, http.StatusOK)
+	} else {
+		render.Status(r, http.StatusServiceUnavailable)
+	}
+	respond(w, r, health)
 }
 
 // GetArticle handles a GET request to retrieve a Article
@@ -117,17 +302,17 @@ func (d *delegate) GetArticle(w http.ResponseWriter, r *http.Request) {
 		renderErrorResponse(w, r, err)
 		return
 	}
-	article, err := d.articleStore.ReadArticleByID(articleID)
+	article, err := d.articleStore.ReadArticleByID(r.Context(), articleID)
 	if err != nil {
-		if "mongo: no documents in result" == err.Error() {
-			renderErrorResponse(w, r, model.ErrorEf(model.ErrNotFound, err, "Article Not Found"))
-			return
-		}
 		renderErrorResponse(w, r, err)
 		return
 	}
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, article)
+	var lastModified time.Time
+	if article.UpdatedAt != nil {
+		lastModified = *article.UpdatedAt
+	}
+	renderCacheable(w, r, article, lastModified)
 }
 
 func (d *delegate) SearchTags(w http.ResponseWriter, r *http.Request) {
@@ -136,12 +321,8 @@ func (d *delegate) SearchTags(w http.ResponseWriter, r *http.Request) {
 		renderErrorResponse(w, r, model.Errorf(model.ErrInvalidInput, "date and tagName are mandatory"))
 		return
 	}
-	tags, err := d.articleStore.SearchTagsByDate(date, tagName)
+	tags, err := d.articleStore.SearchTagsByDate(r.Context(), date, tagName)
 	if err != nil {
-		if "mongo: no documents in result" == err.Error() {
-			renderErrorResponse(w, r, model.ErrorEf(model.ErrNotFound, err, "Article Not Found"))
-			return
-		}
 		renderErrorResponse(w, r, err)
 		return
 	}
@@ -150,23 +331,32 @@ func (d *delegate) SearchTags(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, tags[0])
+	renderCacheable(w, r, tags[0], tags[0].LastModified)
 }
 
 // PostArticle handles a POST request to add a new Article
 func (d *delegate) PostArticle(w http.ResponseWriter, r *http.Request) {
-	article, err := readArticleBody(r)
+	article, err := readArticleBody(r, d.validator)
 	if err != nil {
 		renderErrorResponse(w, r, err)
 		return
 	}
+	// the author is whoever authenticated, never what the body claims
+	article.Author = ""
+	if principal := principalFrom(r); principal != nil {
+		article.Author = principal.Subject
+	}
 
-	err = d.articleStore.CreatArticle(article)
+	err = d.articleStore.CreatArticle(r.Context(), article)
 	if err != nil {
 		renderErrorResponse(w, r, err)
 		return
 	}
 	render.Status(r, http.StatusCreated)
+	// v1 has always answered with an empty body
+	if apiVersionOf(r) == apiV2 {
+		respond(w, r, article)
+	}
 }
 
 func readArticleParams(r *http.Request) (string, string) {
@@ -2,22 +2,34 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/health"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/metrics"
 	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/tracing"
 	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestSetupRoutes(t *testing.T) {
 	router := chi.NewRouter()
 	mockArticleStore := &mockArticleStore{status: true}
-	mockArticleDelegate := NewArticleDelegate(mockArticleStore)
-	SetupRoutes(router, mockArticleDelegate)
+	mockArticleDelegate := NewArticleDelegate(mockArticleStore, DelegateOptions{})
+	SetupRoutes(router, mockArticleDelegate, model.HTTPProperties{})
 	server := httptest.NewServer(router)
 	defer server.Close()
 
@@ -49,8 +61,8 @@ func TestSetupRoutesFailedHealthCheck(t *testing.T) {
 	router := chi.NewRouter()
 	mockArticleStore := &mockArticleStore{status: false}
 	mockArticleStore.HealthCheck()
-	mockArticleDelegate := NewArticleDelegate(mockArticleStore)
-	SetupRoutes(router, mockArticleDelegate)
+	mockArticleDelegate := NewArticleDelegate(mockArticleStore, DelegateOptions{})
+	SetupRoutes(router, mockArticleDelegate, model.HTTPProperties{})
 	server := httptest.NewServer(router)
 	defer server.Close()
 
@@ -58,12 +70,70 @@ func TestSetupRoutesFailedHealthCheck(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 500, resp.StatusCode, "Received unexpected response code: %d\n", resp.StatusCode)
 }
+func TestProbes(t *testing.T) {
+	probes := health.NewRegistry(model.HealthProperties{})
+	cacheErr := errors.New("connection refused")
+	probes.Register("database", health.CheckerFunc(func(ctx context.Context) error { return nil }))
+	probes.Register("ratelimit", health.CheckerFunc(func(ctx context.Context) error { return cacheErr }))
+
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{Probes: probes}),
+		model.HTTPProperties{Auth: model.AuthProperties{Enabled: true}})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	get := func(path string) (int, model.Health) {
+		resp, err := http.Get(server.URL + path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var health model.Health
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+		return resp.StatusCode, health
+	}
+
+	// probes are public even with auth enabled
+	status, live := get("/livez")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "success", live.Status)
+	assert.Empty(t, live.Components)
+
+	status, ready := get("/readyz")
+	assert.Equal(t, 503, status)
+	assert.Equal(t, "failure", ready.Status)
+	assert.Len(t, ready.Components, 2)
+	assert.Equal(t, "success", ready.Components[0].Status)
+	assert.Equal(t, "connection refused", ready.Components[1].Error)
+
+	cacheErr = nil
+	status, ready = get("/readyz")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "success", ready.Status)
+
+	probes.Drain()
+	status, ready = get("/readyz")
+	assert.Equal(t, 503, status)
+	assert.Equal(t, "shutdown", ready.Components[0].Name)
+	status, _ = get("/livez")
+	assert.Equal(t, 200, status)
+}
+
+func TestDefaultProbesCheckTheStore(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: false}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/readyz")
+	assert.NoError(t, err)
+	assert.Equal(t, 503, resp.StatusCode)
+}
+
 func TestSetupRoutesPostNilArticle(t *testing.T) {
 
 	router := chi.NewRouter()
 	mockArticleStore := &mockArticleStore{status: true}
-	mockArticleDelegate := NewArticleDelegate(mockArticleStore)
-	SetupRoutes(router, mockArticleDelegate)
+	mockArticleDelegate := NewArticleDelegate(mockArticleStore, DelegateOptions{})
+	SetupRoutes(router, mockArticleDelegate, model.HTTPProperties{})
 	server := httptest.NewServer(router)
 	defer server.Close()
 
@@ -76,8 +146,8 @@ func TestSetupRoutesPostValidArticle(t *testing.T) {
 
 	router := chi.NewRouter()
 	mockArticleStore := &mockArticleStore{status: true}
-	mockArticleDelegate := NewArticleDelegate(mockArticleStore)
-	SetupRoutes(router, mockArticleDelegate)
+	mockArticleDelegate := NewArticleDelegate(mockArticleStore, DelegateOptions{})
+	SetupRoutes(router, mockArticleDelegate, model.HTTPProperties{})
 	server := httptest.NewServer(router)
 	defer server.Close()
 
@@ -91,8 +161,8 @@ func TestSetupRoutesPostNotAnArticle(t *testing.T) {
 
 	router := chi.NewRouter()
 	mockArticleStore := &mockArticleStore{status: true}
-	mockArticleDelegate := NewArticleDelegate(mockArticleStore)
-	SetupRoutes(router, mockArticleDelegate)
+	mockArticleDelegate := NewArticleDelegate(mockArticleStore, DelegateOptions{})
+	SetupRoutes(router, mockArticleDelegate, model.HTTPProperties{})
 	server := httptest.NewServer(router)
 	defer server.Close()
 
@@ -117,8 +187,8 @@ func TestSetupRoutesPostInvalidArticle(t *testing.T) {
 
 	router := chi.NewRouter()
 	mockArticleStore := &mockArticleStore{status: false}
-	mockArticleDelegate := NewArticleDelegate(mockArticleStore)
-	SetupRoutes(router, mockArticleDelegate)
+	mockArticleDelegate := NewArticleDelegate(mockArticleStore, DelegateOptions{})
+	SetupRoutes(router, mockArticleDelegate, model.HTTPProperties{})
 	server := httptest.NewServer(router)
 	defer server.Close()
 
@@ -172,13 +242,263 @@ func TestSetupRoutesPostInvalidArticle(t *testing.T) {
 	}
 }
 
+func TestSetupRoutesPostArticleRules(t *testing.T) {
+	validator, err := model.NewArticleValidator(model.ArticleRules{IDPattern: "[0-9]+", MaxTags: 1, TagCharset: "a-z"})
+	assert.NoError(t, err)
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{Validator: validator}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/articles/", "application/json",
+		bytes.NewBufferString(`{"id":"a11","date":"2019-10-02","tags":["a","B"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	var invalid model.Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&invalid))
+	assert.Equal(t, "Invalid Article data", invalid.Detail)
+	assert.Equal(t, []model.Violation{
+		{Field: "/body/id", Rule: "pattern", Message: "must match [0-9]+"},
+		{Field: "/body/tags", Rule: "maxItems", Message: "must hold at most 1 tags"},
+		{Field: "/body/tags/1", Rule: "charset", Message: "must only use the characters [a-z]"},
+	}, invalid.Violations)
+}
+
+func TestProblemDetails(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(requestID, recoverHandler, negotiateContent)
+	router.Get("/cause", func(w http.ResponseWriter, r *http.Request) {
+		renderErrorResponse(w, r, model.ErrorEf(model.ErrUnknown, errors.New("mongodb://user:secret@db"), "Could not read the article"))
+	})
+	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("secret state")
+	})
+	router.Get("/missing", func(w http.ResponseWriter, r *http.Request) {
+		renderErrorResponse(w, r, model.Errorf(model.ErrNotFound, "Not found error"))
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	testScenarios := []struct {
+		Description string
+		URL         string
+		Accept      string
+		RequestID   string
+		ContentType string
+		Expected    string
+	}{
+		{"Cause only logged", "/cause", "", "req-1", "application/problem+json; charset=utf-8",
+			`{"type":"urn:articleapi:problem:internal_error","title":"Internal Server Error","status":500,` +
+				`"detail":"Could not read the article","instance":"/cause","code":"internal_error","request_id":"req-1"}`},
+		{"Panic", "/panic", "", "req-2", "application/problem+json; charset=utf-8",
+			`{"type":"urn:articleapi:problem:internal_error","title":"Internal Server Error","status":500,` +
+				`"detail":"An unexpected error occurred","instance":"/panic","code":"internal_error","request_id":"req-2"}`},
+		{"Query in instance", "/missing?q=1", "", "req-3", "application/problem+json; charset=utf-8",
+			`{"type":"urn:articleapi:problem:not_found","title":"Not Found","status":404,` +
+				`"detail":"Not found error","instance":"/missing?q=1","code":"not_found","request_id":"req-3"}`},
+		{"XML", "/missing", "application/xml", "req-4", "application/problem+xml; charset=utf-8",
+			`<problem xmlns="urn:ietf:rfc:7807"><type>urn:articleapi:problem:not_found</type><title>Not Found</title><status>404</status>` +
+				`<detail>Not found error</detail><instance>/missing</instance><code>not_found</code><request_id>req-4</request_id>`},
+	}
+	for _, td := range testScenarios {
+		t.Run(td.Description, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+td.URL, nil)
+			req.Header.Set("Accept", td.Accept)
+			req.Header.Set("X-Request-ID", td.RequestID)
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, td.ContentType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, td.RequestID, resp.Header.Get("X-Request-ID"))
+			body, _ := ioutil.ReadAll(resp.Body)
+			assert.NotContains(t, string(body), "secret")
+			if td.Accept == "" {
+				assert.JSONEq(t, td.Expected, string(body))
+			} else {
+				assert.Contains(t, string(body), td.Expected)
+			}
+		})
+	}
+
+	// missing or unsafe request IDs are replaced by generated ones
+	for _, id := range []string{"", "two words", strings.Repeat("a", 129)} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/missing", nil)
+		req.Header.Set("X-Request-ID", id)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		var problem model.Problem
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		assert.Len(t, problem.RequestID, 32)
+		assert.Equal(t, problem.RequestID, resp.Header.Get("X-Request-ID"))
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	log.SetFormatter(&log.JSONFormatter{})
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFormatter(&log.TextFormatter{})
+	}()
+
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v2/articles/1?api_key=secret", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "Request served", entry["msg"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "/api/v2/articles/{id}", entry["route"])
+	assert.Equal(t, float64(200), entry["status"])
+	assert.Equal(t, float64(len(body)), entry["bytes"])
+	assert.Equal(t, "127.0.0.1", entry["client"])
+	assert.Contains(t, entry, "latency_ms")
+	assert.NotContains(t, logs.String(), "secret")
+}
+
+func TestTraceRequest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracing")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "traces.json")
+	stop, err := tracing.Setup(model.TracingProperties{Exporter: "file", File: file})
+	assert.NoError(t, err)
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	log.SetFormatter(&log.JSONFormatter{})
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFormatter(&log.TextFormatter{})
+	}()
+
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v2/articles/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	stop()
+
+	spans, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(spans), `"Name":"GET /api/v2/articles/{id}"`)
+	assert.Contains(t, string(spans), `"Name":"Delegate.GetArticle"`)
+	assert.Contains(t, string(spans), traceID)
+	assert.Contains(t, logs.String(), `"trace_id":"`+traceID+`"`)
+}
+
+func TestRequestMetrics(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	series := []string{
+		`articleapi_http_requests_total{method="GET",route="/api/v2/tags/{tagName}/{date}",status="200"}`,
+		`articleapi_http_requests_total{method="GET",route="/api/v2/tags/{tagName}/{date}",status="404"}`,
+		`articleapi_http_request_duration_seconds_count{method="GET",route="/api/v2/tags/{tagName}/{date}",status="404"}`,
+		// unknown paths are labelled by the mount they fell under, or as unmatched
+		`articleapi_http_requests_total{method="GET",route="/api/v2/*",status="404"}`,
+		`articleapi_http_requests_total{method="GET",route="unmatched",status="404"}`,
+	}
+	before := scrapeSeries(series)
+	for _, url := range []string{"/api/v2/tags/tagName/2019-10-02", "/api/v2/tags/other/2019-10-02", "/api/v2/tags/other/2019-10-02", "/api/v2/nowhere/1", "/nowhere"} {
+		_, err := http.Get(server.URL + url)
+		assert.NoError(t, err)
+	}
+	after := scrapeSeries(series)
+	for i, delta := range []float64{1, 2, 2, 1, 1} {
+		assert.Equal(t, delta, after[i]-before[i], series[i])
+	}
+}
+
+// scrapeSeries returns the current value of each series, 0 for the ones not yet exposed
+func scrapeSeries(series []string) []float64 {
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	values := make([]float64, len(series))
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		for i, name := range series {
+			if strings.HasPrefix(line, name+" ") {
+				values[i], _ = strconv.ParseFloat(strings.TrimPrefix(line, name+" "), 64)
+			}
+		}
+	}
+	return values
+}
+
+func TestConditionalGetArticle(t *testing.T) {
+	router := chi.NewRouter()
+	mockArticleDelegate := NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{})
+	SetupRoutes(router, mockArticleDelegate, model.HTTPProperties{
+		CacheControl: map[string]string{"/api/articles/{id}": "public, max-age=60"},
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/articles/1")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	etag := resp.Header.Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "public, max-age=60", resp.Header.Get("Cache-Control"))
+	assert.Equal(t, mockUpdatedAt.Format(http.TimeFormat), resp.Header.Get("Last-Modified"))
+
+	testScenarios := []struct {
+		Description string
+		Header      string
+		Value       string
+		StatusCode  int
+	}{
+		{"Matching etag", "If-None-Match", etag, 304},
+		{"Matching weak etag in list", "If-None-Match", `"other", W/` + etag, 304},
+		{"Wildcard etag", "If-None-Match", "*", 304},
+		{"Stale etag", "If-None-Match", `"stale"`, 200},
+		{"Not modified since", "If-Modified-Since", mockUpdatedAt.Add(time.Hour).Format(http.TimeFormat), 304},
+		{"Modified since", "If-Modified-Since", mockUpdatedAt.Add(-time.Hour).Format(http.TimeFormat), 200},
+		{"Invalid date", "If-Modified-Since", "yesterday", 200},
+	}
+	for _, td := range testScenarios {
+		t.Run(fmt.Sprintf("%s - %s : %v", td.Description, td.Header, td.StatusCode), func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/articles/1", nil)
+			req.Header.Set(td.Header, td.Value)
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, td.StatusCode, resp.StatusCode)
+			assert.Equal(t, etag, resp.Header.Get("ETag"))
+		})
+	}
+
+	resp, err = http.Get(server.URL + "/api/articles/error")
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+}
+
+var mockUpdatedAt = time.Date(2019, 10, 2, 10, 30, 0, 0, time.UTC)
+
 type mockArticleStore struct{ status bool }
 
 func (store *mockArticleStore) HealthCheck() bool {
 	return store.status
 }
 
-func (store *mockArticleStore) CreatArticle(article *model.Article) error {
+func (store *mockArticleStore) CreatArticle(ctx context.Context, article *model.Article) error {
 	if article.ArticleID == "11" {
 		return nil
 	}
@@ -186,14 +506,28 @@ func (store *mockArticleStore) CreatArticle(article *model.Article) error {
 	return model.Errorf(model.ErrDuplicate, "Duplicate key error")
 }
 
-func (store *mockArticleStore) ReadArticleByID(articleID string) (*model.Article, error) {
+func (store *mockArticleStore) ReadArticleByID(ctx context.Context, articleID string) (*model.Article, error) {
 	if articleID == "1" {
-		return &model.Article{}, nil
+		return &model.Article{ArticleID: articleID, UpdatedAt: &mockUpdatedAt}, nil
 	}
 	return nil, model.Errorf(model.ErrNotFound, "Not found error")
 }
 
-func (store *mockArticleStore) SearchTagsByDate(date string, tag string) ([]*model.Tagsview, error) {
+func (store *mockArticleStore) ReadArticlesByIDs(ctx context.Context, articleIDs []string) ([]*model.Article, error) {
+	var articles []*model.Article
+	for _, id := range articleIDs {
+		if article, err := store.ReadArticleByID(ctx, id); err == nil {
+			articles = append(articles, article)
+		}
+	}
+	return articles, nil
+}
+
+func (store *mockArticleStore) ListTags(ctx context.Context, date string) ([]*model.Tagsview, error) {
+	return []*model.Tagsview{{Tag: "tagName", Count: 2}, {Tag: "other", Count: 1}}, nil
+}
+
+func (store *mockArticleStore) SearchTagsByDate(ctx context.Context, date string, tag string) ([]*model.Tagsview, error) {
 	var tags []*model.Tagsview
 	if tag == "tagName" {
 		tags = append(tags, &model.Tagsview{})
@@ -0,0 +1,87 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/metrics"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// LongLived reports whether the request opens a stream or a websocket, which lasts as long as its client
+// and must not be cut off by request timeouts
+func LongLived(r *http.Request) bool {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	return path == "/api/stream" || strings.HasPrefix(path, "/api/stream/")
+}
+
+// StreamArticles handles a GET request following the articles created with any of the ?tags=, comma separated,
+// as server-sent events. A client reconnecting with the Last-Event-ID header first gets the articles it missed,
+// as long as they are still in the history. Idle streams get a heartbeat comment, and clients falling behind
+// are disconnected so that they resume from the history.
+func (d *delegate) StreamArticles(w http.ResponseWriter, r *http.Request) {
+	if d.streams == nil {
+		renderErrorResponse(w, r, model.Errorf(model.ErrNotFound, "The stream is not enabled"))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		renderErrorResponse(w, r, model.Errorf(model.ErrUnknown, "Responses cannot be streamed"))
+		return
+	}
+	var tags []string
+	for _, tag := range strings.Split(r.URL.Query().Get("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	sub, resumed := d.streams.Subscribe(tags, lastEventID)
+	defer sub.Close()
+	metrics.ObserveStreamClient("sse", 1)
+	defer metrics.ObserveStreamClient("sse", -1)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// proxies such as nginx would buffer the events otherwise
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if lastEventID != "" && !resumed {
+		fmt.Fprint(w, ": the stream could not be resumed, the articles created since the last event are not in the history\n\n")
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(d.streams.Heartbeat())
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-sub.Events():
+			if !ok {
+				if sub.Lagging() {
+					metrics.ObserveStreamLagging("sse")
+					loggerOf(r).Warnln("Disconnected a stream client falling behind the articles created")
+				}
+				return
+			}
+			data, err := json.Marshal(event.Article)
+			if err != nil {
+				loggerOf(r).WithError(err).Errorln("Could not encode article", event.Article.ArticleID)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, model.EventArticleCreated, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/stream"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+// readEvent reads the lines of the next event or comment of a stream, up to the blank line ending it
+func readEvent(t *testing.T, reader *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("the stream ended: %v", err)
+		}
+		if line == "\n" {
+			return lines
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+}
+
+func TestStreamArticles(t *testing.T) {
+	broker := stream.NewBroker(model.StreamProperties{Heartbeat: 1, History: 10})
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{Streams: broker}), model.HTTPProperties{Debug: true})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	follow := func(tags string, lastEventID string) (*http.Response, *bufio.Reader) {
+		req, _ := http.NewRequest("GET", server.URL+"/api/stream?tags="+tags, nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp, bufio.NewReader(resp.Body)
+	}
+	publish := func(id string, tags ...string) {
+		broker.Publish(context.Background(), model.EventArticleCreated, &model.Article{ArticleID: id, Tags: toPointers(tags)})
+	}
+
+	resp, events := follow("health,%20Fitness", "")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+	publish("1", "health")
+	publish("2", "science")
+	publish("3", "fitness")
+
+	first := readEvent(t, events)
+	assert.Len(t, first, 3)
+	assert.True(t, strings.HasPrefix(first[0], "id: "))
+	assert.Equal(t, "event: article.created", first[1])
+	assert.Equal(t, "1", articleOf(t, first))
+	assert.Equal(t, "3", articleOf(t, readEvent(t, events)))
+	assert.Equal(t, []string{": heartbeat"}, readEvent(t, events), "idle streams get a heartbeat")
+	resp.Body.Close()
+
+	// the client reconnects after the first event
+	resp, events = follow("health,fitness", strings.TrimPrefix(first[0], "id: "))
+	assert.Equal(t, "3", articleOf(t, readEvent(t, events)))
+	resp.Body.Close()
+
+	resp, events = follow("", "of another run")
+	assert.Equal(t, []string{": the stream could not be resumed, the articles created since the last event are not in the history"}, readEvent(t, events))
+	publish("4", "science")
+	assert.Equal(t, "4", articleOf(t, readEvent(t, events)))
+	resp.Body.Close()
+}
+
+func TestStreamDisabled(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/stream")
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestLongLived(t *testing.T) {
+	for path, longLived := range map[string]bool{"/api/stream": true, "/api/stream/": true, "/api/stream/tags": true, "/api/articles/1": false} {
+		assert.Equal(t, longLived, LongLived(httptest.NewRequest("GET", path, nil)), path)
+	}
+}
+
+// articleOf returns the id of the article of an event
+func articleOf(t *testing.T, event []string) string {
+	var article model.Article
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(event[len(event)-1], "data: ")), &article))
+	return article.ArticleID
+}
+
+func toPointers(values []string) []*string {
+	pointers := make([]*string, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	return pointers
+}
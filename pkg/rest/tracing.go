@@ -0,0 +1,147 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/tracing"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/propagation"
+	"go.opentelemetry.io/otel/api/standard"
+	"go.opentelemetry.io/otel/api/trace"
+	"google.golang.org/grpc/codes"
+)
+
+// traceRequest starts a server span for every request, continuing the W3C trace context
+// of the incoming headers. The span is named after the chi route pattern once routed.
+func traceRequest(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagation.ExtractHTTP(r.Context(), global.Propagators(), r.Header)
+		ctx, span := tracing.Tracer().Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(standard.HTTPMethodKey.String(r.Method), standard.HTTPTargetKey.String(r.URL.Path)))
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(standard.HTTPRouteKey.String(rctx.RoutePattern()))
+		}
+		span.SetAttributes(standard.HTTPStatusCodeKey.Int(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Internal, http.StatusText(status))
+		}
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// traceDelegate wraps every handler of the Delegate in a span of its own,
+// the middlewares are left to the request span
+func traceDelegate(d Delegate) Delegate {
+	return &tracedDelegate{Delegate: d}
+}
+
+type tracedDelegate struct {
+	Delegate
+}
+
+func (d *tracedDelegate) GetArticle(w http.ResponseWriter, r *http.Request) {
+	traced("GetArticle", d.Delegate.GetArticle)(w, r)
+}
+
+func (d *tracedDelegate) SearchTags(w http.ResponseWriter, r *http.Request) {
+	traced("SearchTags", d.Delegate.SearchTags)(w, r)
+}
+
+func (d *tracedDelegate) PostArticle(w http.ResponseWriter, r *http.Request) {
+	traced("PostArticle", d.Delegate.PostArticle)(w, r)
+}
+
+func (d *tracedDelegate) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	traced("HealthCheck", d.Delegate.HealthCheck)(w, r)
+}
+
+func (d *tracedDelegate) Livez(w http.ResponseWriter, r *http.Request) {
+	traced("Livez", d.Delegate.Livez)(w, r)
+}
+
+func (d *tracedDelegate) Readyz(w http.ResponseWriter, r *http.Request) {
+	traced("Readyz", d.Delegate.Readyz)(w, r)
+}
+
+func (d *tracedDelegate) GraphQL(w http.ResponseWriter, r *http.Request) {
+	traced("GraphQL", d.Delegate.GraphQL)(w, r)
+}
+
+func (d *tracedDelegate) MintAPIKey(w http.ResponseWriter, r *http.Request) {
+	traced("MintAPIKey", d.Delegate.MintAPIKey)(w, r)
+}
+
+func (d *tracedDelegate) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	traced("ListAPIKeys", d.Delegate.ListAPIKeys)(w, r)
+}
+
+func (d *tracedDelegate) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	traced("RevokeAPIKey", d.Delegate.RevokeAPIKey)(w, r)
+}
+
+func (d *tracedDelegate) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	traced("ListWebhooks", d.Delegate.ListWebhooks)(w, r)
+}
+
+func (d *tracedDelegate) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	traced("CreateWebhook", d.Delegate.CreateWebhook)(w, r)
+}
+
+func (d *tracedDelegate) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	traced("GetWebhook", d.Delegate.GetWebhook)(w, r)
+}
+
+func (d *tracedDelegate) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	traced("UpdateWebhook", d.Delegate.UpdateWebhook)(w, r)
+}
+
+func (d *tracedDelegate) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	traced("DeleteWebhook", d.Delegate.DeleteWebhook)(w, r)
+}
+
+func (d *tracedDelegate) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	traced("ListDeliveries", d.Delegate.ListDeliveries)(w, r)
+}
+
+func (d *tracedDelegate) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	traced("ListDeadLetters", d.Delegate.ListDeadLetters)(w, r)
+}
+
+func (d *tracedDelegate) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	traced("ReplayDelivery", d.Delegate.ReplayDelivery)(w, r)
+}
+
+func (d *tracedDelegate) StreamArticles(w http.ResponseWriter, r *http.Request) {
+	traced("StreamArticles", d.Delegate.StreamArticles)(w, r)
+}
+
+func (d *tracedDelegate) LiveTags(w http.ResponseWriter, r *http.Request) {
+	traced("LiveTags", d.Delegate.LiveTags)(w, r)
+}
+
+// traced runs the handler under a "Delegate.<name>" span
+func traced(name string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.Start(r.Context(), "Delegate."+name)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		handler(ww, r.WithContext(ctx))
+		if status := ww.Status(); status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Internal, http.StatusText(status))
+		}
+	}
+}
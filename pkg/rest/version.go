@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/render"
+)
+
+const (
+	apiV1 = "v1"
+	apiV2 = "v2"
+)
+
+type apiVersionCtxKey struct{}
+
+// apiVersion records the api version serving the request, it decides the response shape
+func apiVersion(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), apiVersionCtxKey{}, version)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func apiVersionOf(r *http.Request) string {
+	version, _ := r.Context().Value(apiVersionCtxKey{}).(string)
+	return version
+}
+
+// deprecation announces the configured retirement of the api version as per RFC 9745 and RFC 8594
+func deprecation(props model.HTTPProperties, version string) func(http.Handler) http.Handler {
+	policy := props.Deprecations[version]
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !policy.Deprecation.IsZero() {
+				w.Header().Set("Deprecation", "@"+strconv.FormatInt(policy.Deprecation.Unix(), 10))
+			}
+			if !policy.Sunset.IsZero() {
+				w.Header().Set("Sunset", policy.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if policy.Link != "" {
+				w.Header().Add("Link", "<"+policy.Link+`>; rel="deprecation"`)
+			}
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// envelope wraps v in a model.Envelope for v2 requests, problems are listed under Errors.
+// Other versions get v as is.
+func envelope(r *http.Request, v interface{}) interface{} {
+	if apiVersionOf(r) != apiV2 {
+		return v
+	}
+	status, ok := r.Context().Value(render.StatusCtxKey).(int)
+	if !ok {
+		status = http.StatusOK
+	}
+	env := &model.Envelope{
+		Meta:  model.EnvelopeMeta{Version: apiV2, Status: status},
+		Links: model.EnvelopeLinks{Self: r.URL.RequestURI()},
+	}
+	if problem, ok := v.(*model.Problem); ok {
+		env.Errors = []*model.Problem{problem}
+		return env
+	}
+	env.Data = v
+	return env
+}
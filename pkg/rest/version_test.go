@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionedRoutes(t *testing.T) {
+	router := chi.NewRouter()
+	// debug mode checks every response against the versioned contract
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{Debug: true})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	testScenarios := []struct {
+		Description string
+		Method      string
+		URL         string
+		Body        string
+		StatusCode  int
+		Expected    string
+	}{
+		{"v1 article", "GET", "/api/v1/articles/1", "", 200,
+			`{"id":"1","updated_at":"2019-10-02T10:30:00Z"}`},
+		{"alias article", "GET", "/api/articles/1", "", 200,
+			`{"id":"1","updated_at":"2019-10-02T10:30:00Z"}`},
+		{"v2 article", "GET", "/api/v2/articles/1", "", 200,
+			`{"data":{"id":"1","updated_at":"2019-10-02T10:30:00Z"},` +
+				`"meta":{"version":"v2","status":200},"links":{"self":"/api/v2/articles/1"}}`},
+		{"v2 missing article", "GET", "/api/v2/articles/error", "", 404,
+			`{"meta":{"version":"v2","status":404},"links":{"self":"/api/v2/articles/error"},` +
+				`"errors":[{"type":"urn:articleapi:problem:not_found","title":"Not Found","status":404,"detail":"Not found error",` +
+				`"instance":"/api/v2/articles/error","code":"not_found","request_id":"req-1"}]}`},
+		{"v2 health", "GET", "/api/v2/healthcheck", "", 200,
+			`{"data":{"status":"success"},"meta":{"version":"v2","status":200},"links":{"self":"/api/v2/healthcheck"}}`},
+		{"v1 post", "POST", "/api/v1/articles/", `{"id":"11","date":"2019-10-02","tags":["a"]}`, 200, ``},
+		{"v2 post", "POST", "/api/v2/articles/", `{"id":"11","date":"2019-10-02","tags":["a"]}`, 201,
+			`{"data":{"id":"11","date":"2019-10-02","tags":["a"]},` +
+				`"meta":{"version":"v2","status":201},"links":{"self":"/api/v2/articles/"}}`},
+		{"v2 invalid post", "POST", "/api/v2/articles/", `{"id":"11","date":"2019-10-02"}`, 400,
+			`{"meta":{"version":"v2","status":400},"links":{"self":"/api/v2/articles/"},` +
+				`"errors":[{"type":"urn:articleapi:problem:invalid_input","title":"Bad Request","status":400,` +
+				`"detail":"Request does not match the API contract","instance":"/api/v2/articles/","code":"invalid_input","request_id":"req-1",` +
+				`"violations":[{"field":"/body/tags","rule":"required","message":"is required"}]}]}`},
+	}
+	for _, td := range testScenarios {
+		t.Run(fmt.Sprintf("%s - %s %s : %d", td.Description, td.Method, td.URL, td.StatusCode), func(t *testing.T) {
+			req, _ := http.NewRequest(td.Method, server.URL+td.URL, bytes.NewBufferString(td.Body))
+			req.Header.Set("X-Request-ID", "req-1")
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, td.StatusCode, resp.StatusCode)
+			body, _ := ioutil.ReadAll(resp.Body)
+			if td.Expected == "" {
+				assert.Empty(t, body)
+				return
+			}
+			assert.JSONEq(t, td.Expected, string(body))
+		})
+	}
+}
+
+func TestV2EnvelopeXML(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), model.HTTPProperties{})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/api/v2/healthcheck", nil)
+	req.Header.Set("Accept", "application/xml")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Contains(t, string(body), `<response><health><status>success</status></health>`+
+		`<meta><version>v2</version><status>200</status></meta><links><self>/api/v2/healthcheck</self></links></response>`)
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	props := model.HTTPProperties{Deprecations: map[string]model.DeprecationProperties{
+		"v1": {
+			Deprecation: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			Sunset:      time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+			Link:        "https://example.com/migrate-to-v2",
+		},
+	}}
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{}), props)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	for _, url := range []string{"/api/v1/healthcheck", "/api/healthcheck", "/api/v1/graphql?query={tags{name}}"} {
+		resp, err := http.Get(server.URL + url)
+		assert.NoError(t, err)
+		assert.Equal(t, "@1767225600", resp.Header.Get("Deprecation"), url)
+		assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", resp.Header.Get("Sunset"), url)
+		assert.Equal(t, `<https://example.com/migrate-to-v2>; rel="deprecation"`, resp.Header.Get("Link"), url)
+	}
+
+	resp, err := http.Get(server.URL + "/api/v2/healthcheck")
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+	assert.Empty(t, resp.Header.Get("Sunset"))
+
+	var decoded map[string]json.RawMessage
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	assert.Contains(t, decoded, "data")
+}
@@ -0,0 +1,220 @@
+package rest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/webhook"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// webhookRequest is the body of a request creating or replacing a webhook
+type webhookRequest struct {
+	URL    string   `json:"url" xml:"url" yaml:"url"`
+	Events []string `json:"events" xml:"events>event" yaml:"events"`
+	Tags   []string `json:"tags" xml:"tags>tag" yaml:"tags"`
+}
+
+// webhooksEnabled renders a 404 when the webhooks are disabled
+func (d *delegate) webhooksEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if d.webhooks == nil {
+		renderErrorResponse(w, r, model.Errorf(model.ErrNotFound, "Webhooks are not enabled"))
+		return false
+	}
+	return true
+}
+
+// ListWebhooks handles a GET request listing the webhooks, those receiving the events of the articles
+// tagged ?tag= when given. Secrets are never listed.
+func (d *delegate) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if !d.webhooksEnabled(w, r) {
+		return
+	}
+	webhooks, err := d.webhooks.ListWebhooks(r.Context())
+	if err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	tag := r.URL.Query().Get("tag")
+	listed := []*model.Webhook{}
+	for _, webhook := range webhooks {
+		if tag == "" || webhook.Subscribes(tag) {
+			webhook.Secret = ""
+			listed = append(listed, webhook)
+		}
+	}
+	render.Status(r, http.StatusOK)
+	respond(w, r, listed)
+}
+
+// CreateWebhook handles a POST request subscribing a URL to the article events,
+// the signing secret is only ever sent in this response
+func (d *delegate) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if !d.webhooksEnabled(w, r) {
+		return
+	}
+	webhook, err := readWebhookBody(r)
+	if err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	if err := d.webhooks.CreateWebhook(r.Context(), webhook); err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	respond(w, r, webhook)
+}
+
+// GetWebhook handles a GET request to retrieve a webhook
+func (d *delegate) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	if !d.webhooksEnabled(w, r) {
+		return
+	}
+	webhook, err := d.webhooks.ReadWebhook(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	webhook.Secret = ""
+	render.Status(r, http.StatusOK)
+	respond(w, r, webhook)
+}
+
+// UpdateWebhook handles a PUT request replacing the URL, events and tags of a webhook, the secret is kept
+func (d *delegate) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	if !d.webhooksEnabled(w, r) {
+		return
+	}
+	webhook, err := readWebhookBody(r)
+	if err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	webhook.ID = chi.URLParam(r, "id")
+	if err := d.webhooks.UpdateWebhook(r.Context(), webhook); err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	webhook.Secret = ""
+	render.Status(r, http.StatusOK)
+	respond(w, r, webhook)
+}
+
+// DeleteWebhook handles a DELETE request stopping the deliveries to a webhook, its delivery log is kept
+func (d *delegate) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if !d.webhooksEnabled(w, r) {
+		return
+	}
+	if err := d.webhooks.DeleteWebhook(r.Context(), chi.URLParam(r, "id")); err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles a GET request listing the delivery log of a webhook, newest first,
+// of the deliveries in the ?status= when given
+func (d *delegate) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	if !d.webhooksEnabled(w, r) {
+		return
+	}
+	webhookID := chi.URLParam(r, "id")
+	if _, err := d.webhooks.ReadWebhook(r.Context(), webhookID); err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	filter := map[string]string{"WebhookID": webhookID}
+	if status := r.URL.Query().Get("status"); status != "" {
+		if !contains(model.DeliveryStatuses, status) {
+			renderErrorResponse(w, r, model.Violationsf([]model.Violation{{Field: "/query/status", Rule: "enum",
+				Message: "must be one of " + strings.Join(model.DeliveryStatuses, ", ")}}, "Invalid delivery status"))
+			return
+		}
+		filter["Status"] = status
+	}
+	d.renderDeliveries(w, r, filter)
+}
+
+// ListDeadLetters handles a GET request listing the dead deliveries of every webhook, newest first
+func (d *delegate) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if !d.webhooksEnabled(w, r) {
+		return
+	}
+	d.renderDeliveries(w, r, map[string]string{"Status": model.DeliveryDead})
+}
+
+func (d *delegate) renderDeliveries(w http.ResponseWriter, r *http.Request, filter map[string]string) {
+	deliveries, err := d.webhooks.ListDeliveries(r.Context(), filter)
+	if err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*model.Delivery{}
+	}
+	render.Status(r, http.StatusOK)
+	respond(w, r, deliveries)
+}
+
+// ReplayDelivery handles a POST request attempting a dead delivery again, it is accepted
+// as pending and attempted in the background
+func (d *delegate) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	if !d.webhooksEnabled(w, r) {
+		return
+	}
+	delivery, err := d.webhooks.Replay(r.Context(), chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		renderErrorResponse(w, r, err)
+		return
+	}
+	render.Status(r, http.StatusAccepted)
+	respond(w, r, delivery)
+}
+
+func readWebhookBody(r *http.Request) (*model.Webhook, error) {
+	c, err := requestCodec(r)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, model.ErrorEf(model.ErrInvalidInput, err, "Bad request body")
+	}
+	var req webhookRequest
+	if err = unmarshalBody(r, c, data, &req, "Invalid webhook data"); err != nil {
+		return nil, err
+	}
+	if violations := validateWebhook(req); len(violations) > 0 {
+		return nil, model.Violationsf(violations, "Invalid webhook data")
+	}
+	return &model.Webhook{URL: req.URL, Events: req.Events, Tags: req.Tags}, nil
+}
+
+func validateWebhook(req webhookRequest) []model.Violation {
+	var violations []model.Violation
+	if u, err := url.Parse(req.URL); req.URL == "" {
+		violations = append(violations, model.Violation{Field: "/body/url", Rule: "required", Message: "is required"})
+	} else if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		violations = append(violations, model.Violation{Field: "/body/url", Rule: "format", Message: "must be an absolute http or https url"})
+	} else if !webhook.PublicHost(u.Hostname()) {
+		violations = append(violations, model.Violation{Field: "/body/url", Rule: "format", Message: "must not be a loopback, link-local or private address"})
+	}
+	for i, event := range req.Events {
+		if !contains(model.Events, event) {
+			violations = append(violations, model.Violation{
+				Field: "/body/events/" + strconv.Itoa(i), Rule: "enum", Message: "must be one of " + strings.Join(model.Events, ", ")})
+		}
+	}
+	for i, tag := range req.Tags {
+		if strings.TrimSpace(tag) == "" {
+			violations = append(violations, model.Violation{Field: "/body/tags/" + strconv.Itoa(i), Rule: "minLength", Message: "must not be blank"})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,225 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookRoutes(t *testing.T) {
+	webhooks := newMockWebhookService()
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{KeyStore: newMockAPIKeyStore(), Webhooks: webhooks}),
+		model.HTTPProperties{Auth: model.AuthProperties{Enabled: true}, Debug: true})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	do := func(method string, path string, body string) (*http.Response, map[string]interface{}) {
+		req, _ := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "admin")
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var decoded interface{}
+		json.NewDecoder(resp.Body).Decode(&decoded)
+		if object, ok := decoded.(map[string]interface{}); ok {
+			return resp, object
+		}
+		return resp, map[string]interface{}{"items": decoded}
+	}
+
+	resp, body := do("POST", "/api/webhooks/", `{"url":"https://indexer.example.com/hook","events":["article.updated"]}`)
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "/body/events/0", "rule": "enum", "message": "must be one of article.created"},
+	}, body["violations"])
+	resp, body = do("POST", "/api/webhooks/", `{"url":"ftp://indexer"}`)
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "/body/url", "rule": "format", "message": "must be an absolute http or https url"},
+	}, body["violations"])
+	for _, url := range []string{"http://localhost:8080/hook", "http://127.0.0.1/hook", "http://[::1]/hook", "http://169.254.169.254/latest", "https://10.0.0.12/hook"} {
+		resp, body = do("POST", "/api/webhooks/", `{"url":"`+url+`"}`)
+		assert.Equal(t, 400, resp.StatusCode, url)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"field": "/body/url", "rule": "format", "message": "must not be a loopback, link-local or private address"},
+		}, body["violations"], url)
+	}
+
+	resp, body = do("POST", "/api/webhooks/", `{"url":"https://indexer.example.com/hook","tags":["health"]}`)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, "w1", body["id"])
+	assert.Equal(t, "secret-w1", body["secret"])
+	resp, _ = do("POST", "/api/webhooks/", `{"url":"https://newsletter.example.com/hook","events":["article.created"],"tags":["science"]}`)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	resp, body = do("GET", "/api/webhooks/?tag=health", "")
+	assert.Equal(t, 200, resp.StatusCode)
+	items := body["items"].([]interface{})
+	assert.Len(t, items, 1)
+	assert.Equal(t, "w1", items[0].(map[string]interface{})["id"])
+	assert.Nil(t, items[0].(map[string]interface{})["secret"], "secrets are never listed")
+
+	resp, body = do("PUT", "/api/webhooks/w1", `{"url":"https://indexer.example.com/v2/hook"}`)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "https://indexer.example.com/v2/hook", body["url"])
+	assert.Nil(t, body["secret"])
+	resp, body = do("GET", "/api/webhooks/w1", "")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "https://indexer.example.com/v2/hook", body["url"])
+
+	resp, body = do("GET", "/api/webhooks/w1/deliveries?status=dead", "")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Len(t, body["items"], 1)
+	resp, _ = do("GET", "/api/webhooks/w1/deliveries?status=lost", "")
+	assert.Equal(t, 400, resp.StatusCode)
+	resp, body = do("GET", "/api/webhooks/dead-letters", "")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Len(t, body["items"], 1)
+
+	resp, body = do("POST", "/api/webhooks/dead-letters/d1/replay", "")
+	assert.Equal(t, 202, resp.StatusCode)
+	assert.Equal(t, model.DeliveryPending, body["status"])
+	resp, body = do("POST", "/api/webhooks/dead-letters/d1/replay", "")
+	assert.Equal(t, 409, resp.StatusCode)
+	assert.Equal(t, "duplicate", body["code"])
+
+	resp, _ = do("DELETE", "/api/webhooks/w1", "")
+	assert.Equal(t, 204, resp.StatusCode)
+	resp, _ = do("GET", "/api/webhooks/w1", "")
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestWebhooksDisabled(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRoutes(router, NewArticleDelegate(&mockArticleStore{status: true}, DelegateOptions{KeyStore: newMockAPIKeyStore()}),
+		model.HTTPProperties{Auth: model.AuthProperties{Enabled: true}, Debug: true})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/api/webhooks/", nil)
+	req.Header.Set("X-API-Key", "admin")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+type mockWebhookService struct {
+	webhooks   map[string]*model.Webhook
+	deliveries map[string]*model.Delivery
+}
+
+// newMockWebhookService knows a dead delivery of the first webhook created
+func newMockWebhookService() *mockWebhookService {
+	return &mockWebhookService{
+		webhooks: map[string]*model.Webhook{},
+		deliveries: map[string]*model.Delivery{"d1": {ID: "d1", WebhookID: "w1", EventID: "e1", EventType: model.EventArticleCreated,
+			Status: model.DeliveryDead, Attempts: []model.DeliveryAttempt{{At: mockUpdatedAt, StatusCode: 500}}, CreatedAt: mockUpdatedAt}},
+	}
+}
+
+func (s *mockWebhookService) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	webhook.ID = "w" + strconv.Itoa(len(s.webhooks)+1)
+	webhook.Secret = "secret-" + webhook.ID
+	webhook.CreatedAt, webhook.UpdatedAt = mockUpdatedAt, mockUpdatedAt
+	stored := *webhook
+	s.webhooks[webhook.ID] = &stored
+	return nil
+}
+
+func (s *mockWebhookService) ReadWebhook(ctx context.Context, webhookID string) (*model.Webhook, error) {
+	webhook, ok := s.webhooks[webhookID]
+	if !ok {
+		return nil, model.Errorf(model.ErrNotFound, "Webhook %s not found", webhookID)
+	}
+	read := *webhook
+	return &read, nil
+}
+
+func (s *mockWebhookService) ListWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	var webhooks []*model.Webhook
+	for i := 1; i <= len(s.webhooks)+1; i++ {
+		if webhook, ok := s.webhooks[fmt.Sprintf("w%d", i)]; ok {
+			read := *webhook
+			webhooks = append(webhooks, &read)
+		}
+	}
+	return webhooks, nil
+}
+
+func (s *mockWebhookService) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	current, err := s.ReadWebhook(ctx, webhook.ID)
+	if err != nil {
+		return err
+	}
+	webhook.Secret, webhook.CreatedAt, webhook.UpdatedAt = current.Secret, current.CreatedAt, mockUpdatedAt
+	stored := *webhook
+	s.webhooks[webhook.ID] = &stored
+	return nil
+}
+
+func (s *mockWebhookService) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if _, err := s.ReadWebhook(ctx, webhookID); err != nil {
+		return err
+	}
+	delete(s.webhooks, webhookID)
+	return nil
+}
+
+func (s *mockWebhookService) SaveDelivery(ctx context.Context, delivery *model.Delivery) error {
+	s.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (s *mockWebhookService) UpdateDelivery(ctx context.Context, delivery *model.Delivery) error {
+	s.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (s *mockWebhookService) UpdateDeliveryFrom(ctx context.Context, delivery *model.Delivery, status string, owner string) error {
+	if stored, ok := s.deliveries[delivery.ID]; !ok || stored.Status != status || (owner != "" && stored.Owner != owner) {
+		return model.Errorf(model.ErrDuplicate, "Delivery %s is not %s anymore", delivery.ID, status)
+	}
+	s.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (s *mockWebhookService) ReadDelivery(ctx context.Context, deliveryID string) (*model.Delivery, error) {
+	delivery, ok := s.deliveries[deliveryID]
+	if !ok {
+		return nil, model.Errorf(model.ErrNotFound, "Delivery %s not found", deliveryID)
+	}
+	return delivery, nil
+}
+
+func (s *mockWebhookService) ListDeliveries(ctx context.Context, filter map[string]string) ([]*model.Delivery, error) {
+	var deliveries []*model.Delivery
+	for _, delivery := range s.deliveries {
+		if (filter["WebhookID"] == "" || filter["WebhookID"] == delivery.WebhookID) && (filter["Status"] == "" || filter["Status"] == delivery.Status) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (s *mockWebhookService) Replay(ctx context.Context, deliveryID string) (*model.Delivery, error) {
+	delivery, err := s.ReadDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != model.DeliveryDead {
+		return nil, model.Errorf(model.ErrDuplicate, "Delivery %s is %s, only dead deliveries can be replayed", deliveryID, delivery.Status)
+	}
+	delivery.Status = model.DeliveryPending
+	return delivery, nil
+}
@@ -0,0 +1,615 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: article.proto
+
+package articlepb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Article struct {
+	Id    string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title string `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	// YYYY-MM-DD
+	Date                 string               `protobuf:"bytes,3,opt,name=date,proto3" json:"date,omitempty"`
+	Body                 string               `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`
+	Tags                 []string             `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	CreatedAt            *timestamp.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt            *timestamp.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Author               string               `protobuf:"bytes,8,opt,name=author,proto3" json:"author,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Article) Reset()         { *m = Article{} }
+func (m *Article) String() string { return proto.CompactTextString(m) }
+func (*Article) ProtoMessage()    {}
+func (*Article) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{0}
+}
+
+func (m *Article) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Article.Unmarshal(m, b)
+}
+func (m *Article) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Article.Marshal(b, m, deterministic)
+}
+func (m *Article) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Article.Merge(m, src)
+}
+func (m *Article) XXX_Size() int {
+	return xxx_messageInfo_Article.Size(m)
+}
+func (m *Article) XXX_DiscardUnknown() {
+	xxx_messageInfo_Article.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Article proto.InternalMessageInfo
+
+func (m *Article) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Article) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+func (m *Article) GetDate() string {
+	if m != nil {
+		return m.Date
+	}
+	return ""
+}
+
+func (m *Article) GetBody() string {
+	if m != nil {
+		return m.Body
+	}
+	return ""
+}
+
+func (m *Article) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Article) GetCreatedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return nil
+}
+
+func (m *Article) GetUpdatedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.UpdatedAt
+	}
+	return nil
+}
+
+func (m *Article) GetAuthor() string {
+	if m != nil {
+		return m.Author
+	}
+	return ""
+}
+
+type Tagsview struct {
+	Tag                  string   `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Articles             []string `protobuf:"bytes,2,rep,name=articles,proto3" json:"articles,omitempty"`
+	RelatedTags          []string `protobuf:"bytes,3,rep,name=related_tags,json=relatedTags,proto3" json:"related_tags,omitempty"`
+	Count                int32    `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Tagsview) Reset()         { *m = Tagsview{} }
+func (m *Tagsview) String() string { return proto.CompactTextString(m) }
+func (*Tagsview) ProtoMessage()    {}
+func (*Tagsview) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{1}
+}
+
+func (m *Tagsview) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Tagsview.Unmarshal(m, b)
+}
+func (m *Tagsview) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Tagsview.Marshal(b, m, deterministic)
+}
+func (m *Tagsview) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Tagsview.Merge(m, src)
+}
+func (m *Tagsview) XXX_Size() int {
+	return xxx_messageInfo_Tagsview.Size(m)
+}
+func (m *Tagsview) XXX_DiscardUnknown() {
+	xxx_messageInfo_Tagsview.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Tagsview proto.InternalMessageInfo
+
+func (m *Tagsview) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+func (m *Tagsview) GetArticles() []string {
+	if m != nil {
+		return m.Articles
+	}
+	return nil
+}
+
+func (m *Tagsview) GetRelatedTags() []string {
+	if m != nil {
+		return m.RelatedTags
+	}
+	return nil
+}
+
+func (m *Tagsview) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type GetArticleRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetArticleRequest) Reset()         { *m = GetArticleRequest{} }
+func (m *GetArticleRequest) String() string { return proto.CompactTextString(m) }
+func (*GetArticleRequest) ProtoMessage()    {}
+func (*GetArticleRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{2}
+}
+
+func (m *GetArticleRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetArticleRequest.Unmarshal(m, b)
+}
+func (m *GetArticleRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetArticleRequest.Marshal(b, m, deterministic)
+}
+func (m *GetArticleRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetArticleRequest.Merge(m, src)
+}
+func (m *GetArticleRequest) XXX_Size() int {
+	return xxx_messageInfo_GetArticleRequest.Size(m)
+}
+func (m *GetArticleRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetArticleRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetArticleRequest proto.InternalMessageInfo
+
+func (m *GetArticleRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type CreateArticleRequest struct {
+	Article              *Article `protobuf:"bytes,1,opt,name=article,proto3" json:"article,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CreateArticleRequest) Reset()         { *m = CreateArticleRequest{} }
+func (m *CreateArticleRequest) String() string { return proto.CompactTextString(m) }
+func (*CreateArticleRequest) ProtoMessage()    {}
+func (*CreateArticleRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{3}
+}
+
+func (m *CreateArticleRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateArticleRequest.Unmarshal(m, b)
+}
+func (m *CreateArticleRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateArticleRequest.Marshal(b, m, deterministic)
+}
+func (m *CreateArticleRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateArticleRequest.Merge(m, src)
+}
+func (m *CreateArticleRequest) XXX_Size() int {
+	return xxx_messageInfo_CreateArticleRequest.Size(m)
+}
+func (m *CreateArticleRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateArticleRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateArticleRequest proto.InternalMessageInfo
+
+func (m *CreateArticleRequest) GetArticle() *Article {
+	if m != nil {
+		return m.Article
+	}
+	return nil
+}
+
+type SearchTagsRequest struct {
+	Tag string `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	// YYYY-MM-DD
+	Date                 string   `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SearchTagsRequest) Reset()         { *m = SearchTagsRequest{} }
+func (m *SearchTagsRequest) String() string { return proto.CompactTextString(m) }
+func (*SearchTagsRequest) ProtoMessage()    {}
+func (*SearchTagsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{4}
+}
+
+func (m *SearchTagsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchTagsRequest.Unmarshal(m, b)
+}
+func (m *SearchTagsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchTagsRequest.Marshal(b, m, deterministic)
+}
+func (m *SearchTagsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchTagsRequest.Merge(m, src)
+}
+func (m *SearchTagsRequest) XXX_Size() int {
+	return xxx_messageInfo_SearchTagsRequest.Size(m)
+}
+func (m *SearchTagsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchTagsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchTagsRequest proto.InternalMessageInfo
+
+func (m *SearchTagsRequest) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+func (m *SearchTagsRequest) GetDate() string {
+	if m != nil {
+		return m.Date
+	}
+	return ""
+}
+
+type HealthRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HealthRequest) Reset()         { *m = HealthRequest{} }
+func (m *HealthRequest) String() string { return proto.CompactTextString(m) }
+func (*HealthRequest) ProtoMessage()    {}
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{5}
+}
+
+func (m *HealthRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthRequest.Unmarshal(m, b)
+}
+func (m *HealthRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthRequest.Marshal(b, m, deterministic)
+}
+func (m *HealthRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthRequest.Merge(m, src)
+}
+func (m *HealthRequest) XXX_Size() int {
+	return xxx_messageInfo_HealthRequest.Size(m)
+}
+func (m *HealthRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthRequest proto.InternalMessageInfo
+
+type HealthResponse struct {
+	Status               string   `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HealthResponse) Reset()         { *m = HealthResponse{} }
+func (m *HealthResponse) String() string { return proto.CompactTextString(m) }
+func (*HealthResponse) ProtoMessage()    {}
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{6}
+}
+
+func (m *HealthResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthResponse.Unmarshal(m, b)
+}
+func (m *HealthResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthResponse.Marshal(b, m, deterministic)
+}
+func (m *HealthResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthResponse.Merge(m, src)
+}
+func (m *HealthResponse) XXX_Size() int {
+	return xxx_messageInfo_HealthResponse.Size(m)
+}
+func (m *HealthResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthResponse proto.InternalMessageInfo
+
+func (m *HealthResponse) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*Article)(nil), "articleapi.v1.Article")
+	proto.RegisterType((*Tagsview)(nil), "articleapi.v1.Tagsview")
+	proto.RegisterType((*GetArticleRequest)(nil), "articleapi.v1.GetArticleRequest")
+	proto.RegisterType((*CreateArticleRequest)(nil), "articleapi.v1.CreateArticleRequest")
+	proto.RegisterType((*SearchTagsRequest)(nil), "articleapi.v1.SearchTagsRequest")
+	proto.RegisterType((*HealthRequest)(nil), "articleapi.v1.HealthRequest")
+	proto.RegisterType((*HealthResponse)(nil), "articleapi.v1.HealthResponse")
+}
+
+func init() {
+	proto.RegisterFile("article.proto", fileDescriptor_5c593d380f9840a2)
+}
+
+var fileDescriptor_5c593d380f9840a2 = []byte{
+	// 445 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x52, 0x4d, 0x6f, 0xd3, 0x40,
+	0x10, 0x55, 0x9c, 0xe6, 0x6b, 0x42, 0x02, 0x5d, 0x55, 0x65, 0x65, 0x81, 0x08, 0xee, 0x25, 0x27,
+	0x17, 0xc2, 0xa9, 0xc7, 0x80, 0xa0, 0x3d, 0x70, 0x4a, 0x7b, 0xe2, 0x82, 0x36, 0xf6, 0xe0, 0xac,
+	0xe4, 0x76, 0x5d, 0xef, 0x38, 0x88, 0x3f, 0xc6, 0x7f, 0xe3, 0x86, 0xf6, 0xab, 0x51, 0x1c, 0x22,
+	0x6e, 0x33, 0x6f, 0xde, 0x8c, 0xde, 0x7b, 0xbb, 0x30, 0x11, 0x35, 0xc9, 0xac, 0xc4, 0xb4, 0xaa,
+	0x15, 0x29, 0x16, 0x5a, 0x51, 0xc9, 0x74, 0xfb, 0x3e, 0x7e, 0x53, 0x28, 0x55, 0x94, 0x78, 0x69,
+	0x87, 0xeb, 0xe6, 0xc7, 0x25, 0xc9, 0x7b, 0xd4, 0x24, 0xee, 0x2b, 0xc7, 0x4f, 0xfe, 0x74, 0x60,
+	0xb0, 0x74, 0x2b, 0x6c, 0x0a, 0x91, 0xcc, 0x79, 0x67, 0xd6, 0x99, 0x8f, 0x56, 0x91, 0xcc, 0xd9,
+	0x19, 0xf4, 0x48, 0x52, 0x89, 0x3c, 0xb2, 0x90, 0x6b, 0x18, 0x83, 0x93, 0x5c, 0x10, 0xf2, 0xae,
+	0x05, 0x6d, 0x6d, 0xb0, 0xb5, 0xca, 0x7f, 0xf1, 0x13, 0x87, 0x99, 0xda, 0x60, 0x24, 0x0a, 0xcd,
+	0x7b, 0xb3, 0xae, 0xc1, 0x4c, 0xcd, 0xae, 0x00, 0xb2, 0x1a, 0x05, 0x61, 0xfe, 0x5d, 0x10, 0xef,
+	0xcf, 0x3a, 0xf3, 0xf1, 0x22, 0x4e, 0x9d, 0xc6, 0x34, 0x68, 0x4c, 0xef, 0x82, 0xc6, 0xd5, 0xc8,
+	0xb3, 0x97, 0x64, 0x56, 0x9b, 0x2a, 0x0f, 0xab, 0x83, 0xff, 0xaf, 0x7a, 0xf6, 0x92, 0xd8, 0x39,
+	0xf4, 0x45, 0x43, 0x1b, 0x55, 0xf3, 0xa1, 0xd5, 0xe7, 0xbb, 0xe4, 0x11, 0x86, 0x77, 0xa2, 0xd0,
+	0x5b, 0x89, 0x3f, 0xd9, 0x0b, 0xe8, 0x92, 0x28, 0xbc, 0x79, 0x53, 0xb2, 0x18, 0x86, 0x3e, 0x4b,
+	0xcd, 0x23, 0xeb, 0xe1, 0xa9, 0x67, 0x6f, 0xe1, 0x59, 0x8d, 0xa5, 0x15, 0x63, 0x3d, 0x76, 0xed,
+	0x7c, 0xec, 0x31, 0x73, 0xd4, 0x84, 0x97, 0xa9, 0xe6, 0x81, 0x6c, 0x26, 0xbd, 0x95, 0x6b, 0x92,
+	0x0b, 0x38, 0xbd, 0x46, 0xf2, 0x81, 0xaf, 0xf0, 0xb1, 0x41, 0x4d, 0xed, 0xdc, 0x93, 0x1b, 0x38,
+	0xfb, 0x64, 0x7d, 0xb7, 0x78, 0xef, 0x60, 0xe0, 0x15, 0x58, 0xf2, 0x78, 0x71, 0x9e, 0xee, 0xbd,
+	0x76, 0x1a, 0xf8, 0x81, 0x96, 0x5c, 0xc1, 0xe9, 0x2d, 0x8a, 0x3a, 0xdb, 0x18, 0x49, 0xe1, 0xcc,
+	0xa1, 0xd5, 0xf0, 0xa4, 0xd1, 0xee, 0x49, 0x93, 0xe7, 0x30, 0xb9, 0x41, 0x51, 0xd2, 0xc6, 0xaf,
+	0x25, 0x73, 0x98, 0x06, 0x40, 0x57, 0xea, 0x41, 0xa3, 0xc9, 0x55, 0x93, 0xa0, 0x46, 0xfb, 0x5b,
+	0xbe, 0x5b, 0xfc, 0x8e, 0x60, 0xea, 0xa5, 0xdc, 0x62, 0xbd, 0x95, 0x19, 0xb2, 0x2f, 0x00, 0x3b,
+	0xdf, 0x6c, 0xd6, 0xd2, 0x7d, 0x10, 0x49, 0x7c, 0xc4, 0x19, 0xfb, 0x0a, 0x93, 0xbd, 0x68, 0xd8,
+	0x45, 0x8b, 0xf8, 0xaf, 0xe0, 0x8e, 0x5e, 0xbb, 0x06, 0xd8, 0xc5, 0x73, 0xa0, 0xea, 0x20, 0xb9,
+	0xf8, 0x65, 0x8b, 0xf1, 0xf4, 0x7b, 0x3e, 0x43, 0xdf, 0x65, 0xc3, 0x5e, 0xb5, 0x28, 0x7b, 0x19,
+	0xc6, 0xaf, 0x8f, 0x4c, 0x5d, 0xa0, 0x1f, 0xc7, 0xdf, 0x46, 0x7e, 0x5e, 0xad, 0xd7, 0x7d, 0xfb,
+	0xa9, 0x3f, 0xfc, 0x1d, 0x00, 0xed, 0x59, 0xc1, 0x86, 0xe1, 0x03, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// ArticleServiceClient is the client API for ArticleService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ArticleServiceClient interface {
+	GetArticle(ctx context.Context, in *GetArticleRequest, opts ...grpc.CallOption) (*Article, error)
+	CreateArticle(ctx context.Context, in *CreateArticleRequest, opts ...grpc.CallOption) (*Article, error)
+	SearchTags(ctx context.Context, in *SearchTagsRequest, opts ...grpc.CallOption) (*Tagsview, error)
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+}
+
+type articleServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewArticleServiceClient(cc grpc.ClientConnInterface) ArticleServiceClient {
+	return &articleServiceClient{cc}
+}
+
+func (c *articleServiceClient) GetArticle(ctx context.Context, in *GetArticleRequest, opts ...grpc.CallOption) (*Article, error) {
+	out := new(Article)
+	err := c.cc.Invoke(ctx, "/articleapi.v1.ArticleService/GetArticle", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *articleServiceClient) CreateArticle(ctx context.Context, in *CreateArticleRequest, opts ...grpc.CallOption) (*Article, error) {
+	out := new(Article)
+	err := c.cc.Invoke(ctx, "/articleapi.v1.ArticleService/CreateArticle", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *articleServiceClient) SearchTags(ctx context.Context, in *SearchTagsRequest, opts ...grpc.CallOption) (*Tagsview, error) {
+	out := new(Tagsview)
+	err := c.cc.Invoke(ctx, "/articleapi.v1.ArticleService/SearchTags", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *articleServiceClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, "/articleapi.v1.ArticleService/Health", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ArticleServiceServer is the server API for ArticleService service.
+type ArticleServiceServer interface {
+	GetArticle(context.Context, *GetArticleRequest) (*Article, error)
+	CreateArticle(context.Context, *CreateArticleRequest) (*Article, error)
+	SearchTags(context.Context, *SearchTagsRequest) (*Tagsview, error)
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+}
+
+// UnimplementedArticleServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedArticleServiceServer struct {
+}
+
+func (*UnimplementedArticleServiceServer) GetArticle(ctx context.Context, req *GetArticleRequest) (*Article, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetArticle not implemented")
+}
+func (*UnimplementedArticleServiceServer) CreateArticle(ctx context.Context, req *CreateArticleRequest) (*Article, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateArticle not implemented")
+}
+func (*UnimplementedArticleServiceServer) SearchTags(ctx context.Context, req *SearchTagsRequest) (*Tagsview, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchTags not implemented")
+}
+func (*UnimplementedArticleServiceServer) Health(ctx context.Context, req *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+
+func RegisterArticleServiceServer(s *grpc.Server, srv ArticleServiceServer) {
+	s.RegisterService(&_ArticleService_serviceDesc, srv)
+}
+
+func _ArticleService_GetArticle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetArticleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticleServiceServer).GetArticle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/articleapi.v1.ArticleService/GetArticle",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticleServiceServer).GetArticle(ctx, req.(*GetArticleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArticleService_CreateArticle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateArticleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticleServiceServer).CreateArticle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/articleapi.v1.ArticleService/CreateArticle",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticleServiceServer).CreateArticle(ctx, req.(*CreateArticleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArticleService_SearchTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticleServiceServer).SearchTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/articleapi.v1.ArticleService/SearchTags",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticleServiceServer).SearchTags(ctx, req.(*SearchTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArticleService_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticleServiceServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/articleapi.v1.ArticleService/Health",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticleServiceServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ArticleService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "articleapi.v1.ArticleService",
+	HandlerType: (*ArticleServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetArticle",
+			Handler:    _ArticleService_GetArticle_Handler,
+		},
+		{
+			MethodName: "CreateArticle",
+			Handler:    _ArticleService_CreateArticle_Handler,
+		},
+		{
+			MethodName: "SearchTags",
+			Handler:    _ArticleService_SearchTags_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _ArticleService_Health_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "article.proto",
+}
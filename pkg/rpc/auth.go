@@ -0,0 +1,178 @@
+package rpc
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/auth"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/client"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/ratelimit"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// apiKeyMetadata carries the API key of the caller, like the X-API-Key header of the rest api
+const apiKeyMetadata = "x-api-key"
+
+// methodScopes are the scopes the methods require when auth is enabled, those of the matching rest routes.
+// Health is public like the healthcheck, methods left out require the admin scope.
+var methodScopes = map[string]string{
+	"/articleapi.v1.ArticleService/GetArticle":    model.ScopeArticlesRead,
+	"/articleapi.v1.ArticleService/SearchTags":    model.ScopeArticlesRead,
+	"/articleapi.v1.ArticleService/CreateArticle": model.ScopeArticlesWrite,
+	"/articleapi.v1.ArticleService/Health":        "",
+}
+
+// methodRoutes are the v1 route patterns whose rate limits and buckets the methods share,
+// a caller switching from rest to gRPC keeps its quota. Health is not limited.
+var methodRoutes = map[string]string{
+	"/articleapi.v1.ArticleService/GetArticle":    "/articles/{id}",
+	"/articleapi.v1.ArticleService/SearchTags":    "/tags/{tagName}/{date}",
+	"/articleapi.v1.ArticleService/CreateArticle": "/articles/",
+}
+
+type principalCtxKey struct{}
+
+// principalFrom returns the authenticated caller, nil for anonymous calls
+func principalFrom(ctx context.Context) *model.Principal {
+	principal, _ := ctx.Value(principalCtxKey{}).(*model.Principal)
+	return principal
+}
+
+// guard authenticates, rate limits and authorizes the calls the way the rest api does its requests:
+// the IP address of the caller is limited first, then the credentials are checked, then the caller is limited
+// per method and must hold the scope of the method.
+type guard struct {
+	keyStore      client.APIKeyStore
+	tokenVerifier auth.TokenVerifier
+	limiter       ratelimit.Limiter
+	auth          model.AuthProperties
+	rateLimit     model.RateLimitProperties
+	roleMapping   map[string]string
+}
+
+func (g *guard) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	// the limits of the last bucket taken from are sent, like the headers of the rest api
+	header := metadata.MD{}
+	defer func() {
+		if len(header) > 0 {
+			grpc.SetHeader(ctx, header)
+		}
+	}()
+	ip := peerIP(ctx)
+	if g.rateLimit.Enabled && g.rateLimit.PerIP.Requests > 0 {
+		if err := g.take(header, "ip:"+ip, "/api", g.rateLimit.PerIP); err != nil {
+			return nil, statusError(err)
+		}
+	}
+	principal, err := g.authenticate(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	if principal != nil {
+		ctx = context.WithValue(ctx, principalCtxKey{}, principal)
+	}
+	if route, ok := methodRoutes[info.FullMethod]; ok && g.rateLimit.Enabled {
+		if bucket, limit := ratelimit.RouteLimit(g.rateLimit, "/api/v1", route); limit.Requests > 0 {
+			if err := g.take(header, ratelimit.ClientKey(principal, ip), bucket, limit); err != nil {
+				return nil, statusError(err)
+			}
+		}
+	}
+	if err := g.authorize(principal, info.FullMethod); err != nil {
+		return nil, statusError(err)
+	}
+	return handler(ctx, req)
+}
+
+// authenticate resolves the caller from a bearer token in the authorization metadata, from an API key
+// or from a verified client certificate, in that order. Invalid credentials are rejected.
+func (g *guard) authenticate(ctx context.Context) (*model.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 && len(values[0]) > 7 && strings.EqualFold(values[0][:7], "Bearer ") {
+		if g.tokenVerifier == nil {
+			return nil, model.Errorf(model.ErrUnauthorized, "Bearer tokens are not supported")
+		}
+		return g.tokenVerifier.Verify(strings.TrimSpace(values[0][7:]))
+	}
+	if values := md.Get(apiKeyMetadata); len(values) > 0 && values[0] != "" {
+		if g.keyStore == nil {
+			return nil, model.Errorf(model.ErrUnauthorized, "API keys are not supported")
+		}
+		apiKey, err := g.keyStore.Authenticate(ctx, values[0])
+		if err != nil {
+			return nil, err
+		}
+		return auth.KeyPrincipal(apiKey), nil
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 && len(info.State.VerifiedChains[0]) > 0 {
+			return auth.CertPrincipal(info.State.VerifiedChains[0][0], g.roleMapping), nil
+		}
+	}
+	return nil, nil
+}
+
+// authorize rejects callers lacking the scope of the method, when auth is enabled
+func (g *guard) authorize(principal *model.Principal, method string) error {
+	if !g.auth.Enabled {
+		return nil
+	}
+	scope, ok := methodScopes[method]
+	if !ok {
+		scope = model.ScopeAdmin
+	}
+	if scope == "" {
+		return nil
+	}
+	if principal == nil {
+		return model.Errorf(model.ErrUnauthorized, "A bearer token or an API key in the %s metadata is required", apiKeyMetadata)
+	}
+	if !principal.HasScope(scope) {
+		return model.Errorf(model.ErrForbidden, "%s lacks the %s scope", principal.Subject, scope)
+	}
+	return nil
+}
+
+// take takes a token from the bucket of key, the limits are set in the ratelimit-* header metadata
+func (g *guard) take(header metadata.MD, key string, bucket string, limit model.RateLimit) error {
+	result, err := g.limiter.Take(key+" "+bucket, limit)
+	if err != nil {
+		// an unavailable store must not take the api down with it
+		log.WithError(err).WithField("bucket", bucket).Errorln("Could not apply the rate limit")
+		return nil
+	}
+	header.Set("ratelimit-limit", strconv.Itoa(result.Limit))
+	header.Set("ratelimit-remaining", strconv.Itoa(result.Remaining))
+	header.Set("ratelimit-reset", ceilSeconds(result.Reset))
+	if !result.Allowed {
+		header.Set("retry-after", ceilSeconds(result.RetryAfter))
+		return model.Errorf(model.ErrTooManyRequests, "Rate limit of %s exceeded, retry in %s seconds", bucket, ceilSeconds(result.RetryAfter))
+	}
+	return nil
+}
+
+// peerIP is the host of the address of the caller
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,196 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/rpc/articlepb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// serve starts a server of the options on a local port and returns a client of it
+func serve(t *testing.T, store *authorStore, options ServerOptions) articlepb.ArticleServiceClient {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := NewServer(store, options)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return articlepb.NewArticleServiceClient(conn)
+}
+
+func TestServerScopes(t *testing.T) {
+	store := &authorStore{mockArticleStore: mockArticleStore{status: true}}
+	service := serve(t, store, ServerOptions{KeyStore: mockAPIKeyStore{}, TokenVerifier: mockTokenVerifier{},
+		Auth: model.AuthProperties{Enabled: true}})
+	article := &articlepb.Article{Id: "11", Date: "2019-02-01", Tags: []string{"success"}}
+
+	testScenarios := []struct {
+		Description string
+		Method      string
+		Key         string
+		Value       string
+		Code        codes.Code
+	}{
+		{"Public health", "Health", "", "", codes.OK},
+		{"No credentials", "GetArticle", "", "", codes.Unauthenticated},
+		{"Unknown key", "Health", "x-api-key", "unknown", codes.Unauthenticated},
+		{"Invalid token", "GetArticle", "authorization", "Bearer invalid", codes.Unauthenticated},
+		{"Reader reads", "GetArticle", "x-api-key", "reader", codes.OK},
+		{"Reader searches", "SearchTags", "x-api-key", "reader", codes.OK},
+		{"Reader writes", "CreateArticle", "x-api-key", "reader", codes.PermissionDenied},
+		{"Writer writes", "CreateArticle", "x-api-key", "writer", codes.OK},
+		{"Editor writes", "CreateArticle", "authorization", "Bearer editor", codes.OK},
+	}
+	for _, td := range testScenarios {
+		t.Run(fmt.Sprintf("%s - %s : %v", td.Description, td.Method, td.Code), func(t *testing.T) {
+			ctx := context.Background()
+			if td.Key != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, td.Key, td.Value)
+			}
+			var err error
+			switch td.Method {
+			case "Health":
+				_, err = service.Health(ctx, &articlepb.HealthRequest{})
+			case "GetArticle":
+				_, err = service.GetArticle(ctx, &articlepb.GetArticleRequest{Id: "1"})
+			case "SearchTags":
+				_, err = service.SearchTags(ctx, &articlepb.SearchTagsRequest{Tag: "tagName", Date: "2019-10-02"})
+			case "CreateArticle":
+				_, err = service.CreateArticle(ctx, &articlepb.CreateArticleRequest{Article: article})
+			}
+			assert.Equal(t, td.Code, status.Code(err), status.Convert(err).Message())
+		})
+	}
+	// the author is the authenticated caller
+	assert.Equal(t, []string{"apikey:writer", "editor-subject"}, store.authors)
+}
+
+func TestServerAuthDisabled(t *testing.T) {
+	store := &authorStore{mockArticleStore: mockArticleStore{status: true}}
+	service := serve(t, store, ServerOptions{KeyStore: mockAPIKeyStore{}})
+
+	article, err := service.GetArticle(context.Background(), &articlepb.GetArticleRequest{Id: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "apikey:writer", article.GetAuthor())
+	// credentials sent anyway are still checked
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "unknown")
+	_, err = service.GetArticle(ctx, &articlepb.GetArticleRequest{Id: "1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestServerRateLimit(t *testing.T) {
+	store := &authorStore{mockArticleStore: mockArticleStore{status: true}}
+	service := serve(t, store, ServerOptions{KeyStore: mockAPIKeyStore{}, Auth: model.AuthProperties{Enabled: true},
+		RateLimit: model.RateLimitProperties{
+			Enabled: true,
+			Default: model.RateLimit{Requests: 1, Period: 60},
+			PerIP:   model.RateLimit{Requests: 4, Period: 60},
+		}})
+	call := func(key string) (metadata.MD, error) {
+		var header metadata.MD
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+		_, err := service.GetArticle(ctx, &articlepb.GetArticleRequest{Id: "1"}, grpc.Header(&header))
+		return header, err
+	}
+
+	// each key has its bucket of the route
+	header, err := call("reader")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, header.Get("ratelimit-limit"))
+	assert.Equal(t, []string{"0"}, header.Get("ratelimit-remaining"))
+	header, err = call("reader")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"60"}, header.Get("retry-after"))
+	_, err = call("admin")
+	assert.NoError(t, err)
+
+	// the address is limited before the credentials are checked
+	_, err = call("unknown")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = call("unknown")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = service.Health(context.Background(), &articlepb.HealthRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestGuardClientCertificate(t *testing.T) {
+	g := &guard{auth: model.AuthProperties{Enabled: true}, roleMapping: map[string]string{"reporting": model.RoleReader}}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return principalFrom(ctx).Subject, nil
+	}
+	call := func(commonName string, method string) (interface{}, error) {
+		state := tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: commonName}}}}}
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1)}, AuthInfo: credentials.TLSInfo{State: state}})
+		return g.intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/articleapi.v1.ArticleService/" + method}, handler)
+	}
+
+	subject, err := call("reporting", "GetArticle")
+	assert.NoError(t, err)
+	assert.Equal(t, "cert:reporting", subject)
+	_, err = call("reporting", "CreateArticle")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = call("unknown", "GetArticle")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = call("reporting", "Unknown")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "unlisted methods require the admin scope")
+}
+
+// authorStore records the authors of the articles created
+type authorStore struct {
+	mockArticleStore
+	authors []string
+}
+
+func (store *authorStore) CreatArticle(ctx context.Context, article *model.Article) error {
+	store.authors = append(store.authors, article.Author)
+	return store.mockArticleStore.CreatArticle(ctx, article)
+}
+
+type mockTokenVerifier struct{}
+
+func (mockTokenVerifier) Verify(token string) (*model.Principal, error) {
+	if _, ok := model.RoleScopes[token]; !ok {
+		return nil, model.Errorf(model.ErrUnauthorized, "Invalid bearer token - invalid signature")
+	}
+	return &model.Principal{Subject: token + "-subject", Method: model.AuthJWT, Roles: []string{token}, Scopes: model.RoleScopes[token]}, nil
+}
+
+// mockAPIKeyStore knows the reader, writer and admin keys
+type mockAPIKeyStore struct{}
+
+var mockKeyScopes = map[string]string{"reader": model.ScopeArticlesRead, "writer": model.ScopeArticlesWrite, "admin": model.ScopeAdmin}
+
+func (mockAPIKeyStore) CreateAPIKey(ctx context.Context, name string, scopes []string) (*model.APIKey, error) {
+	return nil, model.Errorf(model.ErrUnknown, "not supported")
+}
+
+func (mockAPIKeyStore) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	scope, ok := mockKeyScopes[key]
+	if !ok {
+		return nil, model.Errorf(model.ErrUnauthorized, "Unknown API key")
+	}
+	return &model.APIKey{ID: key, Name: key, Scopes: []string{scope}}, nil
+}
+
+func (mockAPIKeyStore) ListAPIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	return nil, nil
+}
+
+func (mockAPIKeyStore) RevokeAPIKey(ctx context.Context, keyID string) error {
+	return nil
+}
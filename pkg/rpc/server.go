@@ -0,0 +1,250 @@
+package rpc
+
+//go:generate protoc --go_out=plugins=grpc:. articlepb/article.proto
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/auth"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/client"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/ratelimit"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/rpc/articlepb"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/tracing"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/plugin/grpctrace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ServerOptions are the dependencies of the gRPC server besides its store, those of the rest api so that calls
+// are authenticated, authorized and rate limited alike. Without a KeyStore API keys are refused, and bearer tokens
+// without a TokenVerifier. A nil Limiter keeps the rate limit buckets in process, pass the one of the rest api
+// to share them. Calls are served in plain text without a TLSConfig.
+type ServerOptions struct {
+	Validator     *model.ArticleValidator
+	KeyStore      client.APIKeyStore
+	TokenVerifier auth.TokenVerifier
+	Limiter       ratelimit.Limiter
+	TLSConfig     *tls.Config
+	Auth          model.AuthProperties
+	RateLimit     model.RateLimitProperties
+	RoleMapping   map[string]string // {client certificate name : role}, see auth.CertPrincipal
+}
+
+// NewServer creates a gRPC server exposing the ArticleService over the article store,
+// calls are traced continuing the trace context of the incoming metadata
+func NewServer(articleStore client.ArticleStore, options ServerOptions) *grpc.Server {
+	if options.Limiter == nil {
+		options.Limiter = ratelimit.NewMemoryLimiter()
+	}
+	guard := &guard{keyStore: options.KeyStore, tokenVerifier: options.TokenVerifier, limiter: options.Limiter,
+		auth: options.Auth, rateLimit: options.RateLimit, roleMapping: options.RoleMapping}
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(grpctrace.UnaryServerInterceptor(tracing.Tracer()), recoverInterceptor, auditInterceptor, guard.intercept),
+	}
+	if options.TLSConfig != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(options.TLSConfig)))
+	}
+	server := grpc.NewServer(serverOptions...)
+	articlepb.RegisterArticleServiceServer(server, NewArticleServer(articleStore, options.Validator))
+	return server
+}
+
+// NewArticleServer creates an ArticleService mirroring the rest Delegate.
+// A nil validator only checks the mandatory fields.
+func NewArticleServer(articleStore client.ArticleStore, validator *model.ArticleValidator) articlepb.ArticleServiceServer {
+	if validator == nil {
+		validator = model.DefaultArticleValidator()
+	}
+	return &articleServer{articleStore: articleStore, validator: validator}
+}
+
+// maps from internal errors to grpc status codes
+// statusError defaults to codes.Internal
+// if a specific error code is not defined.
+var errCodeMap = map[int]codes.Code{
+	model.ErrUnknown:         codes.Unknown,
+	model.ErrDuplicate:       codes.AlreadyExists,
+	model.ErrInvalidInput:    codes.InvalidArgument,
+	model.ErrNotFound:        codes.NotFound,
+	model.ErrUnauthorized:    codes.Unauthenticated,
+	model.ErrForbidden:       codes.PermissionDenied,
+	model.ErrTooManyRequests: codes.ResourceExhausted,
+	model.ErrTooLarge:        codes.ResourceExhausted,
+}
+
+// statusError converts an error into a grpc status error
+func statusError(err error) error {
+	// artricle service model.Errors store more specific response information,
+	// the messages of other errors are not meant for the clients
+	code := codes.Internal
+	specificError, ok := err.(*model.Error)
+	if !ok {
+		specificError = model.ErrorEf(model.ErrUnknown, err, "An unexpected error occurred")
+	} else if c, ok := errCodeMap[specificError.Code]; ok {
+		code = c
+	}
+	if specificError.Cause != nil {
+		log.WithError(specificError.Cause).WithField("code", code).Errorln(specificError.Message)
+	}
+	message := specificError.Message
+	// grpc status messages are the only place to list the violations
+	for _, v := range specificError.Violations {
+		message += fmt.Sprintf("\n%s %s: %s", v.Field, v.Rule, v.Message)
+	}
+	return status.Error(code, message)
+}
+
+type articleServer struct {
+	articleStore client.ArticleStore
+	validator    *model.ArticleValidator
+}
+
+func (s *articleServer) Health(ctx context.Context, req *articlepb.HealthRequest) (*articlepb.HealthResponse, error) {
+	if !s.articleStore.HealthCheck() {
+		return nil, status.Error(codes.Unavailable, "failure")
+	}
+	return &articlepb.HealthResponse{Status: "success"}, nil
+}
+
+func (s *articleServer) GetArticle(ctx context.Context, req *articlepb.GetArticleRequest) (*articlepb.Article, error) {
+	if req.GetId() == "" {
+		return nil, statusError(model.Errorf(model.ErrInvalidInput, "id is mandatory"))
+	}
+	article, err := s.articleStore.ReadArticleByID(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toArticlePB(article)
+}
+
+func (s *articleServer) SearchTags(ctx context.Context, req *articlepb.SearchTagsRequest) (*articlepb.Tagsview, error) {
+	if req.GetDate() == "" || req.GetTag() == "" {
+		return nil, statusError(model.Errorf(model.ErrInvalidInput, "date and tag are mandatory"))
+	}
+	tags, err := s.articleStore.SearchTagsByDate(ctx, req.GetDate(), req.GetTag())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	if len(tags) == 0 {
+		return nil, statusError(model.Errorf(model.ErrNotFound, "No Matching Articles Found"))
+	}
+	return &articlepb.Tagsview{
+		Tag:         tags[0].Tag,
+		Articles:    fromStringPtrs(tags[0].Articles),
+		RelatedTags: fromStringPtrs(tags[0].RelatedTags),
+		Count:       int32(tags[0].Count),
+	}, nil
+}
+
+func (s *articleServer) CreateArticle(ctx context.Context, req *articlepb.CreateArticleRequest) (*articlepb.Article, error) {
+	if req.GetArticle() == nil {
+		return nil, statusError(model.Errorf(model.ErrInvalidInput, "No article"))
+	}
+	article := fromArticlePB(req.GetArticle())
+	if err := s.validator.Validate(*article); err != nil {
+		return nil, statusError(err)
+	}
+	// the author is whoever authenticated, never what the request claims, like on the rest api
+	article.Author = ""
+	if principal := principalFrom(ctx); principal != nil {
+		article.Author = principal.Subject
+	}
+	if err := s.articleStore.CreatArticle(ctx, article); err != nil {
+		return nil, statusError(err)
+	}
+	return toArticlePB(article)
+}
+
+func toArticlePB(article *model.Article) (*articlepb.Article, error) {
+	createdAt, err := toTimestamp(article.CreatedAt)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	updatedAt, err := toTimestamp(article.UpdatedAt)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &articlepb.Article{
+		Id:        article.ArticleID,
+		Title:     article.Title,
+		Date:      article.Date,
+		Body:      article.Body,
+		Tags:      fromStringPtrs(article.Tags),
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+		Author:    article.Author,
+	}, nil
+}
+
+func fromArticlePB(article *articlepb.Article) *model.Article {
+	tags := make([]*string, len(article.GetTags()))
+	for i := range article.GetTags() {
+		tags[i] = &article.Tags[i]
+	}
+	return &model.Article{
+		ArticleID: article.GetId(),
+		Title:     article.GetTitle(),
+		Date:      article.GetDate(),
+		Body:      article.GetBody(),
+		Tags:      tags,
+		Author:    article.GetAuthor(),
+	}
+}
+
+// toTimestamp leaves unset times out of the message
+func toTimestamp(t *time.Time) (*timestamp.Timestamp, error) {
+	if t == nil || t.IsZero() {
+		return nil, nil
+	}
+	return ptypes.TimestampProto(*t)
+}
+
+func fromStringPtrs(values []*string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			result = append(result, *v)
+		}
+	}
+	return result
+}
+
+func recoverInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithField("method", info.FullMethod).Errorf("panic: %+v", r)
+			err = status.Error(codes.Internal, "Internal Server Error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// auditInterceptor logs a structured line per call, correlated by the x-request-id metadata when sent
+func auditInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	fields := log.Fields{
+		"method":     info.FullMethod,
+		"code":       status.Code(err).String(),
+		"latency_ms": time.Since(start).Seconds() * 1000,
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("x-request-id")) > 0 {
+		fields["request_id"] = md.Get("x-request-id")[0]
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		fields["client"] = p.Addr.String()
+	}
+	log.WithFields(fields).Infoln("RPC served")
+	return resp, err
+}
@@ -0,0 +1,134 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/rpc/articlepb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestArticleServer(t *testing.T) {
+	server := NewArticleServer(&mockArticleStore{status: true}, nil)
+	ctx := context.Background()
+
+	article, err := server.GetArticle(ctx, &articlepb.GetArticleRequest{Id: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", article.GetId())
+	assert.Equal(t, []string{"success"}, article.GetTags())
+	assert.Equal(t, mockUpdatedAt.Unix(), article.GetUpdatedAt().GetSeconds())
+	assert.Nil(t, article.GetCreatedAt())
+	assert.Equal(t, "apikey:writer", article.GetAuthor())
+
+	tags, err := server.SearchTags(ctx, &articlepb.SearchTagsRequest{Tag: "tagName", Date: "2019-10-02"})
+	assert.NoError(t, err)
+	assert.Equal(t, "tagName", tags.GetTag())
+	assert.Equal(t, int32(1), tags.GetCount())
+
+	health, err := server.Health(ctx, &articlepb.HealthRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "success", health.GetStatus())
+
+	created, err := server.CreateArticle(ctx, &articlepb.CreateArticleRequest{
+		Article: &articlepb.Article{Id: "11", Date: "2019-02-01", Tags: []string{"success"}, Author: "forged"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "11", created.GetId())
+	assert.Empty(t, created.GetAuthor(), "the author is never what the request claims")
+}
+
+func TestArticleServerErrorCodes(t *testing.T) {
+	server := NewArticleServer(&mockArticleStore{status: false}, nil)
+	ctx := context.Background()
+	valid := &articlepb.Article{Id: "1", Date: "2019-02-01", Tags: []string{"success"}}
+
+	testScenarios := []struct {
+		Description string
+		Call        func() error
+		Code        codes.Code
+	}{
+		{"Missing id", func() error { _, err := server.GetArticle(ctx, &articlepb.GetArticleRequest{}); return err }, codes.InvalidArgument},
+		{"Article not found", func() error { _, err := server.GetArticle(ctx, &articlepb.GetArticleRequest{Id: "2"}); return err }, codes.NotFound},
+		{"Tags not found", func() error {
+			_, err := server.SearchTags(ctx, &articlepb.SearchTagsRequest{Tag: "none", Date: "2019-10-02"})
+			return err
+		}, codes.NotFound},
+		{"Missing date", func() error {
+			_, err := server.SearchTags(ctx, &articlepb.SearchTagsRequest{Tag: "tagName"})
+			return err
+		}, codes.InvalidArgument},
+		{"No article", func() error { _, err := server.CreateArticle(ctx, &articlepb.CreateArticleRequest{}); return err }, codes.InvalidArgument},
+		{"Invalid date", func() error {
+			_, err := server.CreateArticle(ctx, &articlepb.CreateArticleRequest{Article: &articlepb.Article{Id: "1", Date: "21313223", Tags: []string{"a"}}})
+			return err
+		}, codes.InvalidArgument},
+		{"Duplicate", func() error {
+			_, err := server.CreateArticle(ctx, &articlepb.CreateArticleRequest{Article: valid})
+			return err
+		}, codes.AlreadyExists},
+		{"Unhealthy", func() error { _, err := server.Health(ctx, &articlepb.HealthRequest{}); return err }, codes.Unavailable},
+		{"Unknown error", func() error { return statusError(fmt.Errorf("boom")) }, codes.Internal},
+	}
+	for _, td := range testScenarios {
+		t.Run(fmt.Sprintf("%s : %v", td.Description, td.Code), func(t *testing.T) {
+			assert.Equal(t, td.Code, status.Code(td.Call()))
+		})
+	}
+}
+
+func TestStatusErrorHidesCause(t *testing.T) {
+	err := statusError(fmt.Errorf("E11000 duplicate key error collection: articlestore.articles"))
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "An unexpected error occurred", status.Convert(err).Message())
+}
+
+func TestArticleServerViolations(t *testing.T) {
+	server := NewArticleServer(&mockArticleStore{status: true}, nil)
+	_, err := server.CreateArticle(context.Background(), &articlepb.CreateArticleRequest{Article: &articlepb.Article{Date: "21313223"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "Invalid Article data\n/id required: is required\n/tags required: is required\n"+
+		"/date format: must be a date of format YYYY-MM-DD", status.Convert(err).Message())
+}
+
+var mockUpdatedAt = time.Date(2019, 10, 2, 10, 30, 0, 0, time.UTC)
+
+type mockArticleStore struct{ status bool }
+
+func (store *mockArticleStore) HealthCheck() bool {
+	return store.status
+}
+
+func (store *mockArticleStore) CreatArticle(ctx context.Context, article *model.Article) error {
+	if article.ArticleID == "11" {
+		return nil
+	}
+	return model.Errorf(model.ErrDuplicate, "Duplicate key error")
+}
+
+func (store *mockArticleStore) ReadArticleByID(ctx context.Context, articleID string) (*model.Article, error) {
+	if articleID == "1" {
+		s := "success"
+		return &model.Article{ArticleID: articleID, Tags: []*string{&s}, UpdatedAt: &mockUpdatedAt, Author: "apikey:writer"}, nil
+	}
+	return nil, model.Errorf(model.ErrNotFound, "Not found error")
+}
+
+func (store *mockArticleStore) ReadArticlesByIDs(ctx context.Context, articleIDs []string) ([]*model.Article, error) {
+	return nil, nil
+}
+
+func (store *mockArticleStore) ListTags(ctx context.Context, date string) ([]*model.Tagsview, error) {
+	return nil, nil
+}
+
+func (store *mockArticleStore) SearchTagsByDate(ctx context.Context, date string, tag string) ([]*model.Tagsview, error) {
+	if tag == "tagName" {
+		return []*model.Tagsview{{Tag: tag, Count: 1}}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,193 @@
+package stream
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+)
+
+// Event is an article created, its ID resumes a stream right after it
+type Event struct {
+	ID      string
+	Article *model.Article
+}
+
+// Broker fans the articles created out to the subscribers following their tags, and keeps the last
+// History events so that subscribers can resume after the last event they got.
+// It is fed by Publish with the articles this instance created, or by a Watcher of the database.
+type Broker struct {
+	props model.StreamProperties
+	// prefix of the ids of the published events, the ids of an earlier run are never mistaken for these
+	prefix string
+
+	mu          sync.Mutex
+	sequence    uint64
+	history     []Event
+	subscribers map[*Subscription]bool
+	closed      bool
+}
+
+// NewBroker returns a broker of the stream settings, settings left to 0 take their default
+func NewBroker(props model.StreamProperties) *Broker {
+	defaults := model.DefaultConfig().Stream
+	if props.Heartbeat <= 0 {
+		props.Heartbeat = defaults.Heartbeat
+	}
+	if props.ClientBuffer <= 0 {
+		props.ClientBuffer = defaults.ClientBuffer
+	}
+	if props.History < 0 {
+		props.History = 0
+	}
+	if props.MaxSubscriptions <= 0 {
+		props.MaxSubscriptions = defaults.MaxSubscriptions
+	}
+	if props.WriteTimeout <= 0 {
+		props.WriteTimeout = defaults.WriteTimeout
+	}
+	return &Broker{
+		props:       props,
+		prefix:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		subscribers: map[*Subscription]bool{},
+	}
+}
+
+// Heartbeat is how often idle streams should send a comment, so that proxies keep them open
+func (b *Broker) Heartbeat() time.Duration {
+	return time.Duration(b.props.Heartbeat) * time.Second
+}
+
+// MaxSubscriptions is how many tag and date pairs a websocket client may follow at once
+func (b *Broker) MaxSubscriptions() int {
+	return b.props.MaxSubscriptions
+}
+
+// WriteTimeout is how long a websocket client has to take a message before it is disconnected
+func (b *Broker) WriteTimeout() time.Duration {
+	return time.Duration(b.props.WriteTimeout) * time.Second
+}
+
+// Publish sends the articles created to the subscribers, other events are ignored
+func (b *Broker) Publish(ctx context.Context, event string, article *model.Article) {
+	if event != model.EventArticleCreated {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sequence++
+	b.deliver(Event{ID: b.prefix + "-" + strconv.FormatUint(b.sequence, 10), Article: article})
+}
+
+// send hands an event of the database to the subscribers
+func (b *Broker) send(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.deliver(event)
+}
+
+// deliver keeps the event in the history and hands it to the matching subscribers, it must be called
+// with the lock held. A subscriber whose buffer is full is closed rather than waited for,
+// it resumes from the history once it reconnects.
+func (b *Broker) deliver(event Event) {
+	if b.closed {
+		return
+	}
+	if b.props.History > 0 {
+		b.history = append(b.history, event)
+		if len(b.history) > b.props.History {
+			b.history = b.history[len(b.history)-b.props.History:]
+		}
+	}
+	for sub := range b.subscribers {
+		if !sub.matches(event.Article) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			sub.lagging = true
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe follows the articles created with any of the tags, case insensitively, every article when there are none.
+// The events of the history after lastEventID are sent first, resumed is false when lastEventID is not
+// in the history anymore: the stream then starts with the next article created.
+func (b *Broker) Subscribe(tags []string, lastEventID string) (sub *Subscription, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var backlog []Event
+	if lastEventID != "" {
+		for i := range b.history {
+			if b.history[i].ID == lastEventID {
+				backlog, resumed = b.history[i+1:], true
+				break
+			}
+		}
+	}
+	sub = &Subscription{broker: b, tags: tags, events: make(chan Event, b.props.ClientBuffer+len(backlog))}
+	for _, event := range backlog {
+		if sub.matches(event.Article) {
+			sub.events <- event
+		}
+	}
+	if b.closed {
+		close(sub.events)
+		return sub, resumed
+	}
+	b.subscribers[sub] = true
+	return sub, resumed
+}
+
+// Close ends every subscription, eg: on shutdown so that the streams do not hold the server up
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+// remove must be called with the lock held
+func (b *Broker) remove(sub *Subscription) {
+	if b.subscribers[sub] {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Subscription of a client to the articles created
+type Subscription struct {
+	broker  *Broker
+	tags    []string
+	events  chan Event
+	lagging bool
+}
+
+// Events are the articles created matching the tags, the channel is closed once the subscription ends
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Lagging reports whether the subscription ended as the client fell behind the articles created
+func (s *Subscription) Lagging() bool {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.lagging
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+func (s *Subscription) matches(article *model.Article) bool {
+	return len(s.tags) == 0 || article.HasAnyTag(s.tags)
+}
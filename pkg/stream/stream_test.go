@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func article(id string, tags ...string) *model.Article {
+	article := &model.Article{ArticleID: id, Date: "2016-09-22"}
+	for i := range tags {
+		article.Tags = append(article.Tags, &tags[i])
+	}
+	return article
+}
+
+// received drains the events sent so far
+func received(sub *Subscription) []string {
+	var ids []string
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, event.Article.ArticleID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestSubscribersFollowTheirTags(t *testing.T) {
+	broker := NewBroker(model.StreamProperties{})
+	health, _ := broker.Subscribe([]string{"HEALTH"}, "")
+	every, _ := broker.Subscribe(nil, "")
+	defer health.Close()
+
+	broker.Publish(context.Background(), model.EventArticleCreated, article("1", "fitness", "health"))
+	broker.Publish(context.Background(), model.EventArticleCreated, article("2", "science"))
+	broker.Publish(context.Background(), model.EventArticleUpdated, article("1", "health"))
+
+	assert.Equal(t, []string{"1"}, received(health))
+	assert.Equal(t, []string{"1", "2"}, received(every))
+	every.Close()
+	broker.Publish(context.Background(), model.EventArticleCreated, article("3", "health"))
+	_, open := <-every.Events()
+	assert.False(t, open)
+	assert.Equal(t, []string{"3"}, received(health))
+}
+
+func TestResumeAfterLastEventID(t *testing.T) {
+	broker := NewBroker(model.StreamProperties{History: 2})
+	first, _ := broker.Subscribe(nil, "")
+	for _, id := range []string{"1", "2", "3"} {
+		broker.Publish(context.Background(), model.EventArticleCreated, article(id, "health"))
+	}
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, (<-first.Events()).ID)
+	}
+
+	resumed, ok := broker.Subscribe([]string{"health"}, ids[1])
+	assert.True(t, ok)
+	assert.Equal(t, []string{"3"}, received(resumed))
+
+	// the first event is out of the history
+	fresh, ok := broker.Subscribe(nil, ids[0])
+	assert.False(t, ok)
+	assert.Empty(t, received(fresh))
+	_, ok = broker.Subscribe(nil, "of another run")
+	assert.False(t, ok)
+}
+
+func TestLaggingSubscribersAreClosed(t *testing.T) {
+	broker := NewBroker(model.StreamProperties{ClientBuffer: 1})
+	slow, _ := broker.Subscribe(nil, "")
+	fast, _ := broker.Subscribe(nil, "")
+
+	broker.Publish(context.Background(), model.EventArticleCreated, article("1"))
+	assert.Equal(t, []string{"1"}, received(fast))
+	broker.Publish(context.Background(), model.EventArticleCreated, article("2"))
+
+	assert.Equal(t, []string{"1"}, received(slow))
+	assert.True(t, slow.Lagging())
+	assert.Equal(t, []string{"2"}, received(fast))
+	assert.False(t, fast.Lagging())
+
+	broker.Close()
+	_, open := <-fast.Events()
+	assert.False(t, open)
+	assert.False(t, fast.Lagging())
+	closed, _ := broker.Subscribe(nil, "")
+	_, open = <-closed.Events()
+	assert.False(t, open)
+}
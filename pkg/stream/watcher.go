@@ -0,0 +1,127 @@
+package stream
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/client"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// reopenDelay between two attempts to reopen a failed change stream
+const reopenDelay = 2 * time.Second
+
+// insertsOnly is the pipeline of the change stream, the articles are never updated or deleted
+var insertsOnly = []bson.M{{"$match": bson.M{"operationType": "insert"}}}
+
+// Watcher sends the articles inserted in the article collection, by any instance of the app, to a broker.
+// Event ids are the resume tokens of the change stream, so that every instance gives an event the same id
+// and a client can resume its stream on any of them.
+type Watcher struct {
+	dbClient client.DBClient
+	broker   *Broker
+	cancel   context.CancelFunc
+	stopped  chan struct{}
+}
+
+// NewWatcher returns a watcher of the articles of dbClient
+func NewWatcher(dbClient client.DBClient, broker *Broker) *Watcher {
+	return &Watcher{dbClient: dbClient, broker: broker, stopped: make(chan struct{})}
+}
+
+// Start opens the change stream and watches it until Stop. It fails when the database does not
+// support change streams, eg: a standalone server.
+func (w *Watcher) Start(ctx context.Context) error {
+	changes, err := w.open(ctx, nil)
+	if err != nil {
+		return err
+	}
+	ctx, w.cancel = context.WithCancel(context.Background())
+	go w.watch(ctx, changes)
+	return nil
+}
+
+// Stop closes the change stream
+func (w *Watcher) Stop(ctx context.Context) error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel()
+	select {
+	case <-w.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Watcher) open(ctx context.Context, resumeAfter bson.Raw) (*mongo.ChangeStream, error) {
+	var token interface{}
+	if resumeAfter != nil {
+		token = resumeAfter
+	}
+	cs, err := w.dbClient.Watch(ctx, insertsOnly, token)
+	if err != nil {
+		return nil, err
+	}
+	changes, ok := cs.(*mongo.ChangeStream)
+	if !ok {
+		return nil, errors.New("unexpected change stream")
+	}
+	return changes, nil
+}
+
+// watch sends every insert to the broker. The driver resumes the change stream after transient errors itself,
+// others reopen it after the last change sent, or from now when that change is too old to resume after.
+func (w *Watcher) watch(ctx context.Context, changes *mongo.ChangeStream) {
+	defer close(w.stopped)
+	var resumeAfter bson.Raw
+	for {
+		for changes.Next(ctx) {
+			var change struct {
+				FullDocument *model.Article `bson:"fullDocument"`
+			}
+			resumeAfter = changes.ResumeToken()
+			if err := changes.Decode(&change); err != nil || change.FullDocument == nil {
+				log.WithError(err).Warnln("Could not decode an article of the change stream")
+				continue
+			}
+			w.broker.send(Event{ID: base64.RawURLEncoding.EncodeToString(resumeAfter), Article: change.FullDocument})
+		}
+		err := changes.Err()
+		changes.Close(context.Background())
+		if ctx.Err() != nil {
+			return
+		}
+		log.WithError(err).Warnln("The change stream of the articles failed, reopening it")
+		for changes = nil; changes == nil; {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(reopenDelay):
+			}
+			if changes, err = w.open(ctx, resumeAfter); err != nil {
+				if resumeAfter != nil && historyLost(err) {
+					log.WithError(err).Errorln("The articles created while the change stream was down are lost to the streams")
+					resumeAfter = nil
+				} else {
+					log.WithError(err).Warnln("Could not reopen the change stream of the articles")
+				}
+			}
+		}
+	}
+}
+
+// historyLost reports whether the oplog no longer holds the change to resume after
+func historyLost(err error) bool {
+	if commandError, ok := err.(mongo.CommandError); ok {
+		// ChangeStreamHistoryLost and ChangeStreamFatalError
+		return commandError.Code == 286 || commandError.Code == 280
+	}
+	return false
+}
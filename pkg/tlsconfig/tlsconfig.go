@@ -0,0 +1,159 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// checkInterval between two looks at the modification times of the files, handshakes in between reuse what was loaded
+const checkInterval = time.Second
+
+// NewServerConfig returns the TLS config of the api listener. Every handshake is served with the latest
+// certificate and client CAs: they are read again once their files are modified, eg: rotated by cert-manager.
+func NewServerConfig(props model.TLSProperties) (*tls.Config, error) {
+	files := &serverFiles{props: props, now: time.Now}
+	if err := files.load(); err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if props.MinVersion == "1.3" {
+		config.MinVersion = tls.VersionTLS13
+	}
+	if props.ClientCAFile != "" {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		if props.ClientAuth == "request" {
+			config.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cert, clientCAs := files.current()
+		handshake := config.Clone()
+		handshake.GetConfigForClient = nil
+		handshake.Certificates = []tls.Certificate{*cert}
+		handshake.ClientCAs = clientCAs
+		return handshake, nil
+	}
+	return config, nil
+}
+
+// serverFiles keep the certificate and client CAs loaded from the files of props
+type serverFiles struct {
+	props model.TLSProperties
+	now   func() time.Time
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modified  map[string]time.Time
+	checked   time.Time
+}
+
+// current reloads the files once they are modified. Files that cannot be read, eg: half way through
+// a rotation, keep the previous certificate in use until the next check.
+func (f *serverFiles) current() (*tls.Certificate, *x509.CertPool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if now := f.now(); now.Sub(f.checked) >= checkInterval {
+		f.checked = now
+		if f.changed() {
+			if err := f.reload(); err != nil {
+				log.WithError(err).Errorln("Could not reload the TLS certificates, the previous ones are kept")
+			} else {
+				log.WithField("cert", f.props.CertFile).Infoln("TLS certificates reloaded")
+			}
+		}
+	}
+	return f.cert, f.clientCAs
+}
+
+func (f *serverFiles) load() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checked = f.now()
+	f.changed()
+	return f.reload()
+}
+
+// changed records the modification times of the files and reports whether any moved since the last check
+func (f *serverFiles) changed() bool {
+	changed := false
+	modified := map[string]time.Time{}
+	for _, name := range []string{f.props.CertFile, f.props.KeyFile, f.props.ClientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			// a missing file is waited for
+			modified[name] = f.modified[name]
+			continue
+		}
+		modified[name] = info.ModTime()
+		if !info.ModTime().Equal(f.modified[name]) {
+			changed = true
+		}
+	}
+	f.modified = modified
+	return changed
+}
+
+func (f *serverFiles) reload() error {
+	cert, err := tls.LoadX509KeyPair(f.props.CertFile, f.props.KeyFile)
+	if err != nil {
+		return fmt.Errorf("could not load the certificate %s: %v", f.props.CertFile, err)
+	}
+	var clientCAs *x509.CertPool
+	if f.props.ClientCAFile != "" {
+		if clientCAs, err = loadPool(f.props.ClientCAFile); err != nil {
+			return err
+		}
+	}
+	f.cert, f.clientCAs = &cert, clientCAs
+	return nil
+}
+
+// NewClientConfig returns the TLS config of a connection to a server, eg: the database
+func NewClientConfig(props model.ClientTLSProperties) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: props.Insecure,
+	}
+	if props.CAFile != "" {
+		roots, err := loadPool(props.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = roots
+	}
+	if props.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(props.CertFile, props.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the client certificate %s: %v", props.CertFile, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func loadPool(name string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the CA bundle %s: %v", name, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificate found in the CA bundle %s", name)
+	}
+	return pool, nil
+}
@@ -0,0 +1,213 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCA signs the certificates of the tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of name, a server certificate for localhost or a client one
+func (ca *testCA) issue(t *testing.T, name string, serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+	assert.NoError(t, ioutil.WriteFile(name, data, 0600))
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "tlsconfig")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := tempDir(t)
+	props := model.TLSProperties{
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+	}
+	cert, key := ca.issue(t, "articleapi", 2, x509.ExtKeyUsageServerAuth)
+	writeFile(t, props.CertFile, cert)
+	writeFile(t, props.KeyFile, key)
+	writeFile(t, props.ClientCAFile, ca.pem)
+	clientCert, clientKey := ca.issue(t, "reporting", 3, x509.ExtKeyUsageClientAuth)
+	clientPair, err := tls.X509KeyPair(clientCert, clientKey)
+	assert.NoError(t, err)
+
+	for _, clientAuth := range []string{"", "request"} {
+		props.ClientAuth = clientAuth
+		config, err := NewServerConfig(props)
+		assert.NoError(t, err)
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.VerifiedChains) > 0 {
+				w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+			}
+		}))
+		server.TLS = config
+		server.StartTLS()
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.cert)
+		get := func(certificates ...tls.Certificate) (string, error) {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certificates}}}
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				return "", err
+			}
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			return string(body), err
+		}
+
+		identity, err := get(clientPair)
+		assert.NoError(t, err)
+		assert.Equal(t, "reporting", identity)
+		identity, err = get()
+		if clientAuth == "request" {
+			assert.NoError(t, err)
+			assert.Empty(t, identity)
+		} else {
+			assert.Error(t, err, "a client certificate is required")
+		}
+		server.Close()
+	}
+}
+
+func TestServerCertificateReload(t *testing.T) {
+	ca := newTestCA(t)
+	dir := tempDir(t)
+	props := model.TLSProperties{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key")}
+	cert, key := ca.issue(t, "before", 2, x509.ExtKeyUsageServerAuth)
+	writeFile(t, props.CertFile, cert)
+	writeFile(t, props.KeyFile, key)
+
+	now := time.Now()
+	files := &serverFiles{props: props, now: func() time.Time { return now }}
+	assert.NoError(t, files.load())
+	current, _ := files.current()
+	leaf, _ := x509.ParseCertificate(current.Certificate[0])
+	assert.Equal(t, "before", leaf.Subject.CommonName)
+
+	// half way through the rotation the key no longer matches, the previous certificate is kept
+	cert, key = ca.issue(t, "after", 3, x509.ExtKeyUsageServerAuth)
+	writeFile(t, props.CertFile, cert)
+	modified := now.Add(time.Minute)
+	assert.NoError(t, os.Chtimes(props.CertFile, modified, modified))
+	now = now.Add(2 * checkInterval)
+	current, _ = files.current()
+	leaf, _ = x509.ParseCertificate(current.Certificate[0])
+	assert.Equal(t, "before", leaf.Subject.CommonName)
+
+	writeFile(t, props.KeyFile, key)
+	assert.NoError(t, os.Chtimes(props.KeyFile, modified, modified))
+	// not looked at again within the check interval
+	current, _ = files.current()
+	leaf, _ = x509.ParseCertificate(current.Certificate[0])
+	assert.Equal(t, "before", leaf.Subject.CommonName)
+
+	now = now.Add(2 * checkInterval)
+	current, _ = files.current()
+	leaf, _ = x509.ParseCertificate(current.Certificate[0])
+	assert.Equal(t, "after", leaf.Subject.CommonName)
+}
+
+func TestNewServerConfigErrors(t *testing.T) {
+	dir := tempDir(t)
+	_, err := NewServerConfig(model.TLSProperties{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: filepath.Join(dir, "missing.key")})
+	assert.Error(t, err)
+
+	ca := newTestCA(t)
+	props := model.TLSProperties{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key"), ClientCAFile: filepath.Join(dir, "ca.crt")}
+	cert, key := ca.issue(t, "articleapi", 2, x509.ExtKeyUsageServerAuth)
+	writeFile(t, props.CertFile, cert)
+	writeFile(t, props.KeyFile, key)
+	writeFile(t, props.ClientCAFile, []byte("not a certificate"))
+	_, err = NewServerConfig(props)
+	assert.EqualError(t, err, "no PEM certificate found in the CA bundle "+props.ClientCAFile)
+}
+
+func TestNewClientConfig(t *testing.T) {
+	ca := newTestCA(t)
+	dir := tempDir(t)
+	props := model.ClientTLSProperties{
+		Enabled:  true,
+		CAFile:   filepath.Join(dir, "ca.crt"),
+		CertFile: filepath.Join(dir, "client.crt"),
+		KeyFile:  filepath.Join(dir, "client.key"),
+	}
+	cert, key := ca.issue(t, "articleapi", 2, x509.ExtKeyUsageClientAuth)
+	writeFile(t, props.CAFile, ca.pem)
+	writeFile(t, props.CertFile, cert)
+	writeFile(t, props.KeyFile, key)
+
+	config, err := NewClientConfig(props)
+	assert.NoError(t, err)
+	assert.NotNil(t, config.RootCAs)
+	assert.Len(t, config.Certificates, 1)
+	assert.False(t, config.InsecureSkipVerify)
+
+	props.KeyFile = filepath.Join(dir, "missing.key")
+	_, err = NewClientConfig(props)
+	assert.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/api/kv"
+	"go.opentelemetry.io/otel/api/standard"
+	"go.opentelemetry.io/otel/api/trace"
+)
+
+// CommandMonitor traces every Mongo command as a child of the span of the operation's context,
+// set it on the client options. Commands are left out of the spans as they carry article content.
+var CommandMonitor = &event.CommandMonitor{
+	Started:   commandStarted,
+	Succeeded: commandSucceeded,
+	Failed:    commandFailed,
+}
+
+// commandSpans holds the spans of the running commands {connectionID/requestID : span}
+var commandSpans sync.Map
+
+func commandKey(connectionID string, requestID int64) string {
+	return fmt.Sprintf("%s/%d", connectionID, requestID)
+}
+
+func commandStarted(ctx context.Context, e *event.CommandStartedEvent) {
+	attrs := []kv.KeyValue{
+		standard.DBTypeKey.String("mongodb"),
+		standard.DBInstanceKey.String(e.DatabaseName),
+		kv.String("db.operation", e.CommandName),
+		kv.String("db.connection_id", e.ConnectionID),
+	}
+	// the first element of a command names its collection eg: {"find": "articles", ...}
+	if element, err := e.Command.IndexErr(0); err == nil {
+		if collection, ok := element.Value().StringValueOK(); ok {
+			attrs = append(attrs, kv.String("db.mongodb.collection", collection))
+		}
+	}
+	_, span := Tracer().Start(ctx, "mongo."+e.CommandName, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	commandSpans.Store(commandKey(e.ConnectionID, e.RequestID), span)
+}
+
+func commandSucceeded(ctx context.Context, e *event.CommandSucceededEvent) {
+	if span, ok := commandSpans.Load(commandKey(e.ConnectionID, e.RequestID)); ok {
+		commandSpans.Delete(commandKey(e.ConnectionID, e.RequestID))
+		End(span.(trace.Span), nil)
+	}
+}
+
+func commandFailed(ctx context.Context, e *event.CommandFailedEvent) {
+	if span, ok := commandSpans.Load(commandKey(e.ConnectionID, e.RequestID)); ok {
+		commandSpans.Delete(commandKey(e.ConnectionID, e.RequestID))
+		End(span.(trace.Span), errors.New(e.Failure))
+	}
+}
@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/kv"
+	"go.opentelemetry.io/otel/api/propagation"
+	"go.opentelemetry.io/otel/api/standard"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/trace/stdout"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/codes"
+)
+
+// instrumentationName names the tracer of every span of the app
+const instrumentationName = "github.com/PadmavathiSundaram/ArticleAPI"
+
+// Setup installs the W3C trace context propagator and, when an exporter is configured,
+// the global tracer provider. The returned func flushes the pending spans and stops the exporter.
+func Setup(props model.TracingProperties) (func(), error) {
+	global.SetPropagators(propagation.New(
+		propagation.WithExtractors(trace.TraceContext{}),
+		propagation.WithInjectors(trace.TraceContext{}),
+	))
+
+	var processor sdktrace.SpanProcessor
+	var stop func()
+	switch props.Exporter {
+	case "":
+		return func() {}, nil
+	case "file":
+		if props.File == "" {
+			return nil, fmt.Errorf("the file exporter needs a File")
+		}
+		file, err := os.OpenFile(props.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		exporter, _ := stdout.NewExporter(stdout.Options{Writer: file})
+		processor = sdktrace.NewSimpleSpanProcessor(exporter)
+		stop = func() { file.Close() }
+	case "otlp":
+		address := props.OTLPAddress
+		if address == "" {
+			address = "localhost:55680"
+		}
+		options := []otlp.ExporterOption{otlp.WithAddress(address)}
+		if props.Insecure {
+			options = append(options, otlp.WithInsecure())
+		}
+		exporter, err := otlp.NewExporter(options...)
+		if err != nil {
+			return nil, err
+		}
+		batcher, err := sdktrace.NewBatchSpanProcessor(exporter)
+		if err != nil {
+			return nil, err
+		}
+		processor = batcher
+		stop = func() { exporter.Stop() }
+	default:
+		return nil, fmt.Errorf("unknown exporter %s, expected otlp or file", props.Exporter)
+	}
+
+	serviceName := props.ServiceName
+	if serviceName == "" {
+		serviceName = "articleapi"
+	}
+	sampleRatio := props.SampleRatio
+	if sampleRatio == 0 {
+		sampleRatio = 1
+	}
+	provider, err := sdktrace.NewProvider(sdktrace.WithConfig(sdktrace.Config{
+		DefaultSampler: sdktrace.ProbabilitySampler(sampleRatio),
+		Resource:       resource.New(standard.ServiceNameKey.String(serviceName)),
+	}))
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	provider.RegisterSpanProcessor(processor)
+	global.SetTraceProvider(provider)
+
+	return func() {
+		// unregistering flushes the processor
+		provider.UnregisterSpanProcessor(processor)
+		stop()
+	}, nil
+}
+
+// Tracer returns the tracer of the app, a no-op one until Setup installs an exporter
+func Tracer() trace.Tracer {
+	return global.Tracer(instrumentationName)
+}
+
+// Start starts a span as a child of the span of ctx
+func Start(ctx context.Context, name string, attrs ...kv.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error on the span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(context.Background(), err, trace.WithErrorStatus(errorStatus(err)))
+	}
+	span.End()
+}
+
+// errorStatus maps the model errors to span status codes
+func errorStatus(err error) codes.Code {
+	specificError, ok := err.(*model.Error)
+	if !ok {
+		return codes.Unknown
+	}
+	switch specificError.Code {
+	case model.ErrInvalidInput:
+		return codes.InvalidArgument
+	case model.ErrDuplicate:
+		return codes.AlreadyExists
+	case model.ErrNotFound:
+		return codes.NotFound
+	}
+	return codes.Unknown
+}
@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"google.golang.org/grpc/codes"
+)
+
+func TestSetupFileExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracing")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "traces.json")
+
+	stop, err := Setup(model.TracingProperties{Exporter: "file", File: file})
+	assert.NoError(t, err)
+
+	ctx, span := Start(context.Background(), "parent")
+	command, err := bson.Marshal(bson.D{{Key: "find", Value: "articles"}, {Key: "filter", Value: bson.D{{Key: "Body", Value: "secret"}}}})
+	assert.NoError(t, err)
+	CommandMonitor.Started(ctx, &event.CommandStartedEvent{Command: command, DatabaseName: "articlestore", CommandName: "find", RequestID: 1, ConnectionID: "db:27017[-1]"})
+	CommandMonitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 1, ConnectionID: "db:27017[-1]"}, Failure: "timeout"})
+	End(span, nil)
+	stop()
+
+	spans, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(spans), `"Name":"parent"`)
+	assert.Contains(t, string(spans), `"Name":"mongo.find"`)
+	assert.Contains(t, string(spans), span.SpanContext().TraceID.String())
+	assert.Contains(t, string(spans), `"Value":"articles"`)
+	assert.Contains(t, string(spans), "timeout")
+	assert.NotContains(t, string(spans), "secret")
+}
+
+func TestSetupInvalidExporter(t *testing.T) {
+	_, err := Setup(model.TracingProperties{Exporter: "jaeger"})
+	assert.Error(t, err)
+	_, err = Setup(model.TracingProperties{Exporter: "file"})
+	assert.Error(t, err)
+}
+
+func TestErrorStatus(t *testing.T) {
+	assert.Equal(t, codes.NotFound, errorStatus(model.Errorf(model.ErrNotFound, "Not found")))
+	assert.Equal(t, codes.InvalidArgument, errorStatus(model.Errorf(model.ErrInvalidInput, "Invalid")))
+	assert.Equal(t, codes.Unknown, errorStatus(errors.New("connection reset")))
+}
@@ -0,0 +1,489 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/client"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/metrics"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// Headers of every delivery
+const (
+	EventHeader     = "X-ArticleAPI-Event"
+	DeliveryHeader  = "X-ArticleAPI-Delivery"
+	TimestampHeader = "X-ArticleAPI-Timestamp"
+	SignatureHeader = "X-ArticleAPI-Signature"
+)
+
+// Sign returns the signature of a delivery body sent at timestamp, in unix seconds: sha256= followed by
+// the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by the secret of the webhook. Receivers sign the body
+// again to compare, in constant time, and refuse old timestamps so that captured deliveries cannot be replayed.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// privateNetworks are the unspecified, loopback, link-local and private ranges deliveries are never sent to
+var privateNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+		"172.16.0.0/12", "192.168.0.0/16", "::/128", "::1/128", "fc00::/7", "fe80::/10"} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// PublicIP tells whether ip is outside of the private networks
+func PublicIP(ip net.IP) bool {
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// PublicHost tells whether a webhook may be sent to host, neither localhost nor a private address.
+// Names are only resolved by the dispatcher, which refuses to connect to the private addresses they resolve to.
+func PublicHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return PublicIP(ip)
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return host != "localhost" && !strings.HasSuffix(host, ".localhost")
+}
+
+// refusePrivate is the dialer control rejecting the connections to private addresses,
+// checked once the name is resolved so that it cannot be rebound to one after the webhook was validated
+func refusePrivate(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !PublicIP(ip) {
+		return fmt.Errorf("%s is a private address", host)
+	}
+	return nil
+}
+
+// leaseMargin is how long a claimed delivery is held past the timeout of its attempt, to record its outcome
+const leaseMargin = time.Minute
+
+// Service manages the webhooks and their delivery log, and replays the dead deliveries
+type Service interface {
+	client.WebhookStore
+	Replay(ctx context.Context, deliveryID string) (*model.Delivery, error)
+}
+
+// Dispatcher delivers the published article events to the webhooks subscribed to them.
+// Every delivery is logged in the store before it is attempted, deliveries that were not
+// delivered when the app stopped are attempted again once it starts: receivers may get an event twice,
+// the delivery header tells the copies apart. Instances sharing the store claim a delivery before attempting it,
+// for a lease, so that only one of them attempts it at a time.
+type Dispatcher struct {
+	client.WebhookStore
+	props      model.WebhookProperties
+	httpClient *http.Client
+	now        func() time.Time
+	owner      string        // the instance, in the deliveries it claims
+	lease      time.Duration // how long a claimed delivery is held, past the timeout of its attempt
+
+	events   chan *model.WebhookEvent // published, not yet logged
+	due      chan *model.Delivery     // logged, to attempt now
+	done     chan struct{}
+	stopOnce sync.Once
+	workers  sync.WaitGroup
+}
+
+// NewDispatcher returns a dispatcher of the webhooks of store, settings left to 0 take their default
+func NewDispatcher(store client.WebhookStore, props model.WebhookProperties) *Dispatcher {
+	defaults := model.DefaultConfig().Webhooks
+	for _, setting := range []struct{ value, fallback *int }{
+		{&props.Workers, &defaults.Workers},
+		{&props.QueueSize, &defaults.QueueSize},
+		{&props.MaxAttempts, &defaults.MaxAttempts},
+		{&props.InitialBackoff, &defaults.InitialBackoff},
+		{&props.MaxBackoff, &defaults.MaxBackoff},
+		{&props.Timeout, &defaults.Timeout},
+	} {
+		if *setting.value <= 0 {
+			*setting.value = *setting.fallback
+		}
+	}
+	return &Dispatcher{
+		WebhookStore: store,
+		props:        props,
+		httpClient: &http.Client{
+			Timeout: time.Duration(props.Timeout) * time.Second,
+			// no proxy, the receivers are dialed directly so that private addresses are refused
+			Transport: &http.Transport{
+				DialContext:         (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: refusePrivate}).DialContext,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+			// a redirect is a failed attempt, following it would turn the POST into a GET
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		now:    time.Now,
+		owner:  instanceName() + "/" + newID(),
+		lease:  time.Duration(props.Timeout)*time.Second + leaseMargin,
+		events: make(chan *model.WebhookEvent, props.QueueSize),
+		due:    make(chan *model.Delivery),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start schedules the deliveries left pending or retrying by the previous run, and those left in flight
+// once their lease expires, then starts the workers. The instances sharing the store resume the same deliveries,
+// the one claiming a delivery first attempts it, and the deliveries recorded by their owner before their lease
+// expired are left as they are.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	for _, status := range []string{model.DeliveryPending, model.DeliveryRetrying, model.DeliveryInFlight} {
+		deliveries, err := d.ListDeliveries(ctx, map[string]string{"Status": status})
+		if err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			if status == model.DeliveryInFlight {
+				delivery.NextAttemptAt = delivery.LeaseExpiresAt
+			}
+			d.schedule(delivery)
+		}
+		if len(deliveries) > 0 {
+			log.WithFields(log.Fields{"status": status, "deliveries": len(deliveries)}).Infoln("Resumed the webhook deliveries")
+		}
+	}
+	for i := 0; i < d.props.Workers; i++ {
+		d.workers.Add(1)
+		go d.work()
+	}
+	return nil
+}
+
+// Stop waits for the attempts in flight, then logs the events still queued as pending deliveries
+// for the next run. The listeners are drained first, no event is published once Stop is called.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.stopOnce.Do(func() { close(d.done) })
+	stopped := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	for {
+		select {
+		case event := <-d.events:
+			d.log(ctx, event)
+		default:
+			return nil
+		}
+	}
+}
+
+// Publish queues the event of the article for the webhooks, it is dropped when the queue is full
+func (d *Dispatcher) Publish(ctx context.Context, event string, article *model.Article) {
+	webhookEvent := &model.WebhookEvent{ID: newID(), Type: event, CreatedAt: d.now().UTC().Truncate(time.Millisecond), Article: article}
+	logger := log.WithFields(log.Fields{"event": event, "article": article.ArticleID})
+	select {
+	case <-d.done:
+		logger.Warnln("The webhooks are stopped, the event is dropped")
+		return
+	default:
+	}
+	select {
+	case d.events <- webhookEvent:
+	default:
+		metrics.ObserveWebhookDropped()
+		logger.Warnln("The webhook queue is full, the event is dropped")
+	}
+}
+
+// Replay attempts a dead delivery again, with as many attempts as a new one
+func (d *Dispatcher) Replay(ctx context.Context, deliveryID string) (*model.Delivery, error) {
+	delivery, err := d.ReadDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != model.DeliveryDead {
+		return nil, model.Errorf(model.ErrDuplicate, "Delivery %s is %s, only dead deliveries can be replayed", deliveryID, delivery.Status)
+	}
+	replayedAt := d.now().UTC().Truncate(time.Millisecond)
+	delivery.Status = model.DeliveryPending
+	delivery.ReplayedAt = &replayedAt
+	delivery.NextAttemptAt = nil
+	// a concurrent replay of the same delivery finds it pending already
+	if err := d.UpdateDeliveryFrom(ctx, delivery, model.DeliveryDead, ""); err != nil {
+		return nil, err
+	}
+	// the worker attempting it owns the delivery from now on
+	replayed := *delivery
+	replayed.Attempts = append([]model.DeliveryAttempt(nil), delivery.Attempts...)
+	d.schedule(delivery)
+	return &replayed, nil
+}
+
+func (d *Dispatcher) work() {
+	defer d.workers.Done()
+	for {
+		select {
+		case <-d.done:
+			return
+		case event := <-d.events:
+			for _, delivery := range d.log(context.Background(), event) {
+				d.schedule(delivery)
+			}
+		case delivery := <-d.due:
+			d.attempt(delivery)
+		}
+	}
+}
+
+// log saves a pending delivery of the event for every webhook it matches
+func (d *Dispatcher) log(ctx context.Context, event *model.WebhookEvent) []*model.Delivery {
+	logger := log.WithFields(log.Fields{"event": event.Type, "article": event.Article.ArticleID})
+	webhooks, err := d.ListWebhooks(ctx)
+	if err != nil {
+		logger.WithError(err).Errorln("Could not list the webhooks, the event is dropped")
+		return nil
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logger.WithError(err).Errorln("Could not encode the event, it is dropped")
+		return nil
+	}
+	var deliveries []*model.Delivery
+	for _, webhook := range webhooks {
+		if !webhook.Matches(event.Type, event.Article) {
+			continue
+		}
+		delivery := &model.Delivery{
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			EventType: event.Type,
+			Payload:   payload,
+			Status:    model.DeliveryPending,
+		}
+		if err := d.SaveDelivery(ctx, delivery); err != nil {
+			logger.WithError(err).WithField("webhook", webhook.ID).Errorln("Could not log the delivery, the event is dropped")
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
+
+// schedule hands the delivery to a worker once its next attempt is due
+func (d *Dispatcher) schedule(delivery *model.Delivery) {
+	var wait time.Duration
+	if delivery.NextAttemptAt != nil {
+		wait = delivery.NextAttemptAt.Sub(d.now())
+	}
+	time.AfterFunc(wait, func() {
+		select {
+		case d.due <- delivery:
+		case <-d.done:
+			// left pending or retrying in the store for the next run
+		}
+	})
+}
+
+// claim takes the delivery in flight for the lease, it fails when another instance claimed it first
+func (d *Dispatcher) claim(ctx context.Context, delivery *model.Delivery) bool {
+	from, owner := delivery.Status, ""
+	if from == model.DeliveryInFlight {
+		// the lease of owner expired, of the instances taking it over only one gets it
+		owner = delivery.Owner
+	}
+	claimed := *delivery
+	expiresAt := d.now().UTC().Truncate(time.Millisecond).Add(d.lease)
+	claimed.Status = model.DeliveryInFlight
+	claimed.Owner = d.owner
+	claimed.LeaseExpiresAt = &expiresAt
+	claimed.NextAttemptAt = nil
+	if err := d.UpdateDeliveryFrom(ctx, &claimed, from, owner); err != nil {
+		logger := log.WithFields(log.Fields{"webhook": delivery.WebhookID, "delivery": delivery.ID})
+		if specificError, ok := err.(*model.Error); ok && specificError.Code == model.ErrDuplicate {
+			logger.Debugln("The delivery was claimed by another instance")
+		} else {
+			logger.WithError(err).Errorln("Could not claim the delivery, it is left for the next run")
+		}
+		return false
+	}
+	*delivery = claimed
+	return true
+}
+
+// attempt claims the delivery, posts it and records the outcome: delivered, retrying after a backoff or dead
+func (d *Dispatcher) attempt(delivery *model.Delivery) {
+	ctx := context.Background()
+	if !d.claim(ctx, delivery) {
+		return
+	}
+	at := d.now().UTC().Truncate(time.Millisecond)
+	attempt := model.DeliveryAttempt{At: at}
+	var retryAfter time.Duration
+	gone := false
+	webhook, err := d.ReadWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		if specificError, ok := err.(*model.Error); ok && specificError.Code == model.ErrNotFound {
+			gone = true
+			err = errors.New("the webhook was deleted")
+		}
+	} else {
+		attempt.StatusCode, retryAfter, err = d.post(ctx, webhook, delivery, at)
+	}
+	latency := d.now().Sub(at)
+	attempt.LatencyMS = latency.Seconds() * 1000
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	delivery.Attempts = append(delivery.Attempts, attempt)
+
+	delivery.NextAttemptAt = nil
+	delivery.Owner = ""
+	delivery.LeaseExpiresAt = nil
+	attempts := attemptsSinceReplay(delivery)
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliveryDelivered
+	case gone || attempts >= d.props.MaxAttempts:
+		delivery.Status = model.DeliveryDead
+	default:
+		delivery.Status = model.DeliveryRetrying
+		wait := d.backoff(attempts)
+		if retryAfter > wait {
+			wait = retryAfter
+			if maxBackoff := time.Duration(d.props.MaxBackoff) * time.Second; wait > maxBackoff {
+				wait = maxBackoff
+			}
+		}
+		next := at.Add(wait)
+		delivery.NextAttemptAt = &next
+	}
+	metrics.ObserveWebhookAttempt(delivery.EventType, delivery.Status, latency)
+
+	logger := log.WithFields(log.Fields{"webhook": delivery.WebhookID, "delivery": delivery.ID, "event": delivery.EventType,
+		"attempt": attempts, "status": delivery.Status})
+	if updateErr := d.UpdateDeliveryFrom(ctx, delivery, model.DeliveryInFlight, d.owner); updateErr != nil {
+		if specificError, ok := updateErr.(*model.Error); ok && specificError.Code == model.ErrDuplicate {
+			logger.Warnln("The lease of the delivery expired before its attempt was recorded, another instance took it over")
+		} else {
+			logger.WithError(updateErr).Errorln("Could not record the delivery attempt, it is left in flight until its lease expires")
+		}
+		return
+	}
+	switch delivery.Status {
+	case model.DeliveryDelivered:
+		logger.Debugln("Delivered the event")
+	case model.DeliveryDead:
+		logger.WithError(err).Errorln("Gave up delivering the event, it can be replayed from the dead letters")
+	default:
+		logger.WithError(err).WithField("next_attempt_at", delivery.NextAttemptAt).Warnln("Could not deliver the event, retrying")
+		d.schedule(delivery)
+	}
+}
+
+// post sends the signed delivery, any answer but a 2xx is a failure. The Retry-After seconds of the receiver
+// are returned along with the failure.
+func (d *Dispatcher) post(ctx context.Context, webhook *model.Webhook, delivery *model.Delivery, at time.Time) (int, time.Duration, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ArticleAPI-Webhooks")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(at.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, at.Unix(), delivery.Payload))
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	// the connection is reused once the body is read
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var retryAfter time.Duration
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return resp.StatusCode, retryAfter, fmt.Errorf("the receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, 0, nil
+}
+
+// backoff is the wait after the nth failed attempt: InitialBackoff doubled after every attempt, up to MaxBackoff
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := time.Duration(d.props.InitialBackoff) * time.Second
+	maxBackoff := time.Duration(d.props.MaxBackoff) * time.Second
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait
+}
+
+// attemptsSinceReplay counts the attempts towards MaxAttempts, those before a replay do not count
+func attemptsSinceReplay(delivery *model.Delivery) int {
+	if delivery.ReplayedAt == nil {
+		return len(delivery.Attempts)
+	}
+	attempts := 0
+	for _, attempt := range delivery.Attempts {
+		if !attempt.At.Before(*delivery.ReplayedAt) {
+			attempts++
+		}
+	}
+	return attempts
+}
+
+// instanceName tells the instances apart in the owners of the deliveries
+func instanceName() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "articleapi"
+	}
+	return hostname
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		// the time keeps ids apart when there is no randomness
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,472 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/client"
+	"github.com/PadmavathiSundaram/ArticleAPI/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore keeps the webhooks and deliveries of the tests
+type memoryStore struct {
+	mu         sync.Mutex
+	webhooks   map[string]*model.Webhook
+	deliveries map[string]*model.Delivery
+}
+
+func newMemoryStore(webhooks ...*model.Webhook) *memoryStore {
+	store := &memoryStore{webhooks: map[string]*model.Webhook{}, deliveries: map[string]*model.Delivery{}}
+	for _, webhook := range webhooks {
+		store.webhooks[webhook.ID] = webhook
+	}
+	return store
+}
+
+func (store *memoryStore) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	webhook.ID, webhook.Secret = strconv.Itoa(len(store.webhooks)+1), "secret"
+	store.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (store *memoryStore) ReadWebhook(ctx context.Context, webhookID string) (*model.Webhook, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	webhook, ok := store.webhooks[webhookID]
+	if !ok {
+		return nil, model.Errorf(model.ErrNotFound, "Webhook %s not found", webhookID)
+	}
+	return webhook, nil
+}
+
+func (store *memoryStore) ListWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	var webhooks []*model.Webhook
+	for _, webhook := range store.webhooks {
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+func (store *memoryStore) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (store *memoryStore) DeleteWebhook(ctx context.Context, webhookID string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.webhooks, webhookID)
+	return nil
+}
+
+func (store *memoryStore) SaveDelivery(ctx context.Context, delivery *model.Delivery) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delivery.ID = "d" + strconv.Itoa(len(store.deliveries)+1)
+	stored := *delivery
+	store.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (store *memoryStore) UpdateDelivery(ctx context.Context, delivery *model.Delivery) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	stored := *delivery
+	stored.Attempts = append([]model.DeliveryAttempt(nil), delivery.Attempts...)
+	store.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (store *memoryStore) UpdateDeliveryFrom(ctx context.Context, delivery *model.Delivery, status string, owner string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if stored, ok := store.deliveries[delivery.ID]; !ok || stored.Status != status || (owner != "" && stored.Owner != owner) {
+		return model.Errorf(model.ErrDuplicate, "Delivery %s is not %s anymore", delivery.ID, status)
+	}
+	stored := *delivery
+	stored.Attempts = append([]model.DeliveryAttempt(nil), delivery.Attempts...)
+	store.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (store *memoryStore) ReadDelivery(ctx context.Context, deliveryID string) (*model.Delivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delivery, ok := store.deliveries[deliveryID]
+	if !ok {
+		return nil, model.Errorf(model.ErrNotFound, "Delivery %s not found", deliveryID)
+	}
+	read := *delivery
+	return &read, nil
+}
+
+func (store *memoryStore) ListDeliveries(ctx context.Context, filter map[string]string) ([]*model.Delivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	var deliveries []*model.Delivery
+	for _, delivery := range store.deliveries {
+		if status, ok := filter["Status"]; ok && delivery.Status != status {
+			continue
+		}
+		read := *delivery
+		deliveries = append(deliveries, &read)
+	}
+	return deliveries, nil
+}
+
+func (store *memoryStore) delivery(id string) model.Delivery {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return *store.deliveries[id]
+}
+
+func article(id string, tags ...string) *model.Article {
+	article := &model.Article{ArticleID: id, Date: "2016-09-22"}
+	for i := range tags {
+		article.Tags = append(article.Tags, &tags[i])
+	}
+	return article
+}
+
+// newTestDispatcher posts to the local receivers of the tests, the private addresses NewDispatcher refuses
+func newTestDispatcher(store client.WebhookStore, props model.WebhookProperties) *Dispatcher {
+	dispatcher := NewDispatcher(store, props)
+	dispatcher.httpClient.Transport = http.DefaultTransport
+	return dispatcher
+}
+
+func TestPublicHost(t *testing.T) {
+	for host, public := range map[string]bool{
+		"indexer.example.com": true,
+		"93.184.216.34":       true,
+		"2606:2800:220:1::":   true,
+		"localhost":           false,
+		"api.localhost.":      false,
+		"127.0.0.1":           false,
+		"0.0.0.0":             false,
+		"10.1.2.3":            false,
+		"172.16.0.1":          false,
+		"192.168.1.1":         false,
+		"169.254.169.254":     false,
+		"::1":                 false,
+		"fe80::1":             false,
+		"fd00::1":             false,
+		"::ffff:127.0.0.1":    false,
+	} {
+		assert.Equal(t, public, PublicHost(host), host)
+	}
+}
+
+func TestRefusePrivateAddresses(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a private address was posted to")
+	}))
+	defer receiver.Close()
+
+	// eg: a name resolving to a private address
+	store := newMemoryStore(&model.Webhook{ID: "internal", URL: strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1), Secret: "s3cret"})
+	dispatcher := NewDispatcher(store, model.WebhookProperties{})
+	defer dispatcher.Stop(context.Background())
+
+	deliveries := dispatcher.log(context.Background(), &model.WebhookEvent{ID: "e1", Type: model.EventArticleCreated, Article: article("1", "health")})
+	assert.Len(t, deliveries, 1)
+	dispatcher.attempt(deliveries[0])
+	refused := store.delivery(deliveries[0].ID)
+	assert.Equal(t, model.DeliveryRetrying, refused.Status)
+	assert.Contains(t, refused.Attempts[0].Error, "is a private address")
+}
+
+func TestDeliverSignedEvents(t *testing.T) {
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer receiver.Close()
+
+	store := newMemoryStore(
+		&model.Webhook{ID: "health", URL: receiver.URL, Tags: []string{"Health"}, Secret: "s3cret"},
+		&model.Webhook{ID: "deleted-only", URL: receiver.URL, Events: []string{model.EventArticleDeleted}, Secret: "s3cret"},
+		&model.Webhook{ID: "science", URL: receiver.URL, Tags: []string{"science"}, Secret: "s3cret"},
+	)
+	dispatcher := newTestDispatcher(store, model.WebhookProperties{Workers: 2})
+	assert.NoError(t, dispatcher.Start(context.Background()))
+	defer dispatcher.Stop(context.Background())
+
+	dispatcher.Publish(context.Background(), model.EventArticleCreated, article("1", "fitness", "health"))
+
+	select {
+	case r := <-received:
+		body := <-bodies
+		assert.Equal(t, model.EventArticleCreated, r.Header.Get(EventHeader))
+		timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, Sign("s3cret", timestamp, body), r.Header.Get(SignatureHeader))
+		var event model.WebhookEvent
+		assert.NoError(t, json.Unmarshal(body, &event))
+		assert.Equal(t, model.EventArticleCreated, event.Type)
+		assert.Equal(t, "1", event.Article.ArticleID)
+
+		assert.Eventually(t, func() bool {
+			return store.delivery(r.Header.Get(DeliveryHeader)).Status == model.DeliveryDelivered
+		}, time.Second, 10*time.Millisecond)
+		delivered := store.delivery(r.Header.Get(DeliveryHeader))
+		assert.Equal(t, "health", delivered.WebhookID)
+		assert.Len(t, delivered.Attempts, 1)
+		assert.Equal(t, 200, delivered.Attempts[0].StatusCode)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the event was not delivered")
+	}
+	select {
+	case r := <-received:
+		t.Fatalf("a webhook not subscribed to the event received delivery %s", r.Header.Get(DeliveryHeader))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRetriesAndDeadLetters(t *testing.T) {
+	status := http.StatusInternalServerError
+	var mu sync.Mutex
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if status == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", "30")
+		}
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	store := newMemoryStore(&model.Webhook{ID: "flaky", URL: receiver.URL, Secret: "s3cret"})
+	// no worker is started, the attempts are made by the test
+	dispatcher := newTestDispatcher(store, model.WebhookProperties{MaxAttempts: 3, InitialBackoff: 10, MaxBackoff: 15})
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	dispatcher.now = func() time.Time { return now }
+	defer dispatcher.Stop(context.Background())
+
+	deliveries := dispatcher.log(context.Background(), &model.WebhookEvent{ID: "e1", Type: model.EventArticleCreated, Article: article("1", "health")})
+	assert.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+
+	dispatcher.attempt(delivery)
+	assert.Equal(t, model.DeliveryRetrying, store.delivery(delivery.ID).Status)
+	assert.Equal(t, now.Add(10*time.Second), *store.delivery(delivery.ID).NextAttemptAt)
+
+	// backoff doubles up to MaxBackoff, a longer Retry-After is capped as well
+	mu.Lock()
+	status = http.StatusServiceUnavailable
+	mu.Unlock()
+	dispatcher.attempt(delivery)
+	assert.Equal(t, now.Add(15*time.Second), *store.delivery(delivery.ID).NextAttemptAt)
+
+	dispatcher.attempt(delivery)
+	dead := store.delivery(delivery.ID)
+	assert.Equal(t, model.DeliveryDead, dead.Status)
+	assert.Nil(t, dead.NextAttemptAt)
+	assert.Len(t, dead.Attempts, 3)
+	assert.Equal(t, "the receiver answered 503 Service Unavailable", dead.Attempts[2].Error)
+
+	// replays get MaxAttempts attempts again
+	_, err := dispatcher.Replay(context.Background(), "d404")
+	assert.Error(t, err)
+	now = now.Add(time.Hour)
+	replayed, err := dispatcher.Replay(context.Background(), delivery.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.DeliveryPending, replayed.Status)
+	_, err = dispatcher.Replay(context.Background(), delivery.ID)
+	assert.EqualError(t, err, fmt.Sprintf("Delivery %s is pending, only dead deliveries can be replayed", delivery.ID))
+
+	delivery, _ = store.ReadDelivery(context.Background(), delivery.ID)
+	dispatcher.attempt(delivery)
+	assert.Equal(t, model.DeliveryRetrying, store.delivery(delivery.ID).Status)
+	mu.Lock()
+	status = http.StatusNoContent
+	mu.Unlock()
+	dispatcher.attempt(delivery)
+	delivered := store.delivery(delivery.ID)
+	assert.Equal(t, model.DeliveryDelivered, delivered.Status)
+	assert.Len(t, delivered.Attempts, 5)
+}
+
+func TestDeletedWebhookDeliveriesAreDead(t *testing.T) {
+	store := newMemoryStore(&model.Webhook{ID: "gone", URL: "http://127.0.0.1:1", Secret: "s3cret"})
+	dispatcher := newTestDispatcher(store, model.WebhookProperties{})
+	defer dispatcher.Stop(context.Background())
+
+	deliveries := dispatcher.log(context.Background(), &model.WebhookEvent{ID: "e1", Type: model.EventArticleCreated, Article: article("1")})
+	assert.Len(t, deliveries, 1)
+	assert.NoError(t, store.DeleteWebhook(context.Background(), "gone"))
+	dispatcher.attempt(deliveries[0])
+	dead := store.delivery(deliveries[0].ID)
+	assert.Equal(t, model.DeliveryDead, dead.Status)
+	assert.Equal(t, "the webhook was deleted", dead.Attempts[0].Error)
+}
+
+func TestStopLogsQueuedEvents(t *testing.T) {
+	store := newMemoryStore(&model.Webhook{ID: "later", URL: "http://127.0.0.1:1", Secret: "s3cret"})
+	dispatcher := newTestDispatcher(store, model.WebhookProperties{QueueSize: 1})
+
+	dispatcher.Publish(context.Background(), model.EventArticleCreated, article("1"))
+	// the queue is full
+	dispatcher.Publish(context.Background(), model.EventArticleCreated, article("2"))
+	assert.NoError(t, dispatcher.Stop(context.Background()))
+
+	pending, err := store.ListDeliveries(context.Background(), map[string]string{"Status": model.DeliveryPending})
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	var event model.WebhookEvent
+	assert.NoError(t, json.Unmarshal(pending[0].Payload, &event))
+	assert.Equal(t, "1", event.Article.ArticleID)
+}
+
+func TestWebhookMatches(t *testing.T) {
+	created := model.EventArticleCreated
+	testScenarios := []struct {
+		Webhook model.Webhook
+		Matches bool
+	}{
+		{model.Webhook{}, true},
+		{model.Webhook{Events: []string{model.EventArticleCreated}, Tags: []string{"HEALTH"}}, true},
+		{model.Webhook{Events: []string{model.EventArticleUpdated}}, false},
+		{model.Webhook{Tags: []string{"science"}}, false},
+	}
+	for _, td := range testScenarios {
+		assert.Equal(t, td.Matches, td.Webhook.Matches(created, article("1", "fitness", "health")), "%+v", td.Webhook)
+	}
+}
+
+// racingStore holds the replays reading a delivery until all of them read it dead
+type racingStore struct {
+	*memoryStore
+	read *sync.WaitGroup
+}
+
+func (store racingStore) ReadDelivery(ctx context.Context, deliveryID string) (*model.Delivery, error) {
+	delivery, err := store.memoryStore.ReadDelivery(ctx, deliveryID)
+	store.read.Done()
+	store.read.Wait()
+	return delivery, err
+}
+
+func TestConcurrentReplaysReplayOnce(t *testing.T) {
+	const replays = 5
+	memory := newMemoryStore(&model.Webhook{ID: "flaky", URL: "http://127.0.0.1:1", Secret: "s3cret"})
+	memory.deliveries["d1"] = &model.Delivery{ID: "d1", WebhookID: "flaky", Status: model.DeliveryDead}
+	var read sync.WaitGroup
+	read.Add(replays)
+	dispatcher := newTestDispatcher(racingStore{memory, &read}, model.WebhookProperties{})
+	defer dispatcher.Stop(context.Background())
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var replayed, refused int
+	for i := 0; i < replays; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := dispatcher.Replay(context.Background(), "d1")
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				assert.Equal(t, model.ErrDuplicate, err.(*model.Error).Code)
+				refused++
+				return
+			}
+			replayed++
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, replayed)
+	assert.Equal(t, replays-1, refused)
+	assert.Equal(t, model.DeliveryPending, memory.delivery("d1").Status)
+}
+
+func TestInstancesClaimDeliveriesOnce(t *testing.T) {
+	var mu sync.Mutex
+	posted := map[string]int{}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		posted[r.Header.Get(DeliveryHeader)]++
+	}))
+	defer receiver.Close()
+
+	store := newMemoryStore(&model.Webhook{ID: "shared", URL: receiver.URL, Secret: "s3cret"})
+	expired, held := time.Now().Add(-time.Second), time.Now().Add(time.Hour)
+	store.deliveries["d1"] = &model.Delivery{ID: "d1", WebhookID: "shared", Status: model.DeliveryPending}
+	store.deliveries["d2"] = &model.Delivery{ID: "d2", WebhookID: "shared", Status: model.DeliveryRetrying}
+	store.deliveries["d3"] = &model.Delivery{ID: "d3", WebhookID: "shared", Status: model.DeliveryInFlight, Owner: "crashed", LeaseExpiresAt: &expired}
+	store.deliveries["d4"] = &model.Delivery{ID: "d4", WebhookID: "shared", Status: model.DeliveryInFlight, Owner: "running", LeaseExpiresAt: &held}
+
+	// the instances resume the same deliveries from the shared store
+	for i := 0; i < 3; i++ {
+		dispatcher := newTestDispatcher(store, model.WebhookProperties{Workers: 2})
+		assert.NoError(t, dispatcher.Start(context.Background()))
+		defer dispatcher.Stop(context.Background())
+	}
+
+	assert.Eventually(t, func() bool {
+		for _, id := range []string{"d1", "d2", "d3"} {
+			if store.delivery(id).Status != model.DeliveryDelivered {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]int{"d1": 1, "d2": 1, "d3": 1}, posted, "a delivery is posted by one instance, once its lease expired")
+	for _, id := range []string{"d1", "d2", "d3"} {
+		delivered := store.delivery(id)
+		assert.Empty(t, delivered.Owner)
+		assert.Nil(t, delivered.LeaseExpiresAt)
+		assert.Len(t, delivered.Attempts, 1)
+	}
+	assert.Equal(t, "running", store.delivery("d4").Owner)
+}
+
+func TestExpiredLeaseIsNotRecorded(t *testing.T) {
+	var store *memoryStore
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the attempt outlives its lease, another instance takes the delivery over
+		takenOver := store.delivery(r.Header.Get(DeliveryHeader))
+		takenOver.Owner = "other"
+		store.UpdateDelivery(context.Background(), &takenOver)
+	}))
+	defer receiver.Close()
+	store = newMemoryStore(&model.Webhook{ID: "slow", URL: receiver.URL, Secret: "s3cret"})
+	dispatcher := newTestDispatcher(store, model.WebhookProperties{})
+	defer dispatcher.Stop(context.Background())
+
+	deliveries := dispatcher.log(context.Background(), &model.WebhookEvent{ID: "e1", Type: model.EventArticleCreated, Article: article("1")})
+	assert.Len(t, deliveries, 1)
+	pending := *deliveries[0]
+	dispatcher.attempt(deliveries[0])
+	stored := store.delivery(pending.ID)
+	assert.Equal(t, model.DeliveryInFlight, stored.Status)
+	assert.Equal(t, "other", stored.Owner)
+	assert.Empty(t, stored.Attempts, "the outcome is left to the instance holding the lease")
+
+	// a delivery claimed already is not attempted again
+	dispatcher.attempt(&pending)
+	assert.Equal(t, stored, store.delivery(pending.ID))
+}